@@ -1,14 +1,19 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
+	"fmt"
 	"log"
 	"log/slog"
 	"os"
+	"time"
 
 	_ "github.com/danblok/pm/docs"
 	"github.com/danblok/pm/internals/handlers"
 	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/storage"
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -22,12 +27,37 @@ import (
 
 // @host localhost:3000
 // @BasePath /api/v1
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
 func main() {
+	setupTokens := flag.Bool("setup-tokens", false, "print setup tokens of accounts without a password and exit")
+	flag.Parse()
+
 	godotenv.Load(".env")
 	url := os.Getenv("POSTGRES_URL")
 	if url == "" {
 		log.Fatal("POSTGRES_URL isn't specified")
 	}
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		log.Fatal("JWT_SECRET isn't specified")
+	}
+
+	storageDir := os.Getenv("STORAGE_DIR")
+	if storageDir == "" {
+		storageDir = "uploads"
+	}
+
+	retention := 30 * 24 * time.Hour
+	if v := os.Getenv("PURGE_RETENTION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatal("PURGE_RETENTION must be a positive duration: ", v)
+		}
+		retention = d
+	}
 
 	db, err := sql.Open("postgres", url)
 	if err != nil {
@@ -39,40 +69,138 @@ func main() {
 		log.Fatal("Couldn't ping to db: ", err)
 	}
 
+	store, err := storage.NewLocal(storageDir)
+	if err != nil {
+		log.Fatal("Couldn't open storage: ", err)
+	}
+
 	app := &handlers.App{
 		Service: &service.Service{
-			DB: db,
+			DB:              db,
+			Secret:          []byte(secret),
+			Storage:         store,
+			DefaultWorkflow: os.Getenv("DEFAULT_WORKFLOW"),
 		},
 		Logger: slog.Default(),
 	}
 
+	if _, err := app.Service.GetWorkflow(""); err != nil {
+		log.Fatal("Couldn't find the default workflow: ", err)
+	}
+
+	if *setupTokens {
+		printSetupTokens(app)
+		return
+	}
+
 	e := echo.New()
+	e.HTTPErrorHandler = app.HTTPErrorHandler
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 
 	api := e.Group("/api/v1")
+	api.POST("/auth/login", app.HandleLogin)
+	api.POST("/auth/refresh", app.HandleRefresh)
+	api.POST("/auth/restore", app.HandleRestoreAccount)
+	api.POST("/auth/password", app.HandleSetPassword)
+	api.POST("/accounts", app.HandlePostAccount)
+
+	api = api.Group("", app.Authenticate)
 	api.GET("/accounts/:id", app.HandleGetAccount)
 	api.GET("/accounts", app.HandleGetAllAccounts)
-	api.POST("/accounts", app.HandlePostAccount)
 	api.PATCH("/accounts/:id", app.HandlePatchAccount)
 	api.DELETE("/accounts/:id", app.HandleDeleteAccount)
+	api.GET("/accounts/:id/trash", app.HandleGetAccountTrash)
+	api.GET("/accounts/:id/projects", app.HandleGetContributedProjects)
+	api.GET("/accounts/:id/tasks", app.HandleGetAssignedTasks)
+	api.GET("/workflows", app.HandleGetWorkflows)
 	api.GET("/projects/:id", app.HandleGetProjectById)
 	api.GET("/projects", app.HandleGetProjectsByOwner)
 	api.POST("/projects", app.HandlePostProject)
 	api.PATCH("/projects/:id", app.HandlePatchProject)
-	api.DELETE("/projects/:id", app.HandleDeleteAccount)
+	api.DELETE("/projects/:id", app.HandleDeleteProject)
+	api.GET("/projects/:id/trash", app.HandleGetProjectTrash)
+	api.POST("/projects/:id/restore", app.HandleRestoreProject)
+	api.GET("/projects/:id/timeline", app.HandleGetTimeline)
+	api.GET("/projects/:id/transitions", app.HandleGetTransitions)
+	api.PUT("/projects/:id/transitions", app.HandlePutTransitions)
+	api.GET("/projects/:id/contributors", app.HandleGetContributors)
+	api.POST("/projects/:id/contributors", app.HandlePostContributor)
+	api.PATCH("/projects/:id/contributors/:aid", app.HandlePatchContributor)
+	api.DELETE("/projects/:id/contributors/:aid", app.HandleDeleteContributor)
+	api.GET("/projects/:id/invitations", app.HandleGetProjectInvitations)
+	api.POST("/projects/:id/invitations", app.HandlePostInvitation)
+	api.DELETE("/projects/:id/invitations/:iid", app.HandleDeleteInvitation)
+	api.GET("/projects/:id/labels", app.HandleGetLabels)
+	api.POST("/projects/:id/labels", app.HandlePostLabel)
+	api.PATCH("/projects/:id/labels/:lid", app.HandlePatchLabel)
+	api.DELETE("/projects/:id/labels/:lid", app.HandleDeleteLabel)
+	api.GET("/invitations", app.HandleGetPendingInvitations)
+	api.POST("/invitations/accept", app.HandleAcceptInvitation)
+	api.POST("/invitations/decline", app.HandleDeclineInvitation)
 	api.GET("/statuses/:id", app.HandleGetStatusById)
 	api.GET("/statuses", app.HandleGetStatusesByOwner)
 	api.POST("/statuses", app.HandlePostStatus)
 	api.PATCH("/statuses/:id", app.HandlePatchStatus)
-	api.DELETE("/statuses/:id", app.HandleDeleteAccount)
+	api.DELETE("/statuses/:id", app.HandleDeleteStatus)
+	api.POST("/statuses/:id/move", app.HandleMoveStatus)
+	api.POST("/statuses/:id/merge", app.HandleMergeStatus)
+	api.POST("/statuses/:id/restore", app.HandleRestoreStatus)
 	api.GET("/tasks/:id", app.HandleGetTaskById)
 	api.GET("/tasks", app.HandleGetTasks)
 	api.POST("/tasks", app.HandlePostTask)
 	api.PATCH("/tasks/:id", app.HandlePatchTask)
-	api.DELETE("/tasks/:id", app.HandleDeleteAccount)
+	api.DELETE("/tasks/:id", app.HandleDeleteTask)
+	api.POST("/tasks/:id/move", app.HandleMoveTask)
+	api.POST("/tasks/:id/restore", app.HandleRestoreTask)
+	api.GET("/tasks/:id/children", app.HandleGetTaskChildren)
+	api.GET("/tasks/:id/tree", app.HandleGetTaskTree)
+	api.GET("/tasks/:id/dependencies", app.HandleGetDependencies)
+	api.POST("/tasks/:id/dependencies", app.HandlePostDependency)
+	api.DELETE("/tasks/:id/dependencies/:bid", app.HandleDeleteDependency)
+	api.GET("/tasks/:id/assignees", app.HandleGetAssignees)
+	api.POST("/tasks/:id/assignees", app.HandlePostAssignee)
+	api.DELETE("/tasks/:id/assignees/:aid", app.HandleDeleteAssignee)
+	api.GET("/tasks/:id/comments", app.HandleGetComments)
+	api.POST("/tasks/:id/comments", app.HandlePostComment)
+	api.PATCH("/tasks/:id/comments/:cid", app.HandlePatchComment)
+	api.DELETE("/tasks/:id/comments/:cid", app.HandleDeleteComment)
+	api.GET("/tasks/:id/comments/:cid/revisions", app.HandleGetCommentRevisions)
+	api.GET("/tasks/:id/attachments", app.HandleGetAttachments)
+	api.POST("/tasks/:id/attachments", app.HandlePostAttachment)
+	api.GET("/tasks/:id/attachments/:fid", app.HandleGetAttachment)
+	api.DELETE("/tasks/:id/attachments/:fid", app.HandleDeleteAttachment)
 
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
+	go purge(app, retention, time.Hour)
 	app.Logger.Info("Server started on http://localhost:3000")
 	e.Logger.Fatal(e.Start(":3000"))
 }
+
+// purge hard deletes records that have been in the trash longer than
+// retention every interval.
+func purge(app *handlers.App, retention, interval time.Duration) {
+	ctx := context.Background()
+	for ; ; time.Sleep(interval) {
+		n, err := app.Service.Purge(ctx, retention)
+		if err != nil {
+			app.Logger.Error("Couldn't purge deleted records", "error", err)
+			continue
+		}
+		if n > 0 {
+			app.Logger.Info("Purged deleted records", "count", n)
+		}
+	}
+}
+
+// printSetupTokens prints a setup token for every account that has no password,
+// so that the accounts can set one at /auth/password.
+func printSetupTokens(app *handlers.App) {
+	tokens, err := app.Service.IssueSetupTokens(context.Background())
+	if err != nil {
+		log.Fatal("Couldn't issue setup tokens: ", err)
+	}
+	for email, token := range tokens {
+		fmt.Printf("%s\t%s\n", email, token)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/types"
 	"github.com/labstack/echo/v4"
 )
 
@@ -12,11 +13,15 @@ import (
 //	@Summary	Returns an account by ID
 //	@Tags		account
 //	@Produce	json
-//	@Param		id	path		string	true	"Account ID"
-//	@Success	200	{object}	types.Account
+//	@Param		id				path		string	true	"Account ID"
+//	@Param		If-None-Match	header		string	false	"ETag of a cached account"
+//	@Success	200				{object}	types.Account
+//	@Header		200				{string}	ETag	"Entity tag of the account"
+//	@Success	304
 //	@Failure	400	{object}	types.HTTPError
 //	@Failure	404	{object}	types.HTTPError
 //	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
 //	@Router		/accounts/{id} [get]
 func (a *App) HandleGetAccount(c echo.Context) error {
 	id := c.Param("id")
@@ -25,7 +30,7 @@ func (a *App) HandleGetAccount(c echo.Context) error {
 		return a.UnwrapError(c, "Service.GetAccountById error: ", err)
 	}
 
-	return c.JSON(http.StatusOK, acc)
+	return versioned(c, acc)
 }
 
 // HandleGetAccounts lists all existing accounts
@@ -33,12 +38,21 @@ func (a *App) HandleGetAccount(c echo.Context) error {
 //	@Summary	Returns all accounts
 //	@Tags		accounts
 //	@Produce	json
-//	@Success	200	{array}	types.Account
-//	@Failure	400
-//	@Failure	500
+//	@Param		params	query		service.ListParams	false	"Pagination, sorting and filtering"
+//	@Success	200		{object}	types.Page[types.Account]
+//	@Failure	400		{object}	types.HTTPError
+//	@Failure	500		{object}	types.HTTPError
+//	@Security	BearerAuth
 //	@Router		/accounts [get]
 func (a *App) HandleGetAllAccounts(c echo.Context) error {
-	accs, err := a.Service.GetAllAccounts(c.Request().Context())
+	params := new(service.ListParams)
+	err := c.Bind(params)
+	if err != nil {
+		return a.UnwrapError(c, "binding in HandleGetAllAccounts input error: ", err)
+	}
+
+	var accs *types.Page[types.Account]
+	accs, err = a.Service.GetAllAccounts(c.Request().Context(), params)
 	if err != nil {
 		return a.UnwrapError(c, "Service.GetAllAccounts error: ", err)
 	}
@@ -53,7 +67,7 @@ func (a *App) HandleGetAllAccounts(c echo.Context) error {
 //	@Accept		json
 //	@Produce	json
 //	@Param		body	body	service.AddAccountInput	true	"object of type AddAccountInput"
-//	@Success	200
+//	@Success	201	{object}	types.Account
 //	@Failure	400	{object}	types.HTTPError
 //	@Failure	404	{object}	types.HTTPError
 //	@Failure	500	{object}	types.HTTPError
@@ -65,35 +79,42 @@ func (a *App) HandlePostAccount(c echo.Context) error {
 		return a.UnwrapError(c, "binding in HandlePostAccount input error: ", err)
 	}
 
-	err = a.Service.AddAccount(c.Request().Context(), &input)
+	acc, err := a.Service.AddAccount(c.Request().Context(), &input)
 	if err != nil {
 		return a.UnwrapError(c, "Service.UpdateAccount error: ", err)
 	}
-	return c.NoContent(http.StatusCreated)
+	return created(c, acc.Id, acc)
 }
 
 // HandlePatchAccount patches an account
 //
 //	@Summary	Patch an account
 //	@Tags		account
-//	@Accept		json
+//	@Accept		json,application/merge-patch+json
 //	@Produce	json
-//	@Param		body	body	service.AddAccountInput	false	"body of type AddAccountInput"
-//	@Param		id		path	string					true	"Account ID"
+//	@Param		body		body	service.UpdateAccountInput	false	"JSON merge patch of type UpdateAccountInput"
+//	@Param		id			path	string						true	"Account ID"
+//	@Param		If-Match	header	string						false	"ETag the account must still have"
 //	@Success	200
 //	@Failure	400	{object}	types.HTTPError
 //	@Failure	404	{object}	types.HTTPError
+//	@Failure	412	{object}	types.HTTPError
 //	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
 //	@Router		/accounts/{id} [patch]
 func (a *App) HandlePatchAccount(c echo.Context) error {
+	ctx, err := ifMatch(c)
+	if err != nil {
+		return a.UnwrapError(c, "If-Match error: ", err)
+	}
 	var input service.UpdateAccountInput
 	input.Id = c.Param("id")
-	err := c.Bind(&input)
+	err = bindPatch(c, &input)
 	if err != nil {
 		return a.UnwrapError(c, "binding in HandlePatchAccount input error: ", err)
 	}
 
-	err = a.Service.UpdateAccount(c.Request().Context(), &input)
+	err = a.Service.UpdateAccount(ctx, &input)
 	if err != nil {
 		return a.UnwrapError(c, "Service.UpdateAccount error: ", err)
 	}
@@ -106,15 +127,24 @@ func (a *App) HandlePatchAccount(c echo.Context) error {
 //	@Tags		account
 //	@Accept		json
 //	@Produce	json
-//	@Param		id	path	string	true	"Account ID"
+//	@Param		id			path	string	true	"Account ID"
+//	@Param		If-Match	header	string	false	"ETag the account must still have"
 //	@Success	200
 //	@Failure	400	{object}	types.HTTPError
+//	@Failure	401	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
 //	@Failure	404	{object}	types.HTTPError
+//	@Failure	412	{object}	types.HTTPError
 //	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
 //	@Router		/accounts/{id} [delete]
 func (a *App) HandleDeleteAccount(c echo.Context) error {
+	ctx, err := ifMatch(c)
+	if err != nil {
+		return a.UnwrapError(c, "If-Match error: ", err)
+	}
 	id := c.Param("id")
-	err := a.Service.DeleteAccountById(c.Request().Context(), id)
+	err = a.Service.DeleteAccountById(ctx, id)
 	if err != nil {
 		return a.UnwrapError(c, "Service.DeleteAccount: ", err)
 	}
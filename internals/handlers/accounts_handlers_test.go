@@ -15,8 +15,6 @@ import (
 )
 
 func TestHandleGetAccount(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	accId := uuid.NewString()
 
 	tests := map[string]struct {
@@ -35,7 +33,7 @@ func TestHandleGetAccount(t *testing.T) {
 		},
 		"non-existent": {
 			input:    uuid.NewString(),
-			wantCode: http.StatusBadRequest,
+			wantCode: http.StatusNotFound,
 			want:     nil,
 		},
 		"invalid id": {
@@ -46,16 +44,12 @@ func TestHandleGetAccount(t *testing.T) {
 	}
 
 	for name, tt := range tests {
+		app, m := setupMemoryApp()
 		if tt.want != nil {
-			_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", tt.want.Id, tt.want.Email, tt.want.Name)
-			if err != nil {
-				t.Fatal(service.ErrFailedToPrepareTest, err)
-			}
+			m.PutAccount(*tt.want)
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("accounts"))
-
 			e := echo.New()
 			req := httptest.NewRequest(http.MethodGet, "/", nil)
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -75,10 +69,9 @@ func TestHandleGetAccount(t *testing.T) {
 }
 
 func TestHandleGetAllAccounts(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	tests := map[string]struct {
 		wantCode int
+		query    string
 		want     []types.Account
 	}{
 		"2 accounts": {
@@ -100,21 +93,25 @@ func TestHandleGetAllAccounts(t *testing.T) {
 			wantCode: http.StatusOK,
 			want:     []types.Account{},
 		},
+		"invalid limit": {
+			query:    "limit=-1",
+			wantCode: http.StatusBadRequest,
+		},
+		"invalid sort": {
+			query:    "sort=password_hash",
+			wantCode: http.StatusBadRequest,
+		},
 	}
 
 	for name, tt := range tests {
+		app, m := setupMemoryApp()
 		for _, acc := range tt.want {
-			_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", acc.Id, acc.Email, acc.Name)
-			if err != nil {
-				t.Fatal(service.ErrFailedToPrepareTest, err)
-			}
+			m.PutAccount(acc)
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("accounts"))
-
 			e := echo.New()
-			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			res := httptest.NewRecorder()
 			c := e.NewContext(req, res)
@@ -129,12 +126,11 @@ func TestHandleGetAllAccounts(t *testing.T) {
 }
 
 func TestHandlePostAccount(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	type input struct {
-		Name   string `json:"name"`
-		Email  string `json:"email"`
-		Avatar string `json:"avatar,omitempty"`
+		Name     string `json:"name"`
+		Email    string `json:"email"`
+		Avatar   string `json:"avatar,omitempty"`
+		Password string `json:"password"`
 	}
 	tests := map[string]struct {
 		wantCode int
@@ -142,35 +138,44 @@ func TestHandlePostAccount(t *testing.T) {
 	}{
 		"succsessfull add": {
 			input: &input{
-				Name:  "username",
-				Email: "username@test.com",
+				Name:     "username",
+				Email:    "username@test.com",
+				Password: "password",
 			},
 			wantCode: http.StatusCreated,
 		},
 		"invalid name": {
 			input: &input{
-				Name:  "",
-				Email: "username@test.com",
+				Name:     "",
+				Email:    "username@test.com",
+				Password: "password",
 			},
 			wantCode: http.StatusBadRequest,
 		},
 		"invalid email": {
 			input: &input{
-				Name:  "Project",
-				Email: "",
+				Name:     "Project",
+				Email:    "",
+				Password: "password",
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		"missing password": {
+			input: &input{
+				Name:  "username",
+				Email: "username@test.com",
 			},
 			wantCode: http.StatusBadRequest,
 		},
 	}
 
 	for name, tt := range tests {
+		app, _ := setupMemoryApp()
 		data, err := json.Marshal(tt.input)
 		if err != nil {
 			t.Fatal(service.ErrFailedToPrepareTest)
 		}
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("accounts"))
-
 			e := echo.New()
 			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -187,8 +192,6 @@ func TestHandlePostAccount(t *testing.T) {
 }
 
 func TestHandleUpdateAccount(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	type input struct {
 		Name   string `json:"name,omitempty"`
 		Email  string `json:"email,omitempty"`
@@ -218,7 +221,7 @@ func TestHandleUpdateAccount(t *testing.T) {
 				Name:  "New project",
 				Email: "newusername@test.com",
 			},
-			wantCode: http.StatusBadRequest,
+			wantCode: http.StatusNotFound,
 		},
 		"invalid id": {
 			param: "invalid-id",
@@ -231,19 +234,16 @@ func TestHandleUpdateAccount(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", acc.Id, acc.Email, acc.Name)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
+		app, m := setupMemoryApp()
+		m.PutAccount(acc)
 		data, err := json.Marshal(tt.input)
 		if err != nil {
 			t.Fatal(service.ErrFailedToPrepareTest)
 		}
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("accounts"))
-
 			e := echo.New()
 			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+			req = req.WithContext(service.WithAccountId(req.Context(), tt.param))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			res := httptest.NewRecorder()
 			c := e.NewContext(req, res)
@@ -261,8 +261,6 @@ func TestHandleUpdateAccount(t *testing.T) {
 }
 
 func TestHandleDeleteAccount(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	acc := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -278,7 +276,7 @@ func TestHandleDeleteAccount(t *testing.T) {
 		},
 		"non-existent": {
 			input:    uuid.NewString(),
-			wantCode: http.StatusBadRequest,
+			wantCode: http.StatusNotFound,
 		},
 		"invalid id": {
 			input:    "invalid-id",
@@ -287,22 +285,19 @@ func TestHandleDeleteAccount(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", acc.Id, acc.Email, acc.Name)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
+		app, m := setupMemoryApp()
+		m.PutAccount(acc)
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("accounts"))
-
 			e := echo.New()
 			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req = req.WithContext(service.WithAccountId(req.Context(), tt.input))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			res := httptest.NewRecorder()
 			c := e.NewContext(req, res)
 			c.SetPath("/:id")
 			c.SetParamNames("id")
 			c.SetParamValues(tt.input)
-			app.HandlePatchAccount(c)
+			app.HandleDeleteAccount(c)
 
 			gotCode := res.Code
 			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/types"
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetAssignees lists assignees of a task
+//
+//	@Summary	Returns all assignees of a task
+//	@Tags		assignees
+//	@Produce	json
+//	@Param		id	path	string	true	"Task ID"
+//	@Success	200	{array}	types.Account
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/tasks/{id}/assignees [get]
+func (a *App) HandleGetAssignees(c echo.Context) error {
+	ctx := c.Request().Context()
+	tId := c.Param("id")
+
+	accs, err := a.Service.GetAssigneesByTaskId(ctx, tId)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, accs)
+}
+
+// HandleGetAssignedTasks lists tasks assigned to an account across all projects
+//
+//	@Summary	Returns tasks assigned to an account
+//	@Tags		tasks
+//	@Produce	json
+//	@Param		id		path		string				true	"Account ID"
+//	@Param		params	query		service.ListParams	false	"Pagination, sorting and filtering"
+//	@Success	200		{object}	types.Page[types.Task]
+//	@Failure	400		{object}	types.HTTPError
+//	@Failure	500		{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/accounts/{id}/tasks [get]
+func (a *App) HandleGetAssignedTasks(c echo.Context) error {
+	ctx := c.Request().Context()
+	aId := c.Param("id")
+	params := new(service.ListParams)
+	err := c.Bind(params)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	var tks *types.Page[types.Task]
+	tks, err = a.Service.GetAssignedTasks(ctx, aId, params)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, tks)
+}
+
+// HandlePostAssignee assigns an account to a task
+//
+//	@Summary	Assign an account to a task
+//	@Tags		assignee
+//	@Accept		json
+//	@Produce	json
+//	@Param		id		path	string					true	"Task ID"
+//	@Param		body	body	service.AssignTaskInput	true	"object of type AssignTaskInput"
+//	@Success	201
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	409	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/tasks/{id}/assignees [post]
+func (a *App) HandlePostAssignee(c echo.Context) error {
+	ctx := c.Request().Context()
+	input := new(service.AssignTaskInput)
+	err := c.Bind(input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	err = a.Service.AssignTask(ctx, input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusCreated)
+}
+
+// HandleDeleteAssignee unassigns an account from a task
+//
+//	@Summary	Unassign an account from a task
+//	@Tags		assignee
+//	@Produce	json
+//	@Param		id	path	string	true	"Task ID"
+//	@Param		aid	path	string	true	"Account ID"
+//	@Success	200
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/tasks/{id}/assignees/{aid} [delete]
+func (a *App) HandleDeleteAssignee(c echo.Context) error {
+	ctx := c.Request().Context()
+	tId := c.Param("id")
+	aId := c.Param("aid")
+
+	err := a.Service.UnassignTask(ctx, tId, aId)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+func TestHandlePostAssignee(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	outsider := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "outsider",
+		Email: "outsider@test.com",
+	}
+	task := types.Task{
+		Id:        uuid.NewString(),
+		Name:      "task",
+		ProjectId: uuid.NewString(),
+		StatusId:  uuid.NewString(),
+	}
+	tests := map[string]struct {
+		wantCode  int
+		caller    string
+		accountId string
+	}{
+		"succsessfull assign": {
+			caller:    owner.Id,
+			accountId: owner.Id,
+			wantCode:  http.StatusCreated,
+		},
+		"invalid account id": {
+			caller:    owner.Id,
+			accountId: "invalid-id",
+			wantCode:  http.StatusBadRequest,
+		},
+		"not a contributor": {
+			caller:    owner.Id,
+			accountId: outsider.Id,
+			wantCode:  http.StatusBadRequest,
+		},
+		"caller outside of the project": {
+			caller:    outsider.Id,
+			accountId: owner.Id,
+			wantCode:  http.StatusForbidden,
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutAccount(outsider)
+		m.PutProject(types.Project{Id: task.ProjectId, Name: "project", OwnerId: owner.Id})
+		m.PutStatus(types.Status{Id: task.StatusId, Name: "todo", ProjectId: task.ProjectId})
+		m.PutTask(task)
+		data, err := json.Marshal(map[string]string{"account_id": tt.accountId})
+		if err != nil {
+			t.Fatal(service.ErrFailedToPrepareTest)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+			req = req.WithContext(service.WithAccountId(req.Context(), tt.caller))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(task.Id)
+			app.HandlePostAssignee(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandlePostAssignee() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/types"
+	"github.com/labstack/echo/v4"
+)
+
+// multipartOverhead is the room left for multipart headers and boundaries
+// when the size of an upload request is limited.
+const multipartOverhead = 1 << 20
+
+// HandleGetAttachments lists attachments of a task
+//
+//	@Summary	Returns all attachments of a task
+//	@Tags		attachments
+//	@Produce	json
+//	@Param		id	path	string	true	"Task ID"
+//	@Success	200	{array}	types.Attachment
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/tasks/{id}/attachments [get]
+func (a *App) HandleGetAttachments(c echo.Context) error {
+	ctx := c.Request().Context()
+	tId := c.Param("id")
+
+	as, err := a.Service.GetAttachmentsByTaskId(ctx, tId)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, as)
+}
+
+// HandleGetAttachment downloads contents of an attachment
+//
+//	@Summary	Download an attachment
+//	@Tags		attachments
+//	@Produce	octet-stream
+//	@Param		id	path	string	true	"Task ID"
+//	@Param		fid	path	string	true	"Attachment ID"
+//	@Success	200	{file}	file
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/tasks/{id}/attachments/{fid} [get]
+func (a *App) HandleGetAttachment(c echo.Context) error {
+	ctx := c.Request().Context()
+	tId := c.Param("id")
+	fId := c.Param("fid")
+
+	at, r, err := a.Service.OpenAttachment(ctx, tId, fId)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+	defer r.Close()
+
+	h := c.Response().Header()
+	h.Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": at.Name}))
+	h.Set(echo.HeaderContentLength, strconv.FormatInt(at.Size, 10))
+	h.Set(echo.HeaderXContentTypeOptions, "nosniff")
+	return c.Stream(http.StatusOK, at.ContentType, r)
+}
+
+// HandlePostAttachment uploads a file and attaches it to a task
+//
+//	@Summary	Upload an attachment
+//	@Tags		attachments
+//	@Accept		multipart/form-data
+//	@Produce	json
+//	@Param		id		path		string	true	"Task ID"
+//	@Param		file	formData	file	true	"File to attach"
+//	@Success	201		{object}	types.Attachment
+//	@Failure	400		{object}	types.HTTPError
+//	@Failure	401		{object}	types.HTTPError
+//	@Failure	403		{object}	types.HTTPError
+//	@Failure	404		{object}	types.HTTPError
+//	@Failure	413		{object}	types.HTTPError
+//	@Failure	500		{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/tasks/{id}/attachments [post]
+func (a *App) HandlePostAttachment(c echo.Context) error {
+	ctx := c.Request().Context()
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, service.MaxAttachmentSize+multipartOverhead)
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			return a.UnwrapError(c, "", service.ErrTooLarge)
+		}
+		return a.UnwrapError(c, "", &service.Error{
+			Err:    service.ErrFailedValidation,
+			Fields: []types.FieldError{{Field: "file", Message: "must be provided"}},
+		})
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+	defer f.Close()
+
+	input := &service.AddAttachmentInput{
+		TaskId:  c.Param("id"),
+		Name:    fh.Filename,
+		Content: f,
+	}
+	at, err := a.Service.AddAttachment(ctx, input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return created(c, at.Id, at)
+}
+
+// HandleDeleteAttachment deletes an attachment
+//
+//	@Summary	Delete an attachment
+//	@Tags		attachments
+//	@Produce	json
+//	@Param		id	path	string	true	"Task ID"
+//	@Param		fid	path	string	true	"Attachment ID"
+//	@Success	200
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	401	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/tasks/{id}/attachments/{fid} [delete]
+func (a *App) HandleDeleteAttachment(c echo.Context) error {
+	ctx := c.Request().Context()
+	tId := c.Param("id")
+	fId := c.Param("fid")
+
+	err := a.Service.DeleteAttachment(ctx, tId, fId)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
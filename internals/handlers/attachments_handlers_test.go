@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/storage"
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+func TestHandlePostAttachment(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	task := types.Task{
+		Id:        uuid.NewString(),
+		Name:      "task",
+		ProjectId: project.Id,
+		StatusId:  uuid.NewString(),
+	}
+	tests := map[string]struct {
+		wantCode int
+		field    string
+		content  []byte
+	}{
+		"succsessfull upload": {
+			field:    "file",
+			content:  []byte("notes"),
+			wantCode: http.StatusCreated,
+		},
+		"missing file": {
+			field:    "other",
+			content:  []byte("notes"),
+			wantCode: http.StatusBadRequest,
+		},
+		"too large": {
+			field:    "file",
+			content:  bytes.Repeat([]byte("a"), service.MaxAttachmentSize+multipartOverhead),
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal("storage err: ", err)
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		app.Service.Storage = store
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: task.StatusId, Name: "todo", ProjectId: project.Id})
+		m.PutTask(task)
+		body := new(bytes.Buffer)
+		mw := multipart.NewWriter(body)
+		fw, err := mw.CreateFormFile(tt.field, "notes.txt")
+		if err != nil {
+			t.Fatal(service.ErrFailedToPrepareTest, err)
+		}
+		if _, err = fw.Write(tt.content); err != nil {
+			t.Fatal(service.ErrFailedToPrepareTest, err)
+		}
+		if err = mw.Close(); err != nil {
+			t.Fatal(service.ErrFailedToPrepareTest, err)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", body)
+			req = req.WithContext(service.WithAccountId(req.Context(), owner.Id))
+			req.Header.Set(echo.HeaderContentType, mw.FormDataContentType())
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(task.Id)
+			app.HandlePostAttachment(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandlePostAttachment() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/labstack/echo/v4"
+)
+
+// AccountIdKey is the key under which Authenticate stores the caller's account id in echo.Context.
+const AccountIdKey = "account_id"
+
+// HandleLogin issues a pair of tokens
+//
+//	@Summary	Log in with email and password
+//	@Tags		auth
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		service.LoginInput	true	"object of type LoginInput"
+//	@Success	200		{object}	types.Tokens
+//	@Failure	400		{object}	types.HTTPError
+//	@Failure	401		{object}	types.HTTPError
+//	@Failure	500		{object}	types.HTTPError
+//	@Router		/auth/login [post]
+func (a *App) HandleLogin(c echo.Context) error {
+	ctx := c.Request().Context()
+	input := new(service.LoginInput)
+	err := c.Bind(input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	tokens, err := a.Service.Login(ctx, input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, tokens)
+}
+
+// HandleRefresh exchanges a refresh token for a new pair of tokens
+//
+//	@Summary	Refresh tokens
+//	@Tags		auth
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		service.RefreshInput	true	"object of type RefreshInput"
+//	@Success	200		{object}	types.Tokens
+//	@Failure	400		{object}	types.HTTPError
+//	@Failure	401		{object}	types.HTTPError
+//	@Failure	500		{object}	types.HTTPError
+//	@Router		/auth/refresh [post]
+func (a *App) HandleRefresh(c echo.Context) error {
+	ctx := c.Request().Context()
+	input := new(service.RefreshInput)
+	err := c.Bind(input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	tokens, err := a.Service.Refresh(ctx, input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, tokens)
+}
+
+// HandleSetPassword sets the first password of an account that has none and issues a pair of tokens
+//
+//	@Summary	Set a first password with a setup token
+//	@Tags		auth
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		service.SetPasswordInput	true	"object of type SetPasswordInput"
+//	@Success	200		{object}	types.Tokens
+//	@Failure	400		{object}	types.HTTPError
+//	@Failure	401		{object}	types.HTTPError
+//	@Failure	500		{object}	types.HTTPError
+//	@Router		/auth/password [post]
+func (a *App) HandleSetPassword(c echo.Context) error {
+	ctx := c.Request().Context()
+	input := new(service.SetPasswordInput)
+	err := c.Bind(input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	tokens, err := a.Service.SetPassword(ctx, input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, tokens)
+}
+
+// Authenticate resolves the caller from the bearer access token and stores
+// its account id both in echo.Context and in the request context.
+func (a *App) Authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		header := c.Request().Header.Get(echo.HeaderAuthorization)
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			return a.UnwrapError(c, "missing bearer token", service.ErrUnauthorized)
+		}
+
+		id, err := a.Service.ParseAccessToken(token)
+		if err != nil {
+			return a.UnwrapError(c, "", err)
+		}
+
+		req := c.Request()
+		c.SetRequest(req.WithContext(service.WithAccountId(req.Context(), id)))
+		c.Set(AccountIdKey, id)
+
+		return next(c)
+	}
+}
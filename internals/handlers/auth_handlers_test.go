@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+func TestHandleLogin(t *testing.T) {
+	acc := service.AddAccountInput{
+		Name:     "username",
+		Email:    "username@test.com",
+		Password: "password",
+	}
+	tests := map[string]struct {
+		wantCode int
+		input    *service.LoginInput
+	}{
+		"succsessfull login": {
+			input:    &service.LoginInput{Email: acc.Email, Password: acc.Password},
+			wantCode: http.StatusOK,
+		},
+		"wrong password": {
+			input:    &service.LoginInput{Email: acc.Email, Password: "wrong password"},
+			wantCode: http.StatusUnauthorized,
+		},
+		"empty email": {
+			input:    &service.LoginInput{Password: acc.Password},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, tt := range tests {
+		app, _ := setupMemoryApp()
+		_, err := app.Service.AddAccount(context.Background(), &acc)
+		if err != nil {
+			t.Fatal(service.ErrFailedToPrepareTest, err)
+		}
+		data, err := json.Marshal(tt.input)
+		if err != nil {
+			t.Fatal(service.ErrFailedToPrepareTest)
+		}
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			app.HandleLogin(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandleLogin() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	app, _ := setupMemoryApp()
+
+	ctx := context.Background()
+	acc := service.AddAccountInput{
+		Name:     "username",
+		Email:    "username@test.com",
+		Password: "password",
+	}
+	_, err := app.Service.AddAccount(ctx, &acc)
+	if err != nil {
+		t.Fatal(service.ErrFailedToPrepareTest, err)
+	}
+	tokens, err := app.Service.Login(ctx, &service.LoginInput{Email: acc.Email, Password: acc.Password})
+	if err != nil {
+		t.Fatal(service.ErrFailedToPrepareTest, err)
+	}
+	accId, err := app.Service.ParseAccessToken(tokens.AccessToken)
+	if err != nil {
+		t.Fatal(service.ErrFailedToPrepareTest, err)
+	}
+	tests := map[string]struct {
+		header   string
+		wantCode int
+		wantId   string
+	}{
+		"valid access token": {
+			header:   "Bearer " + tokens.AccessToken,
+			wantCode: http.StatusOK,
+			wantId:   accId,
+		},
+		"refresh token": {
+			header:   "Bearer " + tokens.RefreshToken,
+			wantCode: http.StatusUnauthorized,
+		},
+		"malformed token": {
+			header:   "Bearer malformed",
+			wantCode: http.StatusUnauthorized,
+		},
+		"missing header": {
+			header:   "",
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(echo.HeaderAuthorization, tt.header)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+
+			var gotId string
+			app.Authenticate(func(c echo.Context) error {
+				gotId, _ = service.AccountIdFromContext(c.Request().Context())
+				return c.NoContent(http.StatusOK)
+			})(c)
+
+			if diff := cmp.Diff(tt.wantCode, res.Code); diff != "" {
+				t.Fatalf("Authenticate() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantId, gotId); diff != "" {
+				t.Fatalf("Authenticate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHandleSetPassword(t *testing.T) {
+	acc := types.Account{Id: uuid.NewString(), Name: "legacy", Email: "legacy@test.com"}
+	tests := map[string]struct {
+		wantCode int
+		password string
+		token    bool
+	}{
+		"succsessfull set": {
+			password: "password",
+			token:    true,
+			wantCode: http.StatusOK,
+		},
+		"short password": {
+			password: "pass",
+			token:    true,
+			wantCode: http.StatusBadRequest,
+		},
+		"invalid token": {
+			password: "password",
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		m.PutAccount(acc)
+		input := service.SetPasswordInput{SetupToken: "invalid", Password: tt.password}
+		if tt.token {
+			tokens, err := app.Service.IssueSetupTokens(context.Background())
+			if err != nil {
+				t.Fatal(service.ErrFailedToPrepareTest, err)
+			}
+			input.SetupToken = tokens[acc.Email]
+		}
+		data, err := json.Marshal(input)
+		if err != nil {
+			t.Fatal(service.ErrFailedToPrepareTest)
+		}
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			app.HandleSetPassword(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandleSetPassword() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/types"
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetComments lists top-level comments of a task with their replies
+//
+//	@Summary	Returns comments of a task
+//	@Tags		comments
+//	@Produce	json
+//	@Param		id		path		string				true	"Task ID"
+//	@Param		params	query		service.ListParams	false	"Pagination, sorting and filtering"
+//	@Success	200		{object}	types.Page[types.Comment]
+//	@Failure	400		{object}	types.HTTPError
+//	@Failure	500		{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/tasks/{id}/comments [get]
+func (a *App) HandleGetComments(c echo.Context) error {
+	ctx := c.Request().Context()
+	tId := c.Param("id")
+	params := new(service.ListParams)
+	err := c.Bind(params)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	var cs *types.Page[types.Comment]
+	cs, err = a.Service.GetCommentsByTaskId(ctx, tId, params)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, cs)
+}
+
+// HandlePostComment adds a comment or a reply to a task on behalf of the caller
+//
+//	@Summary	Create a new comment
+//	@Tags		comments
+//	@Accept		json
+//	@Produce	json
+//	@Param		id		path		string					true	"Task ID"
+//	@Param		body	body		service.AddCommentInput	true	"object of type AddCommentInput"
+//	@Success	201		{object}	types.Comment
+//	@Failure	400		{object}	types.HTTPError
+//	@Failure	401		{object}	types.HTTPError
+//	@Failure	403		{object}	types.HTTPError
+//	@Failure	404		{object}	types.HTTPError
+//	@Failure	500		{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/tasks/{id}/comments [post]
+func (a *App) HandlePostComment(c echo.Context) error {
+	ctx := c.Request().Context()
+	input := new(service.AddCommentInput)
+	err := c.Bind(input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	cm, err := a.Service.AddComment(ctx, input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return created(c, cm.Id, cm)
+}
+
+// HandlePatchComment edits a comment, the previous body is kept in its revisions
+//
+//	@Summary	Edit a comment
+//	@Tags		comments
+//	@Accept		json
+//	@Produce	json
+//	@Param		id		path	string						true	"Task ID"
+//	@Param		cid		path	string						true	"Comment ID"
+//	@Param		body	body	service.UpdateCommentInput	true	"object of type UpdateCommentInput"
+//	@Success	200
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	401	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/tasks/{id}/comments/{cid} [patch]
+func (a *App) HandlePatchComment(c echo.Context) error {
+	ctx := c.Request().Context()
+	input := new(service.UpdateCommentInput)
+	err := c.Bind(input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	err = a.Service.UpdateComment(ctx, input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// HandleDeleteComment deletes a comment with its replies
+//
+//	@Summary	Delete a comment
+//	@Tags		comments
+//	@Produce	json
+//	@Param		id	path	string	true	"Task ID"
+//	@Param		cid	path	string	true	"Comment ID"
+//	@Success	200
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	401	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/tasks/{id}/comments/{cid} [delete]
+func (a *App) HandleDeleteComment(c echo.Context) error {
+	ctx := c.Request().Context()
+	tId := c.Param("id")
+	cId := c.Param("cid")
+
+	err := a.Service.DeleteComment(ctx, tId, cId)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// HandleGetCommentRevisions lists previous bodies of a comment
+//
+//	@Summary	Returns edit history of a comment
+//	@Tags		comments
+//	@Produce	json
+//	@Param		id	path	string	true	"Task ID"
+//	@Param		cid	path	string	true	"Comment ID"
+//	@Success	200	{array}	types.CommentRevision
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/tasks/{id}/comments/{cid}/revisions [get]
+func (a *App) HandleGetCommentRevisions(c echo.Context) error {
+	ctx := c.Request().Context()
+	tId := c.Param("id")
+	cId := c.Param("cid")
+
+	revs, err := a.Service.GetCommentRevisions(ctx, tId, cId)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, revs)
+}
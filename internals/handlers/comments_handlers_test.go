@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+func TestHandlePostComment(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	task := types.Task{
+		Id:        uuid.NewString(),
+		Name:      "task",
+		ProjectId: project.Id,
+		StatusId:  uuid.NewString(),
+	}
+	tests := map[string]struct {
+		wantCode int
+		input    map[string]string
+	}{
+		"succsessfull add": {
+			input:    map[string]string{"body": "comment"},
+			wantCode: http.StatusCreated,
+		},
+		"empty body": {
+			input:    map[string]string{"body": ""},
+			wantCode: http.StatusBadRequest,
+		},
+		"non-existent parent": {
+			input:    map[string]string{"body": "reply", "parent_id": uuid.NewString()},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: task.StatusId, Name: "todo", ProjectId: project.Id})
+		m.PutTask(task)
+		data, err := json.Marshal(tt.input)
+		if err != nil {
+			t.Fatal(service.ErrFailedToPrepareTest)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+			req = req.WithContext(service.WithAccountId(req.Context(), owner.Id))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(task.Id)
+			app.HandlePostComment(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandlePostComment() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetContributors lists contributors of a project
+//
+//	@Summary	Returns all contributors of a project
+//	@Tags		contributors
+//	@Produce	json
+//	@Param		id	path	string	true	"Project ID"
+//	@Success	200	{array}	types.Account
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/projects/{id}/contributors [get]
+func (a *App) HandleGetContributors(c echo.Context) error {
+	ctx := c.Request().Context()
+	pId := c.Param("id")
+
+	accs, err := a.Service.GetContributorsByProjectId(ctx, pId)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, accs)
+}
+
+// HandleGetContributedProjects lists projects an account contributes to
+//
+//	@Summary	Returns all projects an account contributes to
+//	@Tags		projects
+//	@Produce	json
+//	@Param		id	path	string	true	"Account ID"
+//	@Success	200	{array}	types.Project
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/accounts/{id}/projects [get]
+func (a *App) HandleGetContributedProjects(c echo.Context) error {
+	ctx := c.Request().Context()
+	aId := c.Param("id")
+
+	pjs, err := a.Service.GetContributedProjectsByAccountId(ctx, aId)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, pjs)
+}
+
+// HandlePostContributor adds a contributor to a project
+//
+//	@Summary	Add a contributor to a project
+//	@Tags		contributor
+//	@Accept		json
+//	@Produce	json
+//	@Param		id		path	string						true	"Project ID"
+//	@Param		body	body	service.AddContributorInput	true	"object of type AddContributorInput"
+//	@Success	201
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/projects/{id}/contributors [post]
+func (a *App) HandlePostContributor(c echo.Context) error {
+	ctx := c.Request().Context()
+	input := new(service.AddContributorInput)
+	err := c.Bind(input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	err = a.Service.AddContributor(ctx, input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusCreated)
+}
+
+// HandlePatchContributor changes a role of a contributor
+//
+//	@Summary	Change a role of a contributor
+//	@Tags		contributor
+//	@Accept		json
+//	@Produce	json
+//	@Param		id		path	string							true	"Project ID"
+//	@Param		aid		path	string							true	"Account ID"
+//	@Param		body	body	service.UpdateContributorInput	true	"object of type UpdateContributorInput"
+//	@Success	200
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/projects/{id}/contributors/{aid} [patch]
+func (a *App) HandlePatchContributor(c echo.Context) error {
+	ctx := c.Request().Context()
+	input := new(service.UpdateContributorInput)
+	err := c.Bind(input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	err = a.Service.UpdateContributor(ctx, input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// HandleDeleteContributor removes a contributor from a project
+//
+//	@Summary	Remove a contributor from a project
+//	@Tags		contributor
+//	@Produce	json
+//	@Param		id	path	string	true	"Project ID"
+//	@Param		aid	path	string	true	"Account ID"
+//	@Success	200
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/projects/{id}/contributors/{aid} [delete]
+func (a *App) HandleDeleteContributor(c echo.Context) error {
+	ctx := c.Request().Context()
+	pId := c.Param("id")
+	aId := c.Param("aid")
+
+	err := a.Service.DeleteContributor(ctx, pId, aId)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+func TestHandlePostContributor(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	contributor := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "contributor",
+		Email: "contributor@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	tests := map[string]struct {
+		wantCode  int
+		caller    string
+		accountId string
+	}{
+		"succsessfull add": {
+			caller:    owner.Id,
+			accountId: contributor.Id,
+			wantCode:  http.StatusCreated,
+		},
+		"invalid account id": {
+			caller:    owner.Id,
+			accountId: "invalid-id",
+			wantCode:  http.StatusBadRequest,
+		},
+		"not an owner": {
+			caller:    contributor.Id,
+			accountId: contributor.Id,
+			wantCode:  http.StatusForbidden,
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutAccount(contributor)
+		m.PutProject(project)
+		data, err := json.Marshal(map[string]string{"account_id": tt.accountId})
+		if err != nil {
+			t.Fatal(service.ErrFailedToPrepareTest)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+			req = req.WithContext(service.WithAccountId(req.Context(), tt.caller))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(project.Id)
+			app.HandlePostContributor(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandlePostContributor() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHandleDeleteContributor(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	contributor := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "contributor",
+		Email: "contributor@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	tests := map[string]struct {
+		wantCode int
+		caller   string
+	}{
+		"removed by owner": {
+			caller:   owner.Id,
+			wantCode: http.StatusOK,
+		},
+		"removed by other": {
+			caller:   uuid.NewString(),
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutAccount(contributor)
+		m.PutProject(project)
+		m.PutContributor(project.Id, contributor.Id, types.RoleMember)
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodDelete, "/", nil)
+			req = req.WithContext(service.WithAccountId(req.Context(), tt.caller))
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id/contributors/:aid")
+			c.SetParamNames("id", "aid")
+			c.SetParamValues(project.Id, contributor.Id)
+			app.HandleDeleteContributor(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandleDeleteContributor() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetDependencies lists tasks blocking a task and tasks blocked by it
+//
+//	@Summary	Returns dependencies of a task
+//	@Tags		dependencies
+//	@Produce	json
+//	@Param		id	path		string	true	"Task ID"
+//	@Success	200	{object}	types.Dependencies
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/tasks/{id}/dependencies [get]
+func (a *App) HandleGetDependencies(c echo.Context) error {
+	ctx := c.Request().Context()
+	tId := c.Param("id")
+
+	deps, err := a.Service.GetDependenciesByTaskId(ctx, tId)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, deps)
+}
+
+// HandlePostDependency makes a task blocked by another task
+//
+//	@Summary	Add a dependency
+//	@Tags		dependencies
+//	@Accept		json
+//	@Produce	json
+//	@Param		id		path		string						true	"Blocked task ID"
+//	@Param		body	body		service.AddDependencyInput	true	"object of type AddDependencyInput"
+//	@Success	201		{object}	types.Dependency
+//	@Failure	400		{object}	types.HTTPError
+//	@Failure	403		{object}	types.HTTPError
+//	@Failure	404		{object}	types.HTTPError
+//	@Failure	409		{object}	types.HTTPError
+//	@Failure	422		{object}	types.HTTPError
+//	@Failure	500		{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/tasks/{id}/dependencies [post]
+func (a *App) HandlePostDependency(c echo.Context) error {
+	ctx := c.Request().Context()
+	input := new(service.AddDependencyInput)
+	err := c.Bind(input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	d, err := a.Service.AddDependency(ctx, input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return created(c, d.BlockerId, d)
+}
+
+// HandleDeleteDependency removes a dependency of a task
+//
+//	@Summary	Remove a dependency
+//	@Tags		dependencies
+//	@Produce	json
+//	@Param		id	path	string	true	"Blocked task ID"
+//	@Param		bid	path	string	true	"Blocker task ID"
+//	@Success	200
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/tasks/{id}/dependencies/{bid} [delete]
+func (a *App) HandleDeleteDependency(c echo.Context) error {
+	ctx := c.Request().Context()
+	tId := c.Param("id")
+	bId := c.Param("bid")
+
+	err := a.Service.RemoveDependency(ctx, tId, bId)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
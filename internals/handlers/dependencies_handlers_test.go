@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// dependencyFixture is a project of owner where design blocks build and
+// build blocks release.
+type dependencyFixture struct {
+	owner, outsider        types.Account
+	projectId, statusId    string
+	design, build, release string
+}
+
+func newDependencyFixture() dependencyFixture {
+	return dependencyFixture{
+		owner:     types.Account{Id: uuid.NewString(), Name: "owner", Email: "owner@test.com"},
+		outsider:  types.Account{Id: uuid.NewString(), Name: "outsider", Email: "outsider@test.com"},
+		projectId: uuid.NewString(),
+		statusId:  uuid.NewString(),
+		design:    uuid.NewString(),
+		build:     uuid.NewString(),
+		release:   uuid.NewString(),
+	}
+}
+
+func (f dependencyFixture) put(m *service.Memory) {
+	m.PutAccount(f.owner)
+	m.PutAccount(f.outsider)
+	m.PutProject(types.Project{Id: f.projectId, Name: "project", OwnerId: f.owner.Id})
+	m.PutStatus(types.Status{Id: f.statusId, Name: "todo", ProjectId: f.projectId})
+	for _, id := range []string{f.design, f.build, f.release} {
+		m.PutTask(types.Task{Id: id, Name: "task", ProjectId: f.projectId, StatusId: f.statusId})
+	}
+	m.PutDependency(f.build, f.design)
+	m.PutDependency(f.release, f.build)
+}
+
+// ignoreMessage compares error envelopes by their codes and fields.
+var ignoreMessage = cmpopts.IgnoreFields(types.HTTPError{}, "Message")
+
+func TestHandleGetDependencies(t *testing.T) {
+	f := newDependencyFixture()
+	tests := map[string]struct {
+		wantCode int
+		want     *types.HTTPError
+		input    string
+	}{
+		"existing": {
+			input:    f.build,
+			wantCode: http.StatusOK,
+		},
+		"invalid id": {
+			input:    "invalid-id",
+			wantCode: http.StatusBadRequest,
+			want: &types.HTTPError{
+				Code:   CodeValidation,
+				Fields: []types.FieldError{{Field: "task_id", Message: "must be a valid UUID"}},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		f.put(m)
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req = req.WithContext(service.WithAccountId(req.Context(), f.owner.Id))
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.input)
+			app.HandleGetDependencies(c)
+
+			if diff := cmp.Diff(tt.wantCode, res.Code); diff != "" {
+				t.Fatalf("HandleGetDependencies() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.want, errorBody(t, res), ignoreMessage); diff != "" {
+				t.Fatalf("HandleGetDependencies() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHandlePostDependency(t *testing.T) {
+	f := newDependencyFixture()
+	tests := map[string]struct {
+		wantCode  int
+		want      *types.HTTPError
+		caller    string
+		taskId    string
+		blockerId string
+	}{
+		"succsessfull add": {
+			caller:    f.owner.Id,
+			taskId:    f.release,
+			blockerId: f.design,
+			wantCode:  http.StatusCreated,
+		},
+		"invalid blocker id": {
+			caller:    f.owner.Id,
+			taskId:    f.release,
+			blockerId: "invalid-id",
+			wantCode:  http.StatusBadRequest,
+			want: &types.HTTPError{
+				Code:   CodeValidation,
+				Fields: []types.FieldError{{Field: "blocker_id", Message: "must be a valid UUID"}},
+			},
+		},
+		"caller outside of the project": {
+			caller:    f.outsider.Id,
+			taskId:    f.release,
+			blockerId: f.design,
+			wantCode:  http.StatusForbidden,
+			want:      &types.HTTPError{Code: CodeForbidden},
+		},
+		"non-existent task": {
+			caller:    f.owner.Id,
+			taskId:    uuid.NewString(),
+			blockerId: f.design,
+			wantCode:  http.StatusNotFound,
+			want:      &types.HTTPError{Code: CodeNotFound},
+		},
+		"duplicate": {
+			caller:    f.owner.Id,
+			taskId:    f.build,
+			blockerId: f.design,
+			wantCode:  http.StatusConflict,
+			want:      &types.HTTPError{Code: CodeConflict},
+		},
+		"cycle": {
+			caller:    f.owner.Id,
+			taskId:    f.design,
+			blockerId: f.release,
+			wantCode:  http.StatusUnprocessableEntity,
+			want: &types.HTTPError{
+				Code:   CodeUnprocessable,
+				Fields: []types.FieldError{{Field: "blocker_id", Message: "must not be blocked by the task directly or transitively"}},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		f.put(m)
+		data, err := json.Marshal(map[string]string{"blocker_id": tt.blockerId})
+		if err != nil {
+			t.Fatal(service.ErrFailedToPrepareTest)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+			req = req.WithContext(service.WithAccountId(req.Context(), tt.caller))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.taskId)
+			app.HandlePostDependency(c)
+
+			if diff := cmp.Diff(tt.wantCode, res.Code); diff != "" {
+				t.Fatalf("HandlePostDependency() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.want, errorBody(t, res), ignoreMessage); diff != "" {
+				t.Fatalf("HandlePostDependency() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHandleDeleteDependency(t *testing.T) {
+	f := newDependencyFixture()
+	tests := map[string]struct {
+		wantCode  int
+		want      *types.HTTPError
+		caller    string
+		taskId    string
+		blockerId string
+	}{
+		"succsessfull delete": {
+			caller:    f.owner.Id,
+			taskId:    f.build,
+			blockerId: f.design,
+			wantCode:  http.StatusOK,
+		},
+		"invalid blocker id": {
+			caller:    f.owner.Id,
+			taskId:    f.build,
+			blockerId: "invalid-id",
+			wantCode:  http.StatusBadRequest,
+			want: &types.HTTPError{
+				Code:   CodeValidation,
+				Fields: []types.FieldError{{Field: "blocker_id", Message: "must be a valid UUID"}},
+			},
+		},
+		"caller outside of the project": {
+			caller:    f.outsider.Id,
+			taskId:    f.build,
+			blockerId: f.design,
+			wantCode:  http.StatusForbidden,
+			want:      &types.HTTPError{Code: CodeForbidden},
+		},
+		"non-existent dependency": {
+			caller:    f.owner.Id,
+			taskId:    f.release,
+			blockerId: f.design,
+			wantCode:  http.StatusNotFound,
+			want:      &types.HTTPError{Code: CodeNotFound},
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		f.put(m)
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodDelete, "/", nil)
+			req = req.WithContext(service.WithAccountId(req.Context(), tt.caller))
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id/:bid")
+			c.SetParamNames("id", "bid")
+			c.SetParamValues(tt.taskId, tt.blockerId)
+			app.HandleDeleteDependency(c)
+
+			if diff := cmp.Diff(tt.wantCode, res.Code); diff != "" {
+				t.Fatalf("HandleDeleteDependency() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.want, errorBody(t, res), ignoreMessage); diff != "" {
+				t.Fatalf("HandleDeleteDependency() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"net/http"
+	"path"
+	"strings"
 
 	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/types"
 	"github.com/labstack/echo/v4"
 )
 
@@ -14,10 +18,160 @@ type App struct {
 	Logger  *slog.Logger
 }
 
+// Error codes returned in types.HTTPError.
+const (
+	CodeValidation    = "validation_failed"
+	CodeNotFound      = "not_found"
+	CodeConflict      = "conflict"
+	CodeUnprocessable = "unprocessable_entity"
+	CodeUnauthorized  = "unauthorized"
+	CodeForbidden     = "forbidden"
+	CodeInternal      = "internal"
+	CodeBadRequest    = "bad_request"
+	CodeTooLarge      = "too_large"
+
+	CodePreconditionFailed = "precondition_failed"
+)
+
+var errorResponses = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{service.ErrFailedValidation, http.StatusBadRequest, CodeValidation},
+	{service.ErrNotFound, http.StatusNotFound, CodeNotFound},
+	{service.ErrFailedToUpdate, http.StatusNotFound, CodeNotFound},
+	{service.ErrConflict, http.StatusConflict, CodeConflict},
+	{service.ErrFailedToInsert, http.StatusConflict, CodeConflict},
+	{service.ErrConcurrentUpdate, http.StatusConflict, CodeConflict},
+	{service.ErrUnprocessable, http.StatusUnprocessableEntity, CodeUnprocessable},
+	{service.ErrUnauthorized, http.StatusUnauthorized, CodeUnauthorized},
+	{service.ErrForbidden, http.StatusForbidden, CodeForbidden},
+	{service.ErrTooLarge, http.StatusRequestEntityTooLarge, CodeTooLarge},
+	{service.ErrPreconditionFailed, http.StatusPreconditionFailed, CodePreconditionFailed},
+	{service.ErrInternal, http.StatusInternalServerError, CodeInternal},
+}
+
+// UnwrapError logs err and responds with types.HTTPError that matches it.
 func (a *App) UnwrapError(c echo.Context, logMsg string, err error) error {
-	a.Logger.Error(logMsg, err)
-	if errors.Is(err, service.ErrInternal) {
-		return c.NoContent(http.StatusInternalServerError)
+	a.Logger.Error(logMsg, "err", err)
+	status, body := errorResponse(err)
+	return c.JSON(status, body)
+}
+
+// created responds with 201, the created resource v and a Location header
+// that points to it under the path of the current request.
+func created(c echo.Context, id string, v any) error {
+	c.Response().Header().Set(echo.HeaderLocation, path.Join(c.Request().URL.Path, id))
+	return c.JSON(http.StatusCreated, v)
+}
+
+// Headers of conditional requests, echo doesn't define them.
+const (
+	headerETag        = "ETag"
+	headerIfMatch     = "If-Match"
+	headerIfNoneMatch = "If-None-Match"
+)
+
+// mimeMergePatch is the media type of JSON merge patches (RFC 7396).
+const mimeMergePatch = "application/merge-patch+json"
+
+// bindPatch binds the request like Bind, a body sent as a JSON merge patch is bound as JSON.
+func bindPatch(c echo.Context, input any) error {
+	req := c.Request()
+	if strings.HasPrefix(req.Header.Get(echo.HeaderContentType), mimeMergePatch) {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	}
-	return c.NoContent(http.StatusBadRequest)
+	return c.Bind(input)
+}
+
+// versioned responds with v and its ETag,
+// or with 304 if the ETag matches If-None-Match of the request.
+func versioned(c echo.Context, v any) error {
+	tag, err := service.ETag(v)
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set(headerETag, tag)
+
+	for _, t := range strings.Split(c.Request().Header.Get(headerIfNoneMatch), ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == "*" || t == tag {
+			return c.NoContent(http.StatusNotModified)
+		}
+	}
+
+	return c.JSON(http.StatusOK, v)
+}
+
+// ifMatch returns the context of the request that carries the entity tag
+// of If-Match, so the service only changes the resource if it still has it.
+// If-Match must be * or a single strong entity tag.
+//
+// Returned errors: service.ErrPreconditionFailed
+func ifMatch(c echo.Context) (context.Context, error) {
+	ctx := c.Request().Context()
+	tag := strings.TrimSpace(c.Request().Header.Get(headerIfMatch))
+	if tag == "" || tag == "*" {
+		return ctx, nil
+	}
+
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' || strings.Contains(tag[1:len(tag)-1], `"`) {
+		return nil, &service.Error{Err: service.ErrPreconditionFailed, Message: "If-Match must be * or an entity tag returned in ETag"}
+	}
+
+	return service.WithETag(ctx, tag), nil
+}
+
+// HTTPErrorHandler responds to errors that were returned by handlers
+// and middlewares with the same envelope as UnwrapError.
+func (a *App) HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+	if err := a.UnwrapError(c, "unhandled error", err); err != nil {
+		a.Logger.Error("failed to send error response", "err", err)
+	}
+}
+
+func errorResponse(err error) (int, *types.HTTPError) {
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		msg, ok := he.Message.(string)
+		if !ok {
+			msg = http.StatusText(he.Code)
+		}
+		code := CodeBadRequest
+		switch he.Code {
+		case http.StatusNotFound:
+			code = CodeNotFound
+		case http.StatusUnauthorized:
+			code = CodeUnauthorized
+		case http.StatusForbidden:
+			code = CodeForbidden
+		case http.StatusRequestEntityTooLarge:
+			code = CodeTooLarge
+		case http.StatusInternalServerError:
+			code = CodeInternal
+		}
+		return he.Code, &types.HTTPError{Code: code, Message: msg}
+	}
+
+	for _, r := range errorResponses {
+		if !errors.Is(err, r.err) {
+			continue
+		}
+
+		body := &types.HTTPError{Code: r.code, Message: r.err.Error()}
+		var se *service.Error
+		if errors.As(err, &se) {
+			if se.Message != "" {
+				body.Message = se.Message
+			}
+			body.Fields = se.Fields
+		}
+		return r.status, body
+	}
+
+	return http.StatusInternalServerError, &types.HTTPError{Code: CodeInternal, Message: service.ErrInternal.Error()}
 }
@@ -1,35 +1,277 @@
 package handlers
 
 import (
-	"database/sql"
-	"fmt"
+	"encoding/json"
+	"errors"
 	"log/slog"
-	"os"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/danblok/pm/internals/service"
-	_ "github.com/lib/pq"
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/labstack/echo/v4"
 )
 
-func setupApp(t *testing.T) (*App, func(...string) func()) {
-	db, err := sql.Open("postgres", os.Getenv("POSTGRES_URL"))
+// setupMemoryApp returns an app that keeps its data in the returned memory.
+func setupMemoryApp() (*App, *service.Memory) {
+	m := service.NewMemory()
+	s := m.Service()
+	s.Secret = []byte("secret")
+	return &App{Service: s, Logger: slog.Default()}, m
+}
+
+// errorBody returns the error envelope of the response or nil if it succeeded.
+func errorBody(t *testing.T, res *httptest.ResponseRecorder) *types.HTTPError {
+	if res.Code < http.StatusBadRequest {
+		return nil
+	}
+	body := new(types.HTTPError)
+	if err := json.NewDecoder(res.Body).Decode(body); err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestUnwrapError(t *testing.T) {
+	app := &App{Logger: slog.Default()}
+
+	tests := map[string]struct {
+		input    error
+		wantCode int
+		want     *types.HTTPError
+	}{
+		"validation": {
+			input:    &service.Error{Err: service.ErrFailedValidation, Fields: []types.FieldError{{Field: "name", Message: "must not be empty"}}},
+			wantCode: http.StatusBadRequest,
+			want: &types.HTTPError{
+				Code:    CodeValidation,
+				Message: "failed validation",
+				Fields:  []types.FieldError{{Field: "name", Message: "must not be empty"}},
+			},
+		},
+		"not found": {
+			input:    service.ErrNotFound,
+			wantCode: http.StatusNotFound,
+			want:     &types.HTTPError{Code: CodeNotFound, Message: "not found"},
+		},
+		"conflict": {
+			input:    &service.Error{Err: service.ErrConflict, Message: "already exists"},
+			wantCode: http.StatusConflict,
+			want:     &types.HTTPError{Code: CodeConflict, Message: "already exists"},
+		},
+		"unprocessable": {
+			input:    service.ErrUnprocessable,
+			wantCode: http.StatusUnprocessableEntity,
+			want:     &types.HTTPError{Code: CodeUnprocessable, Message: "unprocessable"},
+		},
+		"forbidden": {
+			input:    service.ErrForbidden,
+			wantCode: http.StatusForbidden,
+			want:     &types.HTTPError{Code: CodeForbidden, Message: "forbidden"},
+		},
+		"too large": {
+			input:    &service.Error{Err: service.ErrTooLarge, Message: "attachments must not exceed 10 bytes"},
+			wantCode: http.StatusRequestEntityTooLarge,
+			want:     &types.HTTPError{Code: CodeTooLarge, Message: "attachments must not exceed 10 bytes"},
+		},
+		"precondition failed": {
+			input:    &service.Error{Err: service.ErrPreconditionFailed, Message: "version is 2, not 1"},
+			wantCode: http.StatusPreconditionFailed,
+			want:     &types.HTTPError{Code: CodePreconditionFailed, Message: "version is 2, not 1"},
+		},
+		"echo error": {
+			input:    echo.NewHTTPError(http.StatusBadRequest, "malformed body"),
+			wantCode: http.StatusBadRequest,
+			want:     &types.HTTPError{Code: CodeBadRequest, Message: "malformed body"},
+		},
+		"unknown error": {
+			input:    errors.New("boom"),
+			wantCode: http.StatusInternalServerError,
+			want:     &types.HTTPError{Code: CodeInternal, Message: "failed internal"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			app.UnwrapError(c, "", tt.input)
+
+			if diff := cmp.Diff(tt.wantCode, res.Code); diff != "" {
+				t.Fatalf("UnwrapError() mismatch (-want +got):\n%s", diff)
+			}
+			got := new(types.HTTPError)
+			if err := json.NewDecoder(res.Body).Decode(got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("UnwrapError() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestVersioned(t *testing.T) {
+	task := &types.Task{Name: "task", Version: 2}
+	tag, err := service.ETag(task)
 	if err != nil {
-		t.Fatal("db connection err: ", err)
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		ifNoneMatch string
+		wantCode    int
+	}{
+		"no header": {
+			wantCode: http.StatusOK,
+		},
+		"same tag": {
+			ifNoneMatch: tag,
+			wantCode:    http.StatusNotModified,
+		},
+		"weak tag in a list": {
+			ifNoneMatch: `"1", W/` + tag,
+			wantCode:    http.StatusNotModified,
+		},
+		"any tag": {
+			ifNoneMatch: "*",
+			wantCode:    http.StatusNotModified,
+		},
+		"stale tag": {
+			ifNoneMatch: `"2"`,
+			wantCode:    http.StatusOK,
+		},
 	}
-	cleanup := func(tables ...string) func() {
-		return func() {
-			for _, table := range tables {
-				_, err = db.Exec(fmt.Sprintf("DELETE FROM %s", table))
-				if err != nil {
-					t.Fatal(err)
-				}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set(headerIfNoneMatch, tt.ifNoneMatch)
+			}
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			if err := versioned(c, task); err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tt.wantCode, res.Code); diff != "" {
+				t.Fatalf("versioned() mismatch (-want +got):\n%s", diff)
 			}
-		}
+			if diff := cmp.Diff(tag, res.Header().Get(headerETag)); diff != "" {
+				t.Fatalf("versioned() ETag mismatch (-want +got):\n%s", diff)
+			}
+		})
 	}
-	return &App{
-		Service: &service.Service{
-			DB: db,
+}
+
+func TestIfMatch(t *testing.T) {
+	tests := map[string]struct {
+		ifMatch string
+		wantErr error
+		wantTag string
+		wantOk  bool
+	}{
+		"no header": {},
+		"any tag": {
+			ifMatch: "*",
+		},
+		"tag": {
+			ifMatch: `"a1B2"`,
+			wantTag: `"a1B2"`,
+			wantOk:  true,
 		},
-		Logger: slog.Default(),
-	}, cleanup
+		"weak tag": {
+			ifMatch: `W/"a1B2"`,
+			wantErr: service.ErrPreconditionFailed,
+		},
+		"list of tags": {
+			ifMatch: `"a1B2", "c3D4"`,
+			wantErr: service.ErrPreconditionFailed,
+		},
+		"unquoted tag": {
+			ifMatch: "a1B2",
+			wantErr: service.ErrPreconditionFailed,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPatch, "/", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set(headerIfMatch, tt.ifMatch)
+			}
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			ctx, err := ifMatch(c)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ifMatch() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			tag, ok := service.ETagFromContext(ctx)
+			if diff := cmp.Diff(tt.wantOk, ok); diff != "" {
+				t.Fatalf("ifMatch() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantTag, tag); diff != "" {
+				t.Fatalf("ifMatch() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBindPatch(t *testing.T) {
+	tests := map[string]struct {
+		contentType string
+		body        string
+		want        *service.UpdateProjectInput
+	}{
+		"absent fields": {
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{"name": "New name"}`,
+			want:        &service.UpdateProjectInput{Id: "id", Name: types.PatchOf("New name")},
+		},
+		"null field": {
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{"description": null, "strict_wip_limits": false}`,
+			want: &service.UpdateProjectInput{
+				Id:              "id",
+				Description:     types.PatchNull[string](),
+				StrictWipLimits: types.PatchOf(false),
+			},
+		},
+		"merge patch": {
+			contentType: mimeMergePatch,
+			body:        `{"name": "New name"}`,
+			want:        &service.UpdateProjectInput{Id: "id", Name: types.PatchOf("New name")},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, tt.contentType)
+			c := e.NewContext(req, httptest.NewRecorder())
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues("id")
+
+			got := new(service.UpdateProjectInput)
+			if err := bindPatch(c, got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("bindPatch() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
 }
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/labstack/echo/v4"
+)
+
+// HandlePostInvitation invites an email to a project
+//
+//	@Summary	Invite an email to a project
+//	@Tags		invitation
+//	@Accept		json
+//	@Produce	json
+//	@Param		id		path		string						true	"Project ID"
+//	@Param		body	body		service.AddInvitationInput	true	"object of type AddInvitationInput"
+//	@Success	201		{object}	types.Invitation
+//	@Failure	400		{object}	types.HTTPError
+//	@Failure	403		{object}	types.HTTPError
+//	@Failure	500		{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/projects/{id}/invitations [post]
+func (a *App) HandlePostInvitation(c echo.Context) error {
+	ctx := c.Request().Context()
+	input := new(service.AddInvitationInput)
+	err := c.Bind(input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	inv, err := a.Service.AddInvitation(ctx, input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return created(c, inv.Id, inv)
+}
+
+// HandleGetProjectInvitations lists invitations of a project
+//
+//	@Summary	Returns all invitations of a project
+//	@Tags		invitations
+//	@Produce	json
+//	@Param		id	path	string	true	"Project ID"
+//	@Success	200	{array}	types.Invitation
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/projects/{id}/invitations [get]
+func (a *App) HandleGetProjectInvitations(c echo.Context) error {
+	ctx := c.Request().Context()
+	pId := c.Param("id")
+
+	invs, err := a.Service.GetInvitationsByProjectId(ctx, pId)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, invs)
+}
+
+// HandleDeleteInvitation revokes an invitation
+//
+//	@Summary	Revoke an invitation
+//	@Tags		invitation
+//	@Produce	json
+//	@Param		id	path	string	true	"Project ID"
+//	@Param		iid	path	string	true	"Invitation ID"
+//	@Success	200
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/projects/{id}/invitations/{iid} [delete]
+func (a *App) HandleDeleteInvitation(c echo.Context) error {
+	ctx := c.Request().Context()
+	pId := c.Param("id")
+	id := c.Param("iid")
+
+	err := a.Service.DeleteInvitation(ctx, pId, id)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// HandleGetPendingInvitations lists pending invitations of the caller
+//
+//	@Summary	Returns pending invitations of the caller
+//	@Tags		invitations
+//	@Produce	json
+//	@Success	200	{array}	types.Invitation
+//	@Failure	401	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/invitations [get]
+func (a *App) HandleGetPendingInvitations(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	invs, err := a.Service.GetPendingInvitations(ctx)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, invs)
+}
+
+// HandleAcceptInvitation accepts an invitation
+//
+//	@Summary	Accept an invitation
+//	@Tags		invitation
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body	service.RespondInvitationInput	true	"object of type RespondInvitationInput"
+//	@Success	200
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	401	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/invitations/accept [post]
+func (a *App) HandleAcceptInvitation(c echo.Context) error {
+	ctx := c.Request().Context()
+	input := new(service.RespondInvitationInput)
+	err := c.Bind(input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	err = a.Service.AcceptInvitation(ctx, input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// HandleDeclineInvitation declines an invitation
+//
+//	@Summary	Decline an invitation
+//	@Tags		invitation
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body	service.RespondInvitationInput	true	"object of type RespondInvitationInput"
+//	@Success	200
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	401	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/invitations/decline [post]
+func (a *App) HandleDeclineInvitation(c echo.Context) error {
+	ctx := c.Request().Context()
+	input := new(service.RespondInvitationInput)
+	err := c.Bind(input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	err = a.Service.DeclineInvitation(ctx, input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+func TestHandlePostInvitation(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	tests := map[string]struct {
+		wantCode int
+		caller   string
+		email    string
+	}{
+		"succsessfull invite": {
+			caller:   owner.Id,
+			email:    "invitee@test.com",
+			wantCode: http.StatusCreated,
+		},
+		"empty email": {
+			caller:   owner.Id,
+			email:    "",
+			wantCode: http.StatusBadRequest,
+		},
+		"owner's email": {
+			caller:   owner.Id,
+			email:    owner.Email,
+			wantCode: http.StatusBadRequest,
+		},
+		"not a manager": {
+			caller:   uuid.NewString(),
+			email:    "invitee@test.com",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		data, err := json.Marshal(map[string]string{"email": tt.email})
+		if err != nil {
+			t.Fatal(service.ErrFailedToPrepareTest)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+			req = req.WithContext(service.WithAccountId(req.Context(), tt.caller))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(project.Id)
+			app.HandlePostInvitation(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandlePostInvitation() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHandleAcceptInvitation(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	invitee := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "invitee",
+		Email: "invitee@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	tests := map[string]struct {
+		wantCode int
+		caller   string
+	}{
+		"succsessfull accept": {
+			caller:   invitee.Id,
+			wantCode: http.StatusOK,
+		},
+		"another account": {
+			caller:   owner.Id,
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutAccount(invitee)
+		m.PutProject(project)
+		_, err := app.Service.AddInvitation(service.WithAccountId(context.Background(), owner.Id), &service.AddInvitationInput{ProjectId: project.Id, Email: invitee.Email})
+		if err != nil {
+			t.Fatal(service.ErrFailedToPrepareTest, err)
+		}
+		invs, err := app.Service.GetPendingInvitations(service.WithAccountId(context.Background(), invitee.Id))
+		if err != nil || len(invs) != 1 {
+			t.Fatal(service.ErrFailedToPrepareTest, err)
+		}
+		data, err := json.Marshal(service.RespondInvitationInput{Token: invs[0].Token})
+		if err != nil {
+			t.Fatal(service.ErrFailedToPrepareTest)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+			req = req.WithContext(service.WithAccountId(req.Context(), tt.caller))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			app.HandleAcceptInvitation(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandleAcceptInvitation() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
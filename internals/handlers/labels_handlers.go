@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetLabels lists labels of a project
+//
+//	@Summary	Returns all labels of a project
+//	@Tags		labels
+//	@Produce	json
+//	@Param		id	path	string	true	"Project ID"
+//	@Success	200	{array}	types.Label
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/projects/{id}/labels [get]
+func (a *App) HandleGetLabels(c echo.Context) error {
+	ctx := c.Request().Context()
+	pId := c.Param("id")
+
+	ls, err := a.Service.GetLabelsByProjectId(ctx, pId)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, ls)
+}
+
+// HandlePostLabel creates a new label in a project
+//
+//	@Summary	Create a new label
+//	@Tags		label
+//	@Accept		json
+//	@Produce	json
+//	@Param		id		path		string					true	"Project ID"
+//	@Param		body	body		service.AddLabelInput	true	"object of type AddLabelInput"
+//	@Success	201		{object}	types.Label
+//	@Failure	400		{object}	types.HTTPError
+//	@Failure	403		{object}	types.HTTPError
+//	@Failure	409		{object}	types.HTTPError
+//	@Failure	500		{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/projects/{id}/labels [post]
+func (a *App) HandlePostLabel(c echo.Context) error {
+	ctx := c.Request().Context()
+	input := new(service.AddLabelInput)
+	err := c.Bind(input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	l, err := a.Service.AddLabel(ctx, input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return created(c, l.Id, l)
+}
+
+// HandlePatchLabel patches a label
+//
+//	@Summary	Patch a label
+//	@Tags		label
+//	@Accept		json
+//	@Produce	json
+//	@Param		id		path	string						true	"Project ID"
+//	@Param		lid		path	string						true	"Label ID"
+//	@Param		body	body	service.UpdateLabelInput	true	"object of type UpdateLabelInput"
+//	@Success	200
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	409	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/projects/{id}/labels/{lid} [patch]
+func (a *App) HandlePatchLabel(c echo.Context) error {
+	ctx := c.Request().Context()
+	input := new(service.UpdateLabelInput)
+	err := c.Bind(input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	err = a.Service.UpdateLabel(ctx, input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// HandleDeleteLabel deletes a label and detaches it from tasks
+//
+//	@Summary	Delete a label
+//	@Tags		label
+//	@Produce	json
+//	@Param		id	path	string	true	"Project ID"
+//	@Param		lid	path	string	true	"Label ID"
+//	@Success	200
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/projects/{id}/labels/{lid} [delete]
+func (a *App) HandleDeleteLabel(c echo.Context) error {
+	ctx := c.Request().Context()
+	pId := c.Param("id")
+	lId := c.Param("lid")
+
+	err := a.Service.DeleteLabel(ctx, pId, lId)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
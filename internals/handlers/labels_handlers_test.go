@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+func TestHandlePostLabel(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	tests := map[string]struct {
+		wantCode int
+		input    map[string]string
+	}{
+		"succsessfull add": {
+			input:    map[string]string{"name": "bug", "color": "#ff0000"},
+			wantCode: http.StatusCreated,
+		},
+		"invalid color": {
+			input:    map[string]string{"name": "bug", "color": "red"},
+			wantCode: http.StatusBadRequest,
+		},
+		"duplicate name": {
+			input:    map[string]string{"name": "feature", "color": "#ff0000"},
+			wantCode: http.StatusConflict,
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutLabel(types.Label{Name: "feature", Color: "#00ff00", ProjectId: project.Id})
+		data, err := json.Marshal(tt.input)
+		if err != nil {
+			t.Fatal(service.ErrFailedToPrepareTest)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+			req = req.WithContext(service.WithAccountId(req.Context(), owner.Id))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(project.Id)
+			app.HandlePostLabel(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandlePostLabel() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/types"
 	"github.com/labstack/echo/v4"
 )
 
@@ -12,11 +13,15 @@ import (
 //	@Summary	Returns a project
 //	@Tags		project
 //	@Produce	json
-//	@Param		id	path		string	true	"Project ID"
-//	@Success	200	{object}	types.Project
+//	@Param		id				path		string	true	"Project ID"
+//	@Param		If-None-Match	header		string	false	"ETag of a cached project"
+//	@Success	200				{object}	types.Project
+//	@Header		200				{string}	ETag	"Entity tag of the project"
+//	@Success	304
 //	@Failure	400	{object}	types.HTTPError
 //	@Failure	404	{object}	types.HTTPError
 //	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
 //	@Router		/projects/{id} [get]
 func (a *App) HandleGetProjectById(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -27,7 +32,7 @@ func (a *App) HandleGetProjectById(c echo.Context) error {
 		return a.UnwrapError(c, "", err)
 	}
 
-	return c.JSONPretty(http.StatusOK, &p, "  ")
+	return versioned(c, p)
 }
 
 // HandleGetProjects lists all existing projects
@@ -35,16 +40,24 @@ func (a *App) HandleGetProjectById(c echo.Context) error {
 //	@Summary	Returns all projects of an account
 //	@Tags		projects
 //	@Produce	json
-//	@Param		pid	path	string	true	"Account ID"
-//	@Success	200	{array}	types.Project
-//	@Failure	400
-//	@Failure	500
+//	@Param		oid		query		string				true	"Account ID"
+//	@Param		params	query		service.ListParams	false	"Pagination, sorting and filtering"
+//	@Success	200		{object}	types.Page[types.Project]
+//	@Failure	400		{object}	types.HTTPError
+//	@Failure	500		{object}	types.HTTPError
+//	@Security	BearerAuth
 //	@Router		/projects [get]
 func (a *App) HandleGetProjectsByOwner(c echo.Context) error {
 	ctx := c.Request().Context()
 	oId := c.QueryParam("oid")
+	params := new(service.ListParams)
+	err := c.Bind(params)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
 
-	pjs, err := a.Service.GetProjectsByOwnerId(ctx, oId)
+	var pjs *types.Page[types.Project]
+	pjs, err = a.Service.GetProjectsByOwnerId(ctx, oId, params)
 	if err != nil {
 		return a.UnwrapError(c, "", err)
 	}
@@ -59,10 +72,11 @@ func (a *App) HandleGetProjectsByOwner(c echo.Context) error {
 //	@Accept		json
 //	@Produce	json
 //	@Param		body	body	service.AddProjectInput	true	"object of type AddProjectInput"
-//	@Success	200
+//	@Success	201	{object}	types.Project
 //	@Failure	400	{object}	types.HTTPError
 //	@Failure	404	{object}	types.HTTPError
 //	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
 //	@Router		/projects [post]
 func (a *App) HandlePostProject(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -72,31 +86,37 @@ func (a *App) HandlePostProject(c echo.Context) error {
 		return a.UnwrapError(c, "", err)
 	}
 
-	err = a.Service.AddProject(ctx, input)
+	pj, err := a.Service.AddProject(ctx, input)
 	if err != nil {
 		return a.UnwrapError(c, "", err)
 	}
 
-	return c.NoContent(http.StatusCreated)
+	return created(c, pj.Id, pj)
 }
 
 // HandlePatchProject patches an project
 //
 //	@Summary	Patche a project
 //	@Tags		project
-//	@Accept		json
+//	@Accept		json,application/merge-patch+json
 //	@Produce	json
-//	@Param		body	body	service.AddProjectInput	false	"body of type AddProjectInput"
-//	@Param		id		path	string					true	"Project ID"
+//	@Param		body		body	service.UpdateProjectInput	false	"JSON merge patch of type UpdateProjectInput"
+//	@Param		id			path	string						true	"Project ID"
+//	@Param		If-Match	header	string						false	"ETag the project must still have"
 //	@Success	200
 //	@Failure	400	{object}	types.HTTPError
 //	@Failure	404	{object}	types.HTTPError
+//	@Failure	412	{object}	types.HTTPError
 //	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
 //	@Router		/projects/{id} [patch]
 func (a *App) HandlePatchProject(c echo.Context) error {
-	ctx := c.Request().Context()
+	ctx, err := ifMatch(c)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
 	input := new(service.UpdateProjectInput)
-	err := c.Bind(input)
+	err = bindPatch(c, input)
 	if err != nil {
 		return a.UnwrapError(c, "", err)
 	}
@@ -115,20 +135,52 @@ func (a *App) HandlePatchProject(c echo.Context) error {
 //	@Tags		project
 //	@Accept		json
 //	@Produce	json
-//	@Param		id	path	string	true	"Project ID"
+//	@Param		id			path	string	true	"Project ID"
+//	@Param		If-Match	header	string	false	"ETag the project must still have"
 //	@Success	200
 //	@Failure	400	{object}	types.HTTPError
+//	@Failure	401	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
 //	@Failure	404	{object}	types.HTTPError
+//	@Failure	412	{object}	types.HTTPError
 //	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
 //	@Router		/projects/{id} [delete]
 func (a *App) HandleDeleteProject(c echo.Context) error {
-	ctx := c.Request().Context()
+	ctx, err := ifMatch(c)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
 	id := c.Param("id")
 
-	err := a.Service.DeleteProjectById(ctx, id)
+	err = a.Service.DeleteProjectById(ctx, id)
 	if err != nil {
 		return a.UnwrapError(c, "", err)
 	}
 
 	return c.NoContent(http.StatusOK)
 }
+
+// HandleGetTimeline returns the schedule of tasks of a project for a Gantt chart
+//
+//	@Summary	Returns a timeline of a project
+//	@Tags		project
+//	@Produce	json
+//	@Param		id	path		string	true	"Project ID"
+//	@Success	200	{object}	types.Timeline
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/projects/{id}/timeline [get]
+func (a *App) HandleGetTimeline(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	tl, err := a.Service.GetProjectTimeline(ctx, id)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, tl)
+}
@@ -16,8 +16,6 @@ import (
 )
 
 func TestHandleGetProjectById(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	pId := uuid.NewString()
 	owner := types.Account{
 		Id:    uuid.NewString(),
@@ -31,7 +29,7 @@ func TestHandleGetProjectById(t *testing.T) {
 	}{
 		"non-existent": {
 			input:    uuid.NewString(),
-			wantCode: http.StatusBadRequest,
+			wantCode: http.StatusNotFound,
 			want:     nil,
 		},
 		"invalid id": {
@@ -51,20 +49,13 @@ func TestHandleGetProjectById(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
 		if tt.want != nil {
-			_, err = app.Service.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", tt.want.Id, tt.want.Name, tt.want.Description, tt.want.OwnerId)
-			if err != nil {
-				t.Fatal(service.ErrFailedToPrepareTest, err)
-			}
+			m.PutProject(*tt.want)
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts"))
-
 			e := echo.New()
 			req := httptest.NewRequest(http.MethodGet, "/", nil)
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -84,8 +75,6 @@ func TestHandleGetProjectById(t *testing.T) {
 }
 
 func TestHandleGetProjectsByOwnerId(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -120,22 +109,15 @@ func TestHandleGetProjectsByOwnerId(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
 		if tt.want != nil {
 			for _, p := range tt.want {
-				_, err = app.Service.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", p.Id, p.Name, p.Description, p.OwnerId)
-				if err != nil {
-					t.Fatal(service.ErrFailedToPrepareTest, err)
-				}
+				m.PutProject(p)
 			}
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts"))
-
 			q := make(url.Values)
 			q.Set("oid", tt.input)
 			e := echo.New()
@@ -154,8 +136,6 @@ func TestHandleGetProjectsByOwnerId(t *testing.T) {
 }
 
 func TestHandleAddProject(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	type input struct {
 		Name        string `json:"name"`
 		Description string `json:"description"`
@@ -188,7 +168,7 @@ func TestHandleAddProject(t *testing.T) {
 				Name:    "project",
 				OwnerId: uuid.NewString(),
 			},
-			wantCode: http.StatusInternalServerError,
+			wantCode: http.StatusUnprocessableEntity,
 		},
 		"sucsessfull add": {
 			input: &input{
@@ -200,20 +180,17 @@ func TestHandleAddProject(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
 		data, err := json.Marshal(tt.input)
 		if err != nil {
 			t.Fatal(service.ErrFailedToPrepareTest)
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts"))
-
 			e := echo.New()
 			req := httptest.NewRequest(http.MethodGet, "/", bytes.NewReader(data))
+			req = req.WithContext(service.WithAccountId(req.Context(), tt.input.OwnerId))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			res := httptest.NewRecorder()
 			c := e.NewContext(req, res)
@@ -223,13 +200,21 @@ func TestHandleAddProject(t *testing.T) {
 			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
 				t.Fatalf("HandlePostProject() mismatch (-want +got):\n%s", diff)
 			}
+			if gotCode != http.StatusCreated {
+				return
+			}
+			got := new(types.Project)
+			if err := json.NewDecoder(res.Body).Decode(got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff("/"+got.Id, res.Header().Get(echo.HeaderLocation)); diff != "" {
+				t.Fatalf("HandlePostProject() mismatch (-want +got):\n%s", diff)
+			}
 		})
 	}
 }
 
 func TestHandleUpdateProject(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	type input struct {
 		Name        string `json:"name,omitempty"`
 		Description string `json:"description,omitempty"`
@@ -261,7 +246,7 @@ func TestHandleUpdateProject(t *testing.T) {
 			input: &input{
 				Name: "New project name",
 			},
-			wantCode: http.StatusBadRequest,
+			wantCode: http.StatusNotFound,
 		},
 		"sucsessfull update": {
 			param: p.Id,
@@ -273,24 +258,18 @@ func TestHandleUpdateProject(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", p.Id, p.Name, p.Description, p.OwnerId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutProject(p)
 		data, err := json.Marshal(tt.input)
 		if err != nil {
 			t.Fatal(service.ErrFailedToPrepareTest)
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts"))
-
 			e := echo.New()
 			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+			req = req.WithContext(service.WithAccountId(req.Context(), owner.Id))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			res := httptest.NewRecorder()
 			c := e.NewContext(req, res)
@@ -308,8 +287,6 @@ func TestHandleUpdateProject(t *testing.T) {
 }
 
 func TestHandleDeleteProjectById(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -330,7 +307,7 @@ func TestHandleDeleteProjectById(t *testing.T) {
 		},
 		"non-existent project id": {
 			input:    uuid.NewString(),
-			wantCode: http.StatusBadRequest,
+			wantCode: http.StatusNotFound,
 		},
 		"sucsessfull delete": {
 			input:    p.Id,
@@ -339,20 +316,14 @@ func TestHandleDeleteProjectById(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", p.Id, p.Name, p.Description, p.OwnerId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutProject(p)
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("accounts", "projects"))
-
 			e := echo.New()
 			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req = req.WithContext(service.WithAccountId(req.Context(), owner.Id))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			res := httptest.NewRecorder()
 			c := e.NewContext(req, res)
@@ -368,3 +339,55 @@ func TestHandleDeleteProjectById(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleGetTimeline(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	tests := map[string]struct {
+		wantCode int
+		input    string
+	}{
+		"non-existent": {
+			input:    uuid.NewString(),
+			wantCode: http.StatusNotFound,
+		},
+		"invalid id": {
+			input:    "invalid-id",
+			wantCode: http.StatusBadRequest,
+		},
+		"existing": {
+			input:    project.Id,
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutProject(project)
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.input)
+			app.HandleGetTimeline(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandleGetTimeline() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/types"
 	"github.com/labstack/echo/v4"
 )
 
@@ -12,11 +13,15 @@ import (
 //	@Summary	Returns a status
 //	@Tags		status
 //	@Produce	json
-//	@Param		id	path		string	true	"Status ID"
-//	@Success	200	{object}	types.Status
+//	@Param		id				path		string	true	"Status ID"
+//	@Param		If-None-Match	header		string	false	"ETag of a cached status"
+//	@Success	200				{object}	types.Status
+//	@Header		200				{string}	ETag	"Entity tag of the status"
+//	@Success	304
 //	@Failure	400	{object}	types.HTTPError
 //	@Failure	404	{object}	types.HTTPError
 //	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
 //	@Router		/statuses/{id}  [get]
 func (a *App) HandleGetStatusById(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -27,7 +32,7 @@ func (a *App) HandleGetStatusById(c echo.Context) error {
 		return a.UnwrapError(c, "", err)
 	}
 
-	return c.JSONPretty(http.StatusOK, &s, "  ")
+	return versioned(c, s)
 }
 
 // HandleGetStatusesByOwner lists all statuses of a project
@@ -35,16 +40,24 @@ func (a *App) HandleGetStatusById(c echo.Context) error {
 //	@Summary	Returns all statuses of a project
 //	@Tags		statuses
 //	@Produce	json
-//	@Param		pid	path	string	true	"Account ID"
-//	@Success	200	{array}	types.Status
-//	@Failure	400
-//	@Failure	500
+//	@Param		pid		query		string				true	"Project ID"
+//	@Param		params	query		service.ListParams	false	"Pagination, sorting and filtering"
+//	@Success	200		{object}	types.Page[types.Status]
+//	@Failure	400		{object}	types.HTTPError
+//	@Failure	500		{object}	types.HTTPError
+//	@Security	BearerAuth
 //	@Router		/statuses [get]
 func (a *App) HandleGetStatusesByOwner(c echo.Context) error {
 	ctx := c.Request().Context()
 	pId := c.QueryParam("pid")
+	params := new(service.ListParams)
+	err := c.Bind(params)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
 
-	sts, err := a.Service.GetStatusesByProjectId(ctx, pId)
+	var sts *types.Page[types.Status]
+	sts, err = a.Service.GetStatusesByProjectId(ctx, pId, params)
 	if err != nil {
 		return a.UnwrapError(c, "", err)
 	}
@@ -59,10 +72,11 @@ func (a *App) HandleGetStatusesByOwner(c echo.Context) error {
 //	@Accept		json
 //	@Produce	json
 //	@Param		body	body	service.AddStatusInput	true	"object of type AddStatusInput"
-//	@Success	200
+//	@Success	201	{object}	types.Status
 //	@Failure	400	{object}	types.HTTPError
 //	@Failure	404	{object}	types.HTTPError
 //	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
 //	@Router		/statuses [post]
 func (a *App) HandlePostStatus(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -72,31 +86,37 @@ func (a *App) HandlePostStatus(c echo.Context) error {
 		return a.UnwrapError(c, "", err)
 	}
 
-	err = a.Service.AddStatus(ctx, input)
+	st, err := a.Service.AddStatus(ctx, input)
 	if err != nil {
 		return a.UnwrapError(c, "", err)
 	}
 
-	return c.NoContent(http.StatusCreated)
+	return created(c, st.Id, st)
 }
 
 // HandlePatchStatus patches an status
 //
 //	@Summary	Patche a status
 //	@Tags		status
-//	@Accept		json
+//	@Accept		json,application/merge-patch+json
 //	@Produce	json
-//	@Param		body	body	service.AddStatusInput	false	"body of type AddStatusInput"
-//	@Param		id		path	string					true	"Status ID"
+//	@Param		body		body	service.UpdateStatusInput	false	"JSON merge patch of type UpdateStatusInput"
+//	@Param		id			path	string						true	"Status ID"
+//	@Param		If-Match	header	string						false	"ETag the status must still have"
 //	@Success	200
 //	@Failure	400	{object}	types.HTTPError
 //	@Failure	404	{object}	types.HTTPError
+//	@Failure	412	{object}	types.HTTPError
 //	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
 //	@Router		/statuses/{id} [patch]
 func (a *App) HandlePatchStatus(c echo.Context) error {
-	ctx := c.Request().Context()
+	ctx, err := ifMatch(c)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
 	input := new(service.UpdateStatusInput)
-	err := c.Bind(input)
+	err = bindPatch(c, input)
 	if err != nil {
 		return a.UnwrapError(c, "", err)
 	}
@@ -115,17 +135,91 @@ func (a *App) HandlePatchStatus(c echo.Context) error {
 //	@Tags		status
 //	@Accept		json
 //	@Produce	json
-//	@Param		id	path	string	true	"Status ID"
+//	@Param		id			path	string	true	"Status ID"
+//	@Param		If-Match	header	string	false	"ETag the status must still have"
+//	@Param		target_id	query	string	false	"Status ID to move tasks of the status to"
 //	@Success	200
 //	@Failure	400	{object}	types.HTTPError
+//	@Failure	401	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
 //	@Failure	404	{object}	types.HTTPError
+//	@Failure	409	{object}	types.HTTPError
+//	@Failure	412	{object}	types.HTTPError
 //	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
 //	@Router		/statuses/{id} [delete]
 func (a *App) HandleDeleteStatus(c echo.Context) error {
-	ctx := c.Request().Context()
+	ctx, err := ifMatch(c)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
 	id := c.Param("id")
 
-	err := a.Service.DeleteStatusById(ctx, id)
+	err = a.Service.DeleteStatusById(ctx, id, c.QueryParam("target_id"))
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// HandleMoveStatus moves a status to another place on the board
+//
+//	@Summary	Move a status after another status of the project
+//	@Tags		status
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body	service.MoveStatusInput	false	"body of type MoveStatusInput"
+//	@Param		id		path	string					true	"Status ID"
+//	@Success	200
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	401	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/statuses/{id}/move [post]
+func (a *App) HandleMoveStatus(c echo.Context) error {
+	ctx := c.Request().Context()
+	input := new(service.MoveStatusInput)
+	err := c.Bind(input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	err = a.Service.MoveStatus(ctx, input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// HandleMergeStatus merges a status into another one
+//
+//	@Summary	Move all tasks of a status to another status and delete it
+//	@Tags		status
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body	service.MergeStatusInput	true	"body of type MergeStatusInput"
+//	@Param		id		path	string						true	"Status ID"
+//	@Success	200
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	401	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/statuses/{id}/merge [post]
+func (a *App) HandleMergeStatus(c echo.Context) error {
+	ctx := c.Request().Context()
+	input := new(service.MergeStatusInput)
+	err := c.Bind(input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	err = a.Service.MergeStatus(ctx, input)
 	if err != nil {
 		return a.UnwrapError(c, "", err)
 	}
@@ -16,8 +16,6 @@ import (
 )
 
 func TestHandleHandleGetStatusById(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	sId := uuid.NewString()
 	owner := types.Account{
 		Id:    uuid.NewString(),
@@ -37,7 +35,7 @@ func TestHandleHandleGetStatusById(t *testing.T) {
 	}{
 		"non-existent": {
 			input:    uuid.NewString(),
-			wantCode: http.StatusBadRequest,
+			wantCode: http.StatusNotFound,
 			want:     nil,
 		},
 		"invalid id": {
@@ -52,29 +50,20 @@ func TestHandleHandleGetStatusById(t *testing.T) {
 				Id:        sId,
 				Name:      "Status 1",
 				ProjectId: project.Id,
+				Category:  types.CategoryTodo,
 			},
 		},
 	}
 
 	for name, tt := range tests {
-		_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutProject(project)
 		if tt.want != nil {
-			_, err = app.Service.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", tt.want.Id, tt.want.Name, tt.want.ProjectId)
-			if err != nil {
-				t.Fatal(service.ErrFailedToPrepareTest, err)
-			}
+			m.PutStatus(types.Status{Id: tt.want.Id, Name: tt.want.Name, ProjectId: tt.want.ProjectId})
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses"))
-
 			e := echo.New()
 			req := httptest.NewRequest(http.MethodGet, "/", nil)
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -94,8 +83,6 @@ func TestHandleHandleGetStatusById(t *testing.T) {
 }
 
 func TestHandleGetStatusesByOwnerId(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -125,35 +112,27 @@ func TestHandleGetStatusesByOwnerId(t *testing.T) {
 					Id:        uuid.NewString(),
 					Name:      "Status 1",
 					ProjectId: project.Id,
+					Category:  types.CategoryTodo,
 				},
 				{
 					Id:        uuid.NewString(),
 					Name:      "Status 2",
 					ProjectId: project.Id,
+					Category:  types.CategoryTodo,
 				},
 			},
 		},
 	}
 
 	for name, tt := range tests {
-		_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutProject(project)
 		for _, st := range tt.want {
-			_, err = app.Service.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", st.Id, st.Name, st.ProjectId)
-			if err != nil {
-				t.Fatal(service.ErrFailedToPrepareTest, err)
-			}
+			m.PutStatus(types.Status{Id: st.Id, Name: st.Name, ProjectId: st.ProjectId})
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses"))
-
 			q := make(url.Values)
 			q.Set("pid", tt.input)
 			e := echo.New()
@@ -172,8 +151,6 @@ func TestHandleGetStatusesByOwnerId(t *testing.T) {
 }
 
 func TestHandleAddStatus(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	type input struct {
 		Name      string `json:"name"`
 		ProjectId string `json:"project_id"`
@@ -211,7 +188,7 @@ func TestHandleAddStatus(t *testing.T) {
 				Name:      "project",
 				ProjectId: uuid.NewString(),
 			},
-			wantCode: http.StatusInternalServerError,
+			wantCode: http.StatusNotFound,
 		},
 		"sucsessfull add": {
 			input: &input{
@@ -223,24 +200,18 @@ func TestHandleAddStatus(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutProject(project)
 		data, err := json.Marshal(tt.input)
 		if err != nil {
 			t.Fatal(service.ErrFailedToPrepareTest)
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses"))
-
 			e := echo.New()
 			req := httptest.NewRequest(http.MethodGet, "/", bytes.NewReader(data))
+			req = req.WithContext(service.WithAccountId(req.Context(), owner.Id))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			res := httptest.NewRecorder()
 			c := e.NewContext(req, res)
@@ -255,8 +226,6 @@ func TestHandleAddStatus(t *testing.T) {
 }
 
 func TestHandleUpdateStatus(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	type input struct {
 		Name        string `json:"name,omitempty"`
 		Description string `json:"description,omitempty"`
@@ -294,7 +263,7 @@ func TestHandleUpdateStatus(t *testing.T) {
 			input: &input{
 				Name: "New project name",
 			},
-			wantCode: http.StatusBadRequest,
+			wantCode: http.StatusNotFound,
 		},
 		"sucsessfull update": {
 			param: status.Id,
@@ -306,28 +275,19 @@ func TestHandleUpdateStatus(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", status.Id, status.Name, status.ProjectId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: status.Id, Name: status.Name, ProjectId: status.ProjectId})
 		data, err := json.Marshal(tt.input)
 		if err != nil {
 			t.Fatal(service.ErrFailedToPrepareTest)
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("accounts", "projects", "statuses"))
-
 			e := echo.New()
 			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+			req = req.WithContext(service.WithAccountId(req.Context(), owner.Id))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			res := httptest.NewRecorder()
 			c := e.NewContext(req, res)
@@ -345,8 +305,6 @@ func TestHandleUpdateStatus(t *testing.T) {
 }
 
 func TestHandleDeleteStatusById(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -373,7 +331,7 @@ func TestHandleDeleteStatusById(t *testing.T) {
 		},
 		"non-existent project id": {
 			input:    uuid.NewString(),
-			wantCode: http.StatusBadRequest,
+			wantCode: http.StatusNotFound,
 		},
 		"sucsessfull delete": {
 			input:    status.Id,
@@ -382,24 +340,15 @@ func TestHandleDeleteStatusById(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", status.Id, status.Name, status.ProjectId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: status.Id, Name: status.Name, ProjectId: status.ProjectId})
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("accounts", "projects", "statuses"))
-
 			e := echo.New()
 			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req = req.WithContext(service.WithAccountId(req.Context(), owner.Id))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			res := httptest.NewRecorder()
 			c := e.NewContext(req, res)
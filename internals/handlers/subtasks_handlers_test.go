@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// subtaskFixture is a project of owner where root has the subtask child that
+// has the subtask grandchild, and a task of another project of owner.
+type subtaskFixture struct {
+	owner                   types.Account
+	projectId, otherId      string
+	statusId, otherStatusId string
+	root, child, grandchild string
+	foreign                 string
+}
+
+func newSubtaskFixture() subtaskFixture {
+	return subtaskFixture{
+		owner:         types.Account{Id: uuid.NewString(), Name: "owner", Email: "owner@test.com"},
+		projectId:     uuid.NewString(),
+		otherId:       uuid.NewString(),
+		statusId:      uuid.NewString(),
+		otherStatusId: uuid.NewString(),
+		root:          uuid.NewString(),
+		child:         uuid.NewString(),
+		grandchild:    uuid.NewString(),
+		foreign:       uuid.NewString(),
+	}
+}
+
+func (f subtaskFixture) put(m *service.Memory) {
+	m.PutAccount(f.owner)
+	m.PutProject(types.Project{Id: f.projectId, Name: "project", OwnerId: f.owner.Id})
+	m.PutProject(types.Project{Id: f.otherId, Name: "other", OwnerId: f.owner.Id})
+	m.PutStatus(types.Status{Id: f.statusId, Name: "todo", ProjectId: f.projectId})
+	m.PutStatus(types.Status{Id: f.otherStatusId, Name: "todo", ProjectId: f.otherId})
+	tasks := []struct{ id, parentId, projectId, statusId string }{
+		{f.root, "", f.projectId, f.statusId},
+		{f.child, f.root, f.projectId, f.statusId},
+		{f.grandchild, f.child, f.projectId, f.statusId},
+		{f.foreign, "", f.otherId, f.otherStatusId},
+	}
+	for _, tk := range tasks {
+		m.PutTask(types.Task{Id: tk.id, Name: "task", ProjectId: tk.projectId, StatusId: tk.statusId, ParentId: tk.parentId})
+	}
+}
+
+// treeIds returns ids of the tree of t, every task followed by its subtasks.
+func treeIds(t types.Task) []string {
+	ids := []string{t.Id}
+	for _, c := range t.Children {
+		ids = append(ids, treeIds(c)...)
+	}
+	return ids
+}
+
+func TestHandleGetTaskTree(t *testing.T) {
+	f := newSubtaskFixture()
+	tests := map[string]struct {
+		wantCode int
+		want     []string
+		input    string
+	}{
+		"whole subtree": {
+			input:    f.root,
+			wantCode: http.StatusOK,
+			want:     []string{f.root, f.child, f.grandchild},
+		},
+		"subtree of a subtask": {
+			input:    f.child,
+			wantCode: http.StatusOK,
+			want:     []string{f.child, f.grandchild},
+		},
+		"invalid id": {
+			input:    "invalid-id",
+			wantCode: http.StatusBadRequest,
+		},
+		"non-existent": {
+			input:    uuid.NewString(),
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		f.put(m)
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req = req.WithContext(service.WithAccountId(req.Context(), f.owner.Id))
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.input)
+			app.HandleGetTaskTree(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandleGetTaskTree() mismatch (-want +got):\n%s", diff)
+			}
+			if gotCode != http.StatusOK {
+				return
+			}
+			var got types.Task
+			if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, treeIds(got)); diff != "" {
+				t.Fatalf("HandleGetTaskTree() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHandleGetTaskChildren(t *testing.T) {
+	f := newSubtaskFixture()
+	tests := map[string]struct {
+		wantCode int
+		want     []string
+		input    string
+	}{
+		"direct subtasks only": {
+			input:    f.root,
+			wantCode: http.StatusOK,
+			want:     []string{f.child},
+		},
+		"no subtasks": {
+			input:    f.grandchild,
+			wantCode: http.StatusOK,
+			want:     []string{},
+		},
+		"invalid id": {
+			input:    "invalid-id",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		f.put(m)
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req = req.WithContext(service.WithAccountId(req.Context(), f.owner.Id))
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.input)
+			app.HandleGetTaskChildren(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandleGetTaskChildren() mismatch (-want +got):\n%s", diff)
+			}
+			if gotCode != http.StatusOK {
+				return
+			}
+			var page types.Page[types.Task]
+			if err := json.NewDecoder(res.Body).Decode(&page); err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0)
+			for _, tk := range page.Items {
+				got = append(got, tk.Id)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("HandleGetTaskChildren() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHandlePatchTaskParent(t *testing.T) {
+	f := newSubtaskFixture()
+	tests := map[string]struct {
+		wantCode int
+		want     *types.HTTPError
+		id       string
+		body     string
+	}{
+		"new parent in the project": {
+			id:       f.grandchild,
+			body:     `{"parent_id": "` + f.root + `"}`,
+			wantCode: http.StatusOK,
+		},
+		"no parent": {
+			id:       f.child,
+			body:     `{"parent_id": null}`,
+			wantCode: http.StatusOK,
+		},
+		"parent of another project": {
+			id:       f.child,
+			body:     `{"parent_id": "` + f.foreign + `"}`,
+			wantCode: http.StatusBadRequest,
+			want: &types.HTTPError{
+				Code:    CodeValidation,
+				Message: "failed validation",
+				Fields:  []types.FieldError{{Field: "parent_id", Message: "must be a task of the same project"}},
+			},
+		},
+		"own subtask": {
+			id:       f.root,
+			body:     `{"parent_id": "` + f.grandchild + `"}`,
+			wantCode: http.StatusBadRequest,
+			want: &types.HTTPError{
+				Code:    CodeValidation,
+				Message: "failed validation",
+				Fields:  []types.FieldError{{Field: "parent_id", Message: "must not be the task itself or one of its subtasks"}},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		f.put(m)
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(tt.body))
+			req = req.WithContext(service.WithAccountId(req.Context(), f.owner.Id))
+			req.Header.Set(echo.HeaderContentType, mimeMergePatch)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+			app.HandlePatchTask(c)
+
+			if diff := cmp.Diff(tt.wantCode, res.Code); diff != "" {
+				t.Fatalf("HandlePatchTask() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.want, errorBody(t, res)); diff != "" {
+				t.Fatalf("HandlePatchTask() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
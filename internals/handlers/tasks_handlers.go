@@ -13,11 +13,15 @@ import (
 //	@Summary	Returns a task
 //	@Tags		task
 //	@Produce	json
-//	@Param		id	path		string	true	"Task ID"
-//	@Success	200	{object}	types.Task
+//	@Param		id				path		string	true	"Task ID"
+//	@Param		If-None-Match	header		string	false	"ETag of a cached task"
+//	@Success	200				{object}	types.Task
+//	@Header		200				{string}	ETag	"Entity tag of the task"
+//	@Success	304
 //	@Failure	400	{object}	types.HTTPError
 //	@Failure	404	{object}	types.HTTPError
 //	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
 //	@Router		/tasks/{id} [get]
 func (a *App) HandleGetTaskById(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -28,7 +32,61 @@ func (a *App) HandleGetTaskById(c echo.Context) error {
 		return a.UnwrapError(c, "", err)
 	}
 
-	return c.JSON(http.StatusOK, &p)
+	return versioned(c, p)
+}
+
+// HandleGetTaskChildren lists direct subtasks of a task
+//
+//	@Summary	Returns subtasks of a task
+//	@Tags		task
+//	@Produce	json
+//	@Param		id		path		string				true	"Task ID"
+//	@Param		params	query		service.ListParams	false	"Pagination, sorting and filtering"
+//	@Success	200		{object}	types.Page[types.Task]
+//	@Failure	400		{object}	types.HTTPError
+//	@Failure	500		{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/tasks/{id}/children [get]
+func (a *App) HandleGetTaskChildren(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+	params := new(service.ListParams)
+	err := c.Bind(params)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	var tks *types.Page[types.Task]
+	tks, err = a.Service.GetTaskChildren(ctx, id, params)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, tks)
+}
+
+// HandleGetTaskTree returns a task with all of its subtasks and their progress
+//
+//	@Summary	Returns a task tree
+//	@Tags		task
+//	@Produce	json
+//	@Param		id	path		string	true	"Task ID"
+//	@Success	200	{object}	types.Task
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/tasks/{id}/tree [get]
+func (a *App) HandleGetTaskTree(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	t, err := a.Service.GetTaskTree(ctx, id)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, t)
 }
 
 // HandleGetTasks lists tasks of a project
@@ -36,25 +94,32 @@ func (a *App) HandleGetTaskById(c echo.Context) error {
 //	@Summary	Returns list of tasks of a project
 //	@Tags		tasks
 //	@Produce	json
-//	@Param		pid	path	string	true	"Project ID"
-//	@Param		sid	path	string	false	"Status ID"
-//	@Success	200	{array}	types.Task
-//	@Failure	400
-//	@Failure	500
+//	@Param		pid		query		string				true	"Project ID"
+//	@Param		sid		query		string				false	"Status ID"
+//	@Param		params	query		service.ListParams	false	"Pagination, sorting and filtering"
+//	@Success	200		{object}	types.Page[types.Task]
+//	@Failure	400		{object}	types.HTTPError
+//	@Failure	500		{object}	types.HTTPError
+//	@Security	BearerAuth
 //	@Router		/tasks [get]
 func (a *App) HandleGetTasks(c echo.Context) error {
 	ctx := c.Request().Context()
 	pId := c.QueryParam("pid")
 	sId := c.QueryParam("sid")
-	var tks []types.Task
-	var err error
+	params := new(service.ListParams)
+	err := c.Bind(params)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	var tks *types.Page[types.Task]
 	if sId != "" {
-		tks, err = a.Service.GetTasksOfProjectByStatusId(ctx, pId, sId)
+		tks, err = a.Service.GetTasksOfProjectByStatusId(ctx, pId, sId, params)
 		if err != nil {
 			return a.UnwrapError(c, "", err)
 		}
 	} else {
-		tks, err = a.Service.GetTasksByProjectId(ctx, pId)
+		tks, err = a.Service.GetTasksByProjectId(ctx, pId, params)
 		if err != nil {
 			return a.UnwrapError(c, "", err)
 		}
@@ -70,10 +135,11 @@ func (a *App) HandleGetTasks(c echo.Context) error {
 //	@Accept		json
 //	@Produce	json
 //	@Param		body	body	service.AddTaskInput	true	"object of type AddTaskInput"
-//	@Success	200
+//	@Success	201	{object}	types.Task
 //	@Failure	400	{object}	types.HTTPError
 //	@Failure	404	{object}	types.HTTPError
 //	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
 //	@Router		/tasks [post]
 func (a *App) HandlePostTask(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -83,31 +149,38 @@ func (a *App) HandlePostTask(c echo.Context) error {
 		return a.UnwrapError(c, "", err)
 	}
 
-	err = a.Service.AddTask(ctx, input)
+	t, err := a.Service.AddTask(ctx, input)
 	if err != nil {
 		return a.UnwrapError(c, "", err)
 	}
 
-	return c.NoContent(http.StatusCreated)
+	return created(c, t.Id, t)
 }
 
 // HandlePatchTask patches a task
 //
 //	@Summary	Patche a task
 //	@Tags		task
-//	@Accept		json
+//	@Accept		json,application/merge-patch+json
 //	@Produce	json
-//	@Param		body	body	service.AddTaskInput	false	"body of type AddTaskInput"
-//	@Param		id		path	string					true	"Task ID"
+//	@Param		body		body	service.UpdateTaskInput	false	"JSON merge patch of type UpdateTaskInput"
+//	@Param		id			path	string					true	"Task ID"
+//	@Param		If-Match	header	string					false	"ETag the task must still have"
 //	@Success	200
 //	@Failure	400	{object}	types.HTTPError
 //	@Failure	404	{object}	types.HTTPError
+//	@Failure	412	{object}	types.HTTPError
+//	@Failure	422	{object}	types.HTTPError
 //	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
 //	@Router		/tasks/{id} [patch]
 func (a *App) HandlePatchTask(c echo.Context) error {
-	ctx := c.Request().Context()
+	ctx, err := ifMatch(c)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
 	input := new(service.UpdateTaskInput)
-	err := c.Bind(input)
+	err = bindPatch(c, input)
 	if err != nil {
 		return a.UnwrapError(c, "", err)
 	}
@@ -126,17 +199,58 @@ func (a *App) HandlePatchTask(c echo.Context) error {
 //	@Tags		task
 //	@Accept		json
 //	@Produce	json
-//	@Param		id	path	string	true	"Task ID"
+//	@Param		id			path	string	true	"Task ID"
+//	@Param		If-Match	header	string	false	"ETag the task must still have"
 //	@Success	200
 //	@Failure	400	{object}	types.HTTPError
+//	@Failure	401	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
 //	@Failure	404	{object}	types.HTTPError
+//	@Failure	412	{object}	types.HTTPError
 //	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
 //	@Router		/tasks/{id} [delete]
 func (a *App) HandleDeleteTask(c echo.Context) error {
-	ctx := c.Request().Context()
+	ctx, err := ifMatch(c)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
 	id := c.Param("id")
 
-	err := a.Service.DeleteTaskById(ctx, id)
+	err = a.Service.DeleteTaskById(ctx, id)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// HandleMoveTask moves a task to another place on the board
+//
+//	@Summary	Move a task after another task of a status
+//	@Tags		task
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body	service.MoveTaskInput	false	"body of type MoveTaskInput"
+//	@Param		id		path	string					true	"Task ID"
+//	@Success	200
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	401	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	422	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/tasks/{id}/move [post]
+func (a *App) HandleMoveTask(c echo.Context) error {
+	ctx := c.Request().Context()
+	input := new(service.MoveTaskInput)
+	err := c.Bind(input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	err = a.Service.MoveTask(ctx, input)
 	if err != nil {
 		return a.UnwrapError(c, "", err)
 	}
@@ -17,8 +17,6 @@ import (
 )
 
 func TestHandleGetTaskById(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	tId := uuid.NewString()
 	owner := types.Account{
 		Id:    uuid.NewString(),
@@ -43,7 +41,7 @@ func TestHandleGetTaskById(t *testing.T) {
 	}{
 		"non-existent": {
 			input:    uuid.NewString(),
-			wantCode: http.StatusBadRequest,
+			wantCode: http.StatusNotFound,
 			want:     nil,
 		},
 		"invalid id": {
@@ -66,28 +64,15 @@ func TestHandleGetTaskById(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", status.Id, status.Name, status.ProjectId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: status.Id, Name: status.Name, ProjectId: status.ProjectId})
 		if tt.want != nil {
-			_, err = app.Service.DB.Exec("INSERT INTO tasks (id, name, project_id, status_id, \"start\", \"end\") VALUES ($1, $2, $3, $4, $5, $6)", tt.want.Id, tt.want.Name, tt.want.ProjectId, tt.want.StatusId, tt.want.Start, tt.want.End)
-			if err != nil {
-				t.Fatal(service.ErrFailedToPrepareTest, err)
-			}
+			m.PutTask(types.Task{Id: tt.want.Id, Name: tt.want.Name, ProjectId: tt.want.ProjectId, StatusId: tt.want.StatusId, Start: tt.want.Start, End: tt.want.End})
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses", "tasks"))
-
 			e := echo.New()
 			req := httptest.NewRequest(http.MethodGet, "/", nil)
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -107,8 +92,6 @@ func TestHandleGetTaskById(t *testing.T) {
 }
 
 func TestHandleGetTasksByProjectId(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -160,28 +143,15 @@ func TestHandleGetTasksByProjectId(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", status.Id, status.Name, status.ProjectId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: status.Id, Name: status.Name, ProjectId: status.ProjectId})
 		for _, ts := range tt.want {
-			_, err = app.Service.DB.Exec("INSERT INTO tasks (id, name, project_id, status_id, \"start\", \"end\") VALUES ($1, $2, $3, $4, $5, $6)", ts.Id, ts.Name, ts.ProjectId, ts.StatusId, ts.Start, ts.End)
-			if err != nil {
-				t.Fatal(service.ErrFailedToPrepareTest, err)
-			}
+			m.PutTask(types.Task{Id: ts.Id, Name: ts.Name, ProjectId: ts.ProjectId, StatusId: ts.StatusId, Start: ts.Start, End: ts.End})
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses", "tasks"))
-
 			q := make(url.Values)
 			q.Set("pid", tt.input)
 			e := echo.New()
@@ -200,8 +170,6 @@ func TestHandleGetTasksByProjectId(t *testing.T) {
 }
 
 func TestHandleGetTasksByStatusId(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -253,29 +221,17 @@ func TestHandleGetTasksByStatusId(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", status.Id, status.Name, status.ProjectId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: status.Id, Name: status.Name, ProjectId: status.ProjectId})
 		for _, ts := range tt.want {
-			_, err = app.Service.DB.Exec("INSERT INTO tasks (id, name, project_id, status_id, \"start\", \"end\") VALUES ($1, $2, $3, $4, $5, $6)", ts.Id, ts.Name, ts.ProjectId, ts.StatusId, ts.Start, ts.End)
-			if err != nil {
-				t.Fatal(service.ErrFailedToPrepareTest, err)
-			}
+			m.PutTask(types.Task{Id: ts.Id, Name: ts.Name, ProjectId: ts.ProjectId, StatusId: ts.StatusId, Start: ts.Start, End: ts.End})
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses", "tasks"))
-
 			q := make(url.Values)
+			q.Set("pid", project.Id)
 			q.Set("sid", tt.input)
 			e := echo.New()
 			req := httptest.NewRequest(http.MethodGet, "/?"+q.Encode(), nil)
@@ -293,8 +249,6 @@ func TestHandleGetTasksByStatusId(t *testing.T) {
 }
 
 func TestHandleAddTask(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	type input struct {
 		Start     string `json:"start"`
 		End       string `json:"end"`
@@ -389,7 +343,7 @@ func TestHandleAddTask(t *testing.T) {
 				Start:     time.Now().Format(time.DateTime),
 				End:       time.Now().AddDate(0, 0, 1).Format(time.DateTime),
 			},
-			wantCode: http.StatusInternalServerError,
+			wantCode: http.StatusNotFound,
 		},
 		"non-existent status id": {
 			input: &input{
@@ -399,7 +353,7 @@ func TestHandleAddTask(t *testing.T) {
 				Start:     time.Now().Format(time.DateTime),
 				End:       time.Now().AddDate(0, 0, 1).Format(time.DateTime),
 			},
-			wantCode: http.StatusInternalServerError,
+			wantCode: http.StatusBadRequest,
 		},
 		"sucsessfull add": {
 			input: &input{
@@ -414,28 +368,19 @@ func TestHandleAddTask(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", status.Id, status.Name, status.ProjectId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: status.Id, Name: status.Name, ProjectId: status.ProjectId})
 		data, err := json.Marshal(tt.input)
 		if err != nil {
 			t.Fatal(service.ErrFailedToPrepareTest)
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses", "tasks"))
-
 			e := echo.New()
 			req := httptest.NewRequest(http.MethodGet, "/", bytes.NewReader(data))
+			req = req.WithContext(service.WithAccountId(req.Context(), owner.Id))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			res := httptest.NewRecorder()
 			c := e.NewContext(req, res)
@@ -450,8 +395,6 @@ func TestHandleAddTask(t *testing.T) {
 }
 
 func TestHandleUpdateTask(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	type input struct {
 		Start    string `json:"start,omitempty"`
 		End      string `json:"end,omitempty"`
@@ -544,7 +487,7 @@ func TestHandleUpdateTask(t *testing.T) {
 				Start:    time.Now().Format(time.DateTime),
 				End:      time.Now().AddDate(0, 0, 1).Format(time.DateTime),
 			},
-			wantCode: http.StatusBadRequest,
+			wantCode: http.StatusNotFound,
 		},
 		"non-existent status id": {
 			input: &input{
@@ -554,7 +497,7 @@ func TestHandleUpdateTask(t *testing.T) {
 				Start:    time.Now().Format(time.DateTime),
 				End:      time.Now().AddDate(0, 0, 1).Format(time.DateTime),
 			},
-			wantCode: http.StatusInternalServerError,
+			wantCode: http.StatusBadRequest,
 		},
 		"sucsessfull update": {
 			input: &input{
@@ -569,32 +512,20 @@ func TestHandleUpdateTask(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", status.Id, status.Name, status.ProjectId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO tasks (id, name, project_id, status_id, \"start\", \"end\") VALUES ($1, $2, $3, $4, $5, $6)", task.Id, task.Name, task.ProjectId, task.StatusId, task.Start, task.End)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: status.Id, Name: status.Name, ProjectId: status.ProjectId})
+		m.PutTask(types.Task{Id: task.Id, Name: task.Name, ProjectId: task.ProjectId, StatusId: task.StatusId, Start: task.Start, End: task.End})
 		data, err := json.Marshal(tt.input)
 		if err != nil {
 			t.Fatal(service.ErrFailedToPrepareTest)
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses", "tasks"))
-
 			e := echo.New()
 			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+			req = req.WithContext(service.WithAccountId(req.Context(), owner.Id))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			res := httptest.NewRecorder()
 			c := e.NewContext(req, res)
@@ -612,8 +543,6 @@ func TestHandleUpdateTask(t *testing.T) {
 }
 
 func TestHandleDeleteTaskById(t *testing.T) {
-	app, cleanup := setupApp(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -648,7 +577,7 @@ func TestHandleDeleteTaskById(t *testing.T) {
 		},
 		"non-existent task id": {
 			input:    uuid.NewString(),
-			wantCode: http.StatusBadRequest,
+			wantCode: http.StatusNotFound,
 		},
 		"sucsessfull delete": {
 			input:    task.Id,
@@ -657,28 +586,16 @@ func TestHandleDeleteTaskById(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := app.Service.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", status.Id, status.Name, status.ProjectId)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
-		_, err = app.Service.DB.Exec("INSERT INTO tasks (id, name, project_id, status_id, \"start\", \"end\") VALUES ($1, $2, $3, $4, $5, $6)", task.Id, task.Name, task.ProjectId, task.StatusId, task.Start, task.End)
-		if err != nil {
-			t.Fatal(service.ErrFailedToPrepareTest, err)
-		}
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: status.Id, Name: status.Name, ProjectId: status.ProjectId})
+		m.PutTask(types.Task{Id: task.Id, Name: task.Name, ProjectId: task.ProjectId, StatusId: task.StatusId, Start: task.Start, End: task.End})
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses", "tasks"))
-
 			e := echo.New()
 			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req = req.WithContext(service.WithAccountId(req.Context(), owner.Id))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			res := httptest.NewRecorder()
 			c := e.NewContext(req, res)
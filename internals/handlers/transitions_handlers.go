@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetTransitions returns the workflow of a project
+//
+//	@Summary	Returns allowed status transitions of a project
+//	@Tags		status
+//	@Produce	json
+//	@Param		id	path	string	true	"Project ID"
+//	@Success	200	{array}	types.Transition
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/projects/{id}/transitions [get]
+func (a *App) HandleGetTransitions(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	trs, err := a.Service.GetTransitionsByProjectId(ctx, id)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, trs)
+}
+
+// HandlePutTransitions replaces the workflow of a project
+//
+//	@Summary	Replace allowed status transitions of a project
+//	@Tags		status
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body	service.SetTransitionsInput	true	"body of type SetTransitionsInput"
+//	@Param		id		path	string						true	"Project ID"
+//	@Success	200
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	401	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/projects/{id}/transitions [put]
+func (a *App) HandlePutTransitions(c echo.Context) error {
+	ctx := c.Request().Context()
+	input := new(service.SetTransitionsInput)
+	err := c.Bind(input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	err = a.Service.SetTransitions(ctx, input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// transitionFixture is a project of owner whose workflow only allows
+// moving tasks from todo to doing.
+type transitionFixture struct {
+	owner, outsider         types.Account
+	projectId, otherId      string
+	todo, doing, done, misc string
+	taskId                  string
+}
+
+func newTransitionFixture() transitionFixture {
+	return transitionFixture{
+		owner:     types.Account{Id: uuid.NewString(), Name: "owner", Email: "owner@test.com"},
+		outsider:  types.Account{Id: uuid.NewString(), Name: "outsider", Email: "outsider@test.com"},
+		projectId: uuid.NewString(),
+		otherId:   uuid.NewString(),
+		todo:      uuid.NewString(),
+		doing:     uuid.NewString(),
+		done:      uuid.NewString(),
+		misc:      uuid.NewString(),
+		taskId:    uuid.NewString(),
+	}
+}
+
+func (f transitionFixture) put(m *service.Memory) {
+	m.PutAccount(f.owner)
+	m.PutAccount(f.outsider)
+	m.PutProject(types.Project{Id: f.projectId, Name: "project", OwnerId: f.owner.Id})
+	m.PutProject(types.Project{Id: f.otherId, Name: "other", OwnerId: f.owner.Id})
+	m.PutStatus(types.Status{Id: f.todo, Name: "todo", ProjectId: f.projectId})
+	m.PutStatus(types.Status{Id: f.doing, Name: "doing", ProjectId: f.projectId})
+	m.PutStatus(types.Status{Id: f.done, Name: "done", ProjectId: f.projectId})
+	m.PutStatus(types.Status{Id: f.misc, Name: "misc", ProjectId: f.otherId})
+	m.PutTask(types.Task{Id: f.taskId, Name: "task", ProjectId: f.projectId, StatusId: f.todo})
+	m.PutTransition(f.projectId, types.Transition{FromStatusId: f.todo, ToStatusId: f.doing})
+}
+
+func TestHandleGetTransitions(t *testing.T) {
+	f := newTransitionFixture()
+	tests := map[string]struct {
+		wantCode int
+		want     []types.Transition
+		input    string
+	}{
+		"existing": {
+			input:    f.projectId,
+			wantCode: http.StatusOK,
+			want:     []types.Transition{{FromStatusId: f.todo, ToStatusId: f.doing}},
+		},
+		"unrestricted project": {
+			input:    f.otherId,
+			wantCode: http.StatusOK,
+			want:     []types.Transition{},
+		},
+		"invalid id": {
+			input:    "invalid-id",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		f.put(m)
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req = req.WithContext(service.WithAccountId(req.Context(), f.owner.Id))
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.input)
+			app.HandleGetTransitions(c)
+
+			if diff := cmp.Diff(tt.wantCode, res.Code); diff != "" {
+				t.Fatalf("HandleGetTransitions() mismatch (-want +got):\n%s", diff)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var got []types.Transition
+			if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("HandleGetTransitions() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHandlePutTransitions(t *testing.T) {
+	f := newTransitionFixture()
+	tests := map[string]struct {
+		wantCode int
+		want     []types.Transition
+		caller   string
+		project  string
+		input    []types.Transition
+	}{
+		"add a transition": {
+			caller:   f.owner.Id,
+			project:  f.projectId,
+			input:    []types.Transition{{FromStatusId: f.todo, ToStatusId: f.doing}, {FromStatusId: f.doing, ToStatusId: f.done}},
+			wantCode: http.StatusOK,
+			want:     []types.Transition{{FromStatusId: f.todo, ToStatusId: f.doing}, {FromStatusId: f.doing, ToStatusId: f.done}},
+		},
+		"delete a transition": {
+			caller:   f.owner.Id,
+			project:  f.projectId,
+			input:    []types.Transition{{FromStatusId: f.doing, ToStatusId: f.done}},
+			wantCode: http.StatusOK,
+			want:     []types.Transition{{FromStatusId: f.doing, ToStatusId: f.done}},
+		},
+		"delete all transitions": {
+			caller:   f.owner.Id,
+			project:  f.projectId,
+			input:    []types.Transition{},
+			wantCode: http.StatusOK,
+			want:     []types.Transition{},
+		},
+		"transition to the same status": {
+			caller:   f.owner.Id,
+			project:  f.projectId,
+			input:    []types.Transition{{FromStatusId: f.todo, ToStatusId: f.todo}},
+			wantCode: http.StatusBadRequest,
+			want:     []types.Transition{{FromStatusId: f.todo, ToStatusId: f.doing}},
+		},
+		"status of another project": {
+			caller:   f.owner.Id,
+			project:  f.projectId,
+			input:    []types.Transition{{FromStatusId: f.todo, ToStatusId: f.misc}},
+			wantCode: http.StatusBadRequest,
+			want:     []types.Transition{{FromStatusId: f.todo, ToStatusId: f.doing}},
+		},
+		"caller outside of the project": {
+			caller:   f.outsider.Id,
+			project:  f.projectId,
+			input:    []types.Transition{},
+			wantCode: http.StatusForbidden,
+			want:     []types.Transition{{FromStatusId: f.todo, ToStatusId: f.doing}},
+		},
+		"non-existent project": {
+			caller:   f.owner.Id,
+			project:  uuid.NewString(),
+			input:    []types.Transition{},
+			wantCode: http.StatusNotFound,
+			want:     []types.Transition{{FromStatusId: f.todo, ToStatusId: f.doing}},
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		f.put(m)
+		data, err := json.Marshal(map[string]any{"transitions": tt.input})
+		if err != nil {
+			t.Fatal(service.ErrFailedToPrepareTest)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPut, "/", bytes.NewReader(data))
+			req = req.WithContext(service.WithAccountId(req.Context(), tt.caller))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.project)
+			app.HandlePutTransitions(c)
+
+			if diff := cmp.Diff(tt.wantCode, res.Code); diff != "" {
+				t.Fatalf("HandlePutTransitions() mismatch (-want +got):\n%s", diff)
+			}
+			got, err := app.Service.GetTransitionsByProjectId(context.Background(), f.projectId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Transitions set at once are ordered by their statuses.
+			if diff := cmp.Diff(tt.want, got, cmpopts.SortSlices(func(a, b types.Transition) bool { return a.FromStatusId < b.FromStatusId })); diff != "" {
+				t.Fatalf("HandlePutTransitions() transitions mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHandleMoveTaskTransition(t *testing.T) {
+	f := newTransitionFixture()
+	tests := map[string]struct {
+		wantCode int
+		want     *types.HTTPError
+		statusId string
+	}{
+		"allowed transition": {
+			statusId: f.doing,
+			wantCode: http.StatusOK,
+		},
+		"disallowed transition": {
+			statusId: f.done,
+			wantCode: http.StatusUnprocessableEntity,
+			want: &types.HTTPError{
+				Code:    CodeUnprocessable,
+				Message: `the workflow doesn't allow moving tasks from "todo" to "done"`,
+				Fields:  []types.FieldError{{Field: "status_id", Message: "must be reachable from the current status"}},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		f.put(m)
+		data, err := json.Marshal(map[string]string{"status_id": tt.statusId})
+		if err != nil {
+			t.Fatal(service.ErrFailedToPrepareTest)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+			req = req.WithContext(service.WithAccountId(req.Context(), f.owner.Id))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(f.taskId)
+			app.HandleMoveTask(c)
+
+			if diff := cmp.Diff(tt.wantCode, res.Code); diff != "" {
+				t.Fatalf("HandleMoveTask() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.want, errorBody(t, res)); diff != "" {
+				t.Fatalf("HandleMoveTask() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetProjectTrash returns deleted statuses and tasks of a project
+//
+//	@Summary	Returns deleted statuses and tasks of a project
+//	@Tags		trash
+//	@Produce	json
+//	@Param		id	path		string	true	"Project ID"
+//	@Success	200	{object}	types.Trash
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	401	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/projects/{id}/trash [get]
+func (a *App) HandleGetProjectTrash(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	trash, err := a.Service.GetProjectTrash(ctx, id)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, trash)
+}
+
+// HandleGetAccountTrash returns deleted projects of an account
+//
+//	@Summary	Returns deleted projects owned by an account
+//	@Tags		trash
+//	@Produce	json
+//	@Param		id	path		string	true	"Account ID"
+//	@Success	200	{object}	types.Trash
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	401	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/accounts/{id}/trash [get]
+func (a *App) HandleGetAccountTrash(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	trash, err := a.Service.GetAccountTrash(ctx, id)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, trash)
+}
+
+// HandleRestoreAccount restores a deleted account and issues a pair of tokens for it
+//
+//	@Summary	Restore a deleted account with projects deleted along with it
+//	@Tags		trash
+//	@Accept		json
+//	@Produce	json
+//	@Param		body	body		service.LoginInput	true	"email and password of the deleted account"
+//	@Success	200		{object}	types.Tokens
+//	@Failure	400		{object}	types.HTTPError
+//	@Failure	401		{object}	types.HTTPError
+//	@Failure	500		{object}	types.HTTPError
+//	@Router		/auth/restore [post]
+func (a *App) HandleRestoreAccount(c echo.Context) error {
+	ctx := c.Request().Context()
+	input := new(service.LoginInput)
+	err := c.Bind(input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	tokens, err := a.Service.RestoreAccount(ctx, input)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.JSON(http.StatusOK, tokens)
+}
+
+// HandleRestoreProject restores a deleted project
+//
+//	@Summary	Restore a deleted project with statuses and tasks deleted along with it
+//	@Tags		trash
+//	@Produce	json
+//	@Param		id	path	string	true	"Project ID"
+//	@Success	200
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	401	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/projects/{id}/restore [post]
+func (a *App) HandleRestoreProject(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	err := a.Service.RestoreProjectById(ctx, id)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// HandleRestoreStatus restores a deleted status
+//
+//	@Summary	Restore a deleted status
+//	@Tags		trash
+//	@Produce	json
+//	@Param		id	path	string	true	"Status ID"
+//	@Success	200
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	401	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/statuses/{id}/restore [post]
+func (a *App) HandleRestoreStatus(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	err := a.Service.RestoreStatusById(ctx, id)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// HandleRestoreTask restores a deleted task
+//
+//	@Summary	Restore a deleted task with subtasks deleted along with it
+//	@Tags		trash
+//	@Produce	json
+//	@Param		id	path	string	true	"Task ID"
+//	@Success	200
+//	@Failure	400	{object}	types.HTTPError
+//	@Failure	401	{object}	types.HTTPError
+//	@Failure	403	{object}	types.HTTPError
+//	@Failure	404	{object}	types.HTTPError
+//	@Failure	409	{object}	types.HTTPError
+//	@Failure	500	{object}	types.HTTPError
+//	@Security	BearerAuth
+//	@Router		/tasks/{id}/restore [post]
+func (a *App) HandleRestoreTask(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	err := a.Service.RestoreTaskById(ctx, id)
+	if err != nil {
+		return a.UnwrapError(c, "", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// trashFixture is a project of owner with a deleted status, deleted tasks,
+// a deleted project of owner and a deleted account.
+type trashFixture struct {
+	owner, outsider, deleted      types.Account
+	projectId, trashedProjectId   string
+	statusId, trashedStatusId     string
+	trashedTaskId, orphanedTaskId string
+}
+
+func newTrashFixture() trashFixture {
+	return trashFixture{
+		owner:            types.Account{Id: uuid.NewString(), Name: "owner", Email: "owner@test.com"},
+		outsider:         types.Account{Id: uuid.NewString(), Name: "outsider", Email: "outsider@test.com"},
+		deleted:          types.Account{Id: uuid.NewString(), Name: "deleted", Email: "deleted@test.com"},
+		projectId:        uuid.NewString(),
+		trashedProjectId: uuid.NewString(),
+		statusId:         uuid.NewString(),
+		trashedStatusId:  uuid.NewString(),
+		trashedTaskId:    uuid.NewString(),
+		orphanedTaskId:   uuid.NewString(),
+	}
+}
+
+// put puts the fixture into m, the password of the deleted account is "password".
+func (f trashFixture) put(t *testing.T, m *service.Memory) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(service.ErrFailedToPrepareTest, err)
+	}
+	m.PutAccount(f.owner)
+	m.PutAccount(f.outsider)
+	deleted := f.deleted
+	deleted.Deleted = true
+	m.PutAccount(deleted)
+	m.PutPassword(deleted.Id, string(hash))
+	m.PutProject(types.Project{Id: f.projectId, Name: "project", OwnerId: f.owner.Id})
+	m.PutProject(types.Project{Id: f.trashedProjectId, Name: "trashed", OwnerId: f.owner.Id, Deleted: true})
+	m.PutStatus(types.Status{Id: f.statusId, Name: "todo", ProjectId: f.projectId})
+	m.PutStatus(types.Status{Id: f.trashedStatusId, Name: "trashed", ProjectId: f.projectId, Deleted: true})
+	m.PutTask(types.Task{Id: f.trashedTaskId, Name: "trashed", ProjectId: f.projectId, StatusId: f.statusId, Deleted: true})
+	m.PutTask(types.Task{Id: f.orphanedTaskId, Name: "orphaned", ProjectId: f.projectId, StatusId: f.trashedStatusId, Deleted: true})
+}
+
+func TestHandleGetProjectTrash(t *testing.T) {
+	f := newTrashFixture()
+	tests := map[string]struct {
+		wantCode int
+		caller   string
+		input    string
+	}{
+		"existing": {
+			caller:   f.owner.Id,
+			input:    f.projectId,
+			wantCode: http.StatusOK,
+		},
+		"invalid id": {
+			caller:   f.owner.Id,
+			input:    "invalid-id",
+			wantCode: http.StatusBadRequest,
+		},
+		"caller outside of the project": {
+			caller:   f.outsider.Id,
+			input:    f.projectId,
+			wantCode: http.StatusForbidden,
+		},
+		"non-existent": {
+			caller:   f.owner.Id,
+			input:    uuid.NewString(),
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		f.put(t, m)
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req = req.WithContext(service.WithAccountId(req.Context(), tt.caller))
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.input)
+			app.HandleGetProjectTrash(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandleGetProjectTrash() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHandleGetAccountTrash(t *testing.T) {
+	f := newTrashFixture()
+	tests := map[string]struct {
+		wantCode int
+		caller   string
+		input    string
+	}{
+		"own trash": {
+			caller:   f.owner.Id,
+			input:    f.owner.Id,
+			wantCode: http.StatusOK,
+		},
+		"invalid id": {
+			caller:   f.owner.Id,
+			input:    "invalid-id",
+			wantCode: http.StatusBadRequest,
+		},
+		"another account": {
+			caller:   f.outsider.Id,
+			input:    f.owner.Id,
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		f.put(t, m)
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req = req.WithContext(service.WithAccountId(req.Context(), tt.caller))
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.input)
+			app.HandleGetAccountTrash(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandleGetAccountTrash() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHandleRestoreAccount(t *testing.T) {
+	f := newTrashFixture()
+	tests := map[string]struct {
+		wantCode int
+		input    *service.LoginInput
+	}{
+		"deleted account": {
+			input:    &service.LoginInput{Email: f.deleted.Email, Password: "password"},
+			wantCode: http.StatusOK,
+		},
+		"wrong password": {
+			input:    &service.LoginInput{Email: f.deleted.Email, Password: "wrong password"},
+			wantCode: http.StatusUnauthorized,
+		},
+		"non-deleted account": {
+			input:    &service.LoginInput{Email: f.owner.Email, Password: "password"},
+			wantCode: http.StatusUnauthorized,
+		},
+		"empty email": {
+			input:    &service.LoginInput{Password: "password"},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		f.put(t, m)
+		data, err := json.Marshal(tt.input)
+		if err != nil {
+			t.Fatal(service.ErrFailedToPrepareTest)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			app.HandleRestoreAccount(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandleRestoreAccount() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHandleRestoreProject(t *testing.T) {
+	f := newTrashFixture()
+	tests := map[string]struct {
+		wantCode int
+		caller   string
+		input    string
+	}{
+		"deleted project": {
+			caller:   f.owner.Id,
+			input:    f.trashedProjectId,
+			wantCode: http.StatusOK,
+		},
+		"invalid id": {
+			caller:   f.owner.Id,
+			input:    "invalid-id",
+			wantCode: http.StatusBadRequest,
+		},
+		"not the owner": {
+			caller:   f.outsider.Id,
+			input:    f.trashedProjectId,
+			wantCode: http.StatusForbidden,
+		},
+		"non-deleted project": {
+			caller:   f.owner.Id,
+			input:    f.projectId,
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		f.put(t, m)
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req = req.WithContext(service.WithAccountId(req.Context(), tt.caller))
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.input)
+			app.HandleRestoreProject(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandleRestoreProject() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHandleRestoreStatus(t *testing.T) {
+	f := newTrashFixture()
+	tests := map[string]struct {
+		wantCode int
+		caller   string
+		input    string
+	}{
+		"deleted status": {
+			caller:   f.owner.Id,
+			input:    f.trashedStatusId,
+			wantCode: http.StatusOK,
+		},
+		"invalid id": {
+			caller:   f.owner.Id,
+			input:    "invalid-id",
+			wantCode: http.StatusBadRequest,
+		},
+		"caller outside of the project": {
+			caller:   f.outsider.Id,
+			input:    f.trashedStatusId,
+			wantCode: http.StatusForbidden,
+		},
+		"non-deleted status": {
+			caller:   f.owner.Id,
+			input:    f.statusId,
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		f.put(t, m)
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req = req.WithContext(service.WithAccountId(req.Context(), tt.caller))
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.input)
+			app.HandleRestoreStatus(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandleRestoreStatus() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHandleRestoreTask(t *testing.T) {
+	f := newTrashFixture()
+	tests := map[string]struct {
+		wantCode int
+		caller   string
+		input    string
+	}{
+		"deleted task": {
+			caller:   f.owner.Id,
+			input:    f.trashedTaskId,
+			wantCode: http.StatusOK,
+		},
+		"invalid id": {
+			caller:   f.owner.Id,
+			input:    "invalid-id",
+			wantCode: http.StatusBadRequest,
+		},
+		"caller outside of the project": {
+			caller:   f.outsider.Id,
+			input:    f.trashedTaskId,
+			wantCode: http.StatusForbidden,
+		},
+		"non-existent": {
+			caller:   f.owner.Id,
+			input:    uuid.NewString(),
+			wantCode: http.StatusNotFound,
+		},
+		"deleted status": {
+			caller:   f.owner.Id,
+			input:    f.orphanedTaskId,
+			wantCode: http.StatusConflict,
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		f.put(t, m)
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req = req.WithContext(service.WithAccountId(req.Context(), tt.caller))
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			c.SetPath("/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.input)
+			app.HandleRestoreTask(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandleRestoreTask() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HandleGetWorkflows returns the workflows new projects can start with
+//
+//	@Summary	Returns workflow templates for new projects
+//	@Tags		project
+//	@Produce	json
+//	@Success	200	{array}	types.Workflow
+//	@Security	BearerAuth
+//	@Router		/workflows [get]
+func (a *App) HandleGetWorkflows(c echo.Context) error {
+	return c.JSON(http.StatusOK, a.Service.GetWorkflows())
+}
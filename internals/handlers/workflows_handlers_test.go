@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danblok/pm/internals/service"
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+func TestHandleGetWorkflows(t *testing.T) {
+	custom := []types.Workflow{
+		{
+			Name:        "custom",
+			Statuses:    []types.WorkflowStatus{{Name: "Open", Category: types.CategoryTodo}, {Name: "Closed", Category: types.CategoryDone}},
+			Transitions: []types.WorkflowTransition{{From: "Open", To: "Closed"}},
+		},
+	}
+	tests := map[string]struct {
+		workflows []types.Workflow
+		want      []types.Workflow
+	}{
+		"default workflows": {
+			want: service.DefaultWorkflows,
+		},
+		"configured workflows": {
+			workflows: custom,
+			want:      custom,
+		},
+	}
+
+	for name, tt := range tests {
+		app, _ := setupMemoryApp()
+		app.Service.Workflows = tt.workflows
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			app.HandleGetWorkflows(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(http.StatusOK, gotCode); diff != "" {
+				t.Fatalf("HandleGetWorkflows() mismatch (-want +got):\n%s", diff)
+			}
+			var got []types.Workflow
+			if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("HandleGetWorkflows() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHandlePostProjectWorkflow(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "username",
+		Email: "username@test.com",
+	}
+	tests := map[string]struct {
+		workflow     string
+		wantCode     int
+		wantStatuses []string
+		wantErr      *types.HTTPError
+	}{
+		"default workflow": {
+			wantCode:     http.StatusCreated,
+			wantStatuses: []string{"To Do", "In Progress", "Done"},
+		},
+		"named workflow": {
+			workflow:     "bugs",
+			wantCode:     http.StatusCreated,
+			wantStatuses: []string{"Open", "In Progress", "Fixed", "Won't Fix"},
+		},
+		"blank workflow": {
+			workflow:     "blank",
+			wantCode:     http.StatusCreated,
+			wantStatuses: []string{},
+		},
+		"unknown workflow": {
+			workflow: "unknown",
+			wantCode: http.StatusBadRequest,
+			wantErr: &types.HTTPError{
+				Code:    CodeValidation,
+				Message: "failed validation",
+				Fields:  []types.FieldError{{Field: "workflow", Message: "must be a name of a workflow"}},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		app, m := setupMemoryApp()
+		m.PutAccount(owner)
+		data, err := json.Marshal(service.AddProjectInput{Name: "project", OwnerId: owner.Id, Workflow: tt.workflow})
+		if err != nil {
+			t.Fatal(service.ErrFailedToPrepareTest)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+			req = req.WithContext(service.WithAccountId(req.Context(), owner.Id))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			res := httptest.NewRecorder()
+			c := e.NewContext(req, res)
+			app.HandlePostProject(c)
+
+			gotCode := res.Code
+			if diff := cmp.Diff(tt.wantCode, gotCode); diff != "" {
+				t.Fatalf("HandlePostProject() mismatch (-want +got):\n%s", diff)
+			}
+			if gotCode != http.StatusCreated {
+				if diff := cmp.Diff(tt.wantErr, errorBody(t, res)); diff != "" {
+					t.Fatalf("HandlePostProject() mismatch (-want +got):\n%s", diff)
+				}
+				return
+			}
+			pj := new(types.Project)
+			if err := json.NewDecoder(res.Body).Decode(pj); err != nil {
+				t.Fatal(err)
+			}
+			page, err := app.Service.GetStatusesByProjectId(req.Context(), pj.Id, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0)
+			for _, st := range page.Items {
+				got = append(got, st.Name)
+			}
+			if diff := cmp.Diff(tt.wantStatuses, got); diff != "" {
+				t.Fatalf("HandlePostProject() statuses mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -10,83 +10,206 @@ import (
 )
 
 type AddAccountInput struct {
-	Email  string `json:"email"`
-	Name   string `json:"name"`
-	Avatar string `json:"avatar,omitempty"`
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Avatar   string `json:"avatar,omitempty"`
+	Password string `json:"password"`
 }
 
+// UpdateAccountInput is a JSON merge patch of an account, a null avatar clears it.
 type UpdateAccountInput struct {
-	Id     string `param:"id"`
-	Email  string `json:"email,omitempty"`
-	Name   string `json:"name,omitempty"`
-	Avatar string `json:"avatar,omitempty"`
+	Id       string              `param:"id"`
+	Email    types.Patch[string] `json:"email" swaggertype:"string"`
+	Name     types.Patch[string] `json:"name" swaggertype:"string"`
+	Avatar   types.Patch[string] `json:"avatar" swaggertype:"string" extensions:"x-nullable"`
+	Password types.Patch[string] `json:"password" swaggertype:"string"`
 }
 
 // Errors returned: ErrFailedValidation, ErrInternal, ErrNotFound
 func (s *Service) GetAccountById(ctx context.Context, id string) (*types.Account, error) {
 	if _, err := uuid.Parse(id); err != nil {
-		return nil, ErrFailedValidation
+		return nil, invalid("id", "must be a valid UUID")
 	}
 
+	return s.accounts().GetById(ctx, id)
+}
+
+// Returned errors: ErrFailedValidation, ErrInternal
+func (s *Service) GetAllAccounts(ctx context.Context, params *ListParams) (*types.Page[types.Account], error) {
+	return s.accounts().List(ctx, params)
+}
+
+// Errors returned: ErrFailedValidation, ErrConflict, ErrInternal
+func (s *Service) AddAccount(ctx context.Context, input *AddAccountInput) (*types.Account, error) {
+	if input.Email == "" {
+		return nil, invalid("email", "must not be empty")
+	}
+
+	if input.Name == "" {
+		return nil, invalid("name", "must not be empty")
+	}
+
+	if err := validPassword(input.Password); err != nil {
+		return nil, err
+	}
+
+	hash, err := hashPassword(input.Password)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	return s.accounts().Add(ctx, input, hash)
+}
+
+// Only the account itself can be updated by the caller.
+//
+// Errors returned: ErrFailedValidation, ErrFailedToUpdate, ErrPreconditionFailed, ErrConflict, ErrInternal, ErrUnauthorized, ErrForbidden
+func (s *Service) UpdateAccount(ctx context.Context, input *UpdateAccountInput) error {
+	if _, err := uuid.Parse(input.Id); err != nil {
+		return invalid("id", "must be a valid UUID")
+	}
+	if err := notEmpty("email", input.Email); err != nil {
+		return err
+	}
+	if err := notEmpty("name", input.Name); err != nil {
+		return err
+	}
+	if input.Password.Set {
+		if err := validPassword(input.Password.Value); err != nil {
+			return err
+		}
+	}
+	if err := checkSelf(ctx, input.Id); err != nil {
+		return err
+	}
+
+	var hash string
+	if input.Password.Set {
+		var err error
+		hash, err = hashPassword(input.Password.Value)
+		if err != nil {
+			return ErrInternal
+		}
+	}
+
+	return s.transact(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if err := checkETag(ctx, input.Id, s.GetAccountById); err != nil {
+			return err
+		}
+
+		return s.accounts().Update(ctx, input, hash)
+	})
+}
+
+// Only the account itself can be deleted by the caller.
+// Projects owned by the account are deleted with it.
+//
+// Errors returned: ErrFailedValidation, ErrFailedToUpdate, ErrPreconditionFailed, ErrInternal, ErrUnauthorized, ErrForbidden
+func (s *Service) DeleteAccountById(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return invalid("id", "must be a valid UUID")
+	}
+	if err := checkSelf(ctx, id); err != nil {
+		return err
+	}
+
+	return s.transact(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if err := checkETag(ctx, id, s.GetAccountById); err != nil {
+			return err
+		}
+
+		return s.accounts().Delete(ctx, id)
+	})
+}
+
+// PostgresAccounts keeps accounts in Postgres.
+type PostgresAccounts struct {
+	DB *sql.DB
+}
+
+const accountColumns = "id, email, name, avatar, version, deleted, created_at, updated_at"
+
+func scanAccount(row scanner, acc *types.Account) error {
+	return row.Scan(&acc.Id, &acc.Email, &acc.Name, &acc.Avatar, &acc.Version, &acc.Deleted, &acc.CreatedAt, &acc.UpdatedAt)
+}
+
+var accountList = &listSpec[types.Account]{
+	query: "SELECT " + accountColumns + " FROM accounts",
+	sorts: map[string]sortField[types.Account]{
+		"id":         {"id", sortUUID, func(a *types.Account) string { return a.Id }},
+		"name":       {"name", sortText, func(a *types.Account) string { return a.Name }},
+		"email":      {"email", sortText, func(a *types.Account) string { return a.Email }},
+		"created_at": {"created_at", sortTime, func(a *types.Account) string { return timeValue(a.CreatedAt) }},
+		"updated_at": {"updated_at", sortTime, func(a *types.Account) string { return timeValue(a.UpdatedAt) }},
+	},
+	scan: scanAccount,
+}
+
+func (r *PostgresAccounts) GetById(ctx context.Context, id string) (*types.Account, error) {
 	var acc types.Account
-	query := "SELECT id, email, name, avatar, deleted, created_at, updated_at FROM accounts WHERE id::text=$1"
-	row := s.DB.QueryRowContext(ctx, query, id)
-	err := row.Scan(&acc.Id, &acc.Email, &acc.Name, &acc.Avatar, &acc.Deleted, &acc.CreatedAt, &acc.UpdatedAt)
+	query := "SELECT " + accountColumns + " FROM accounts WHERE id::text=$1 AND deleted=false"
+	err := scanAccount(conn(ctx, r.DB).QueryRowContext(ctx, query, id), &acc)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNotFound
 		}
-		return nil, ErrInternal
+		return nil, dbError(err)
 	}
 
 	return &acc, nil
 }
 
-// Returne errors: ErrInternal
-func (s *Service) GetAllAccounts(ctx context.Context) ([]types.Account, error) {
-	accs := make([]types.Account, 0)
+func (r *PostgresAccounts) List(ctx context.Context, params *ListParams) (*types.Page[types.Account], error) {
+	return list(ctx, conn(ctx, r.DB), accountList, params, []string{"deleted=false"}, nil)
+}
 
-	rows, err := s.DB.QueryContext(ctx, "SELECT id, email, name, avatar, deleted, created_at, updated_at FROM accounts")
+func (r *PostgresAccounts) Credentials(ctx context.Context, email string) (string, string, error) {
+	var id, hash string
+	query := "SELECT id, password_hash FROM accounts WHERE email=$1 AND deleted=false"
+	err := conn(ctx, r.DB).QueryRowContext(ctx, query, email).Scan(&id, &hash)
 	if err != nil {
-		return nil, ErrInternal
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", ErrNotFound
+		}
+		return "", "", dbError(err)
+	}
+
+	return id, hash, nil
+}
+
+func (r *PostgresAccounts) WithoutPassword(ctx context.Context) ([]types.Account, error) {
+	query := "SELECT " + accountColumns + " FROM accounts WHERE password_hash='' AND deleted=false ORDER BY created_at, id"
+	rows, err := conn(ctx, r.DB).QueryContext(ctx, query)
+	if err != nil {
+		return nil, dbError(err)
 	}
+	defer rows.Close()
 
+	accs := make([]types.Account, 0)
 	for rows.Next() {
 		var acc types.Account
-		err := rows.Scan(&acc.Id, &acc.Email, &acc.Name, &acc.Avatar, &acc.Deleted, &acc.CreatedAt, &acc.UpdatedAt)
-		if err != nil {
-			return nil, ErrInternal
-		}
-
-		if !acc.Deleted {
-			accs = append(accs, acc)
+		if err = scanAccount(rows, &acc); err != nil {
+			return nil, dbError(err)
 		}
+		accs = append(accs, acc)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, ErrInternal
+		return nil, dbError(err)
 	}
 
 	return accs, nil
 }
 
-// Errors returned: ErrFailedValidation, ErrFailedToUpdate, ErrFailedToInsert
-func (s *Service) AddAccount(ctx context.Context, input *AddAccountInput) error {
-	if input.Email == "" {
-		return ErrFailedValidation
-	}
-
-	if input.Name == "" {
-		return ErrFailedValidation
-	}
-
-	res, err := s.DB.ExecContext(ctx, "INSERT INTO accounts (name, email, avatar) VALUES ($1, $2, $3)", input.Name, input.Email, input.Avatar)
+func (r *PostgresAccounts) SetFirstPassword(ctx context.Context, id, hash string) error {
+	query := "UPDATE accounts SET password_hash=$1 WHERE id=$2 AND password_hash='' AND deleted=false"
+	res, err := conn(ctx, r.DB).ExecContext(ctx, query, hash, id)
 	if err != nil {
-		return ErrInternal
+		return dbError(err)
 	}
 	ra, err := res.RowsAffected()
 	if err != nil {
-		return ErrFailedToInsert
+		return dbError(err)
 	}
 	if ra < 1 {
 		return ErrFailedToUpdate
@@ -95,44 +218,85 @@ func (s *Service) AddAccount(ctx context.Context, input *AddAccountInput) error
 	return nil
 }
 
-// Errors returned: ErrFailedValidation, ErrFailedToUpdate, ErrInternal
-func (s *Service) UpdateAccount(ctx context.Context, input *UpdateAccountInput) error {
-	if _, err := uuid.Parse(input.Id); err != nil {
-		return ErrFailedValidation
-	}
+func (r *PostgresAccounts) Add(ctx context.Context, input *AddAccountInput, hash string) (*types.Account, error) {
+	var acc types.Account
+	err := transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		query := "INSERT INTO accounts (name, email, avatar, password_hash) VALUES ($1, $2, $3, $4) RETURNING " + accountColumns
+		err := scanAccount(tx.QueryRowContext(ctx, query, input.Name, input.Email, input.Avatar, hash), &acc)
+		if err != nil {
+			return dbError(err)
+		}
 
-	query := "UPDATE accounts SET name=COALESCE(NULLIF($1, ''), name), email=COALESCE(NULLIF($2, ''), email), avatar=COALESCE(NULLIF($3, ''), avatar) WHERE id=$4"
-	res, err := s.DB.ExecContext(ctx, query, input.Name, input.Email, input.Avatar, input.Id)
-	if err != nil {
-		return ErrInternal
-	}
-	ra, err := res.RowsAffected()
+		if err = claimInvitations(ctx, tx, acc.Email); err != nil {
+			return dbError(err)
+		}
+
+		return nil
+	})
 	if err != nil {
-		return ErrInternal
-	}
-	if ra < 1 {
-		return ErrFailedToUpdate
+		return nil, err
 	}
 
-	return nil
+	return &acc, nil
 }
 
-// Errors returned: ErrFailedValidation, ErrFailedToUpdate, ErrInternal
-func (s *Service) DeleteAccountById(ctx context.Context, id string) error {
-	if _, err := uuid.Parse(id); err != nil {
-		return ErrFailedValidation
-	}
-	res, err := s.DB.ExecContext(ctx, "UPDATE accounts SET deleted=true WHERE id=$1", id)
-	if err != nil {
-		return ErrInternal
-	}
-	ra, err := res.RowsAffected()
-	if err != nil {
-		return ErrFailedToUpdate
-	}
-	if ra < 1 {
-		return ErrFailedToUpdate
-	}
+func (r *PostgresAccounts) Update(ctx context.Context, input *UpdateAccountInput, hash string) error {
+	return transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		var u update
+		if input.Name.Set {
+			u.set("name", input.Name.Value)
+		}
+		if input.Email.Set {
+			u.set("email", input.Email.Value)
+		}
+		if input.Avatar.Set {
+			u.set("avatar", input.Avatar.Value)
+		}
+		if hash != "" {
+			u.set("password_hash", hash)
+		}
 
-	return nil
+		return u.exec(ctx, tx, "accounts", input.Id)
+	})
+}
+
+func (r *PostgresAccounts) Delete(ctx context.Context, id string) error {
+	return transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "UPDATE accounts SET deleted=true, deleted_at=now() WHERE id=$1 AND deleted=false", id)
+		if err != nil {
+			return dbError(err)
+		}
+		ra, err := res.RowsAffected()
+		if err != nil {
+			return ErrFailedToUpdate
+		}
+		if ra < 1 {
+			return ErrFailedToUpdate
+		}
+
+		rows, err := tx.QueryContext(ctx, "UPDATE projects SET deleted=true, deleted_at=now() WHERE owner_id=$1 AND deleted=false RETURNING id", id)
+		if err != nil {
+			return dbError(err)
+		}
+		defer rows.Close()
+
+		pIds := make([]string, 0)
+		for rows.Next() {
+			var pId string
+			if err = rows.Scan(&pId); err != nil {
+				return dbError(err)
+			}
+			pIds = append(pIds, pId)
+		}
+
+		if err := rows.Err(); err != nil {
+			return dbError(err)
+		}
+
+		if err := deleteProjectContents(ctx, tx, pIds); err != nil {
+			return err
+		}
+
+		return nil
+	})
 }
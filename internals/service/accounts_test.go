@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/danblok/pm/internals/types"
@@ -11,8 +12,6 @@ import (
 )
 
 func TestGetAccountById(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	accId := uuid.NewString()
 	tests := map[string]struct {
 		wantErr error
@@ -41,22 +40,18 @@ func TestGetAccountById(t *testing.T) {
 	}
 
 	for name, tt := range tests {
+		s, m := setupMemory()
 		if tt.want != nil {
-			_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", tt.want.Id, tt.want.Email, tt.want.Name)
-			if err != nil {
-				t.Fatal(ErrFailedToPrepareTest, err)
-			}
+			m.PutAccount(*tt.want)
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("accounts"))
-
 			ctx := context.Background()
 			got, err := s.GetAccountById(ctx, tt.input)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("GetAccountById() mismatch (-want +got):\n%s", diff)
 			}
-			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(types.Account{}, "CreatedAt", "UpdatedAt")); diff != "" {
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(types.Account{}, "CreatedAt", "UpdatedAt", "Version")); diff != "" {
 				t.Fatalf("GetAccountById() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -64,8 +59,6 @@ func TestGetAccountById(t *testing.T) {
 }
 
 func TestGetAllAccounts(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	tests := map[string]struct {
 		wantErr error
 		want    []types.Account
@@ -92,22 +85,18 @@ func TestGetAllAccounts(t *testing.T) {
 	}
 
 	for name, tt := range tests {
+		s, m := setupMemory()
 		for _, acc := range tt.want {
-			_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", acc.Id, acc.Email, acc.Name)
-			if err != nil {
-				t.Fatal(ErrFailedToPrepareTest, err)
-			}
+			m.PutAccount(acc)
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("accounts"))
-
 			ctx := context.Background()
-			got, err := s.GetAllAccounts(ctx)
+			got, err := s.GetAllAccounts(ctx, nil)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("GetAllAccounts() mismatch (-want +got):\n%s", diff)
 			}
-			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(types.Account{}, "CreatedAt", "UpdatedAt")); diff != "" {
+			if diff := cmp.Diff(tt.want, pageItems(got), cmpopts.IgnoreFields(types.Account{}, "CreatedAt", "UpdatedAt", "Version"), cmpopts.SortSlices(func(a, b types.Account) bool { return a.Id < b.Id })); diff != "" {
 				t.Fatalf("GetAllAccounts() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -115,30 +104,55 @@ func TestGetAllAccounts(t *testing.T) {
 }
 
 func TestAddAccount(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	tests := map[string]struct {
 		wantErr error
 		input   *AddAccountInput
 	}{
 		"succsessfull add": {
 			input: &AddAccountInput{
-				Name:  "username",
-				Email: "username@test.com",
+				Name:     "username",
+				Email:    "username@test.com",
+				Password: "password",
+			},
+			wantErr: nil,
+		},
+		"short password": {
+			input: &AddAccountInput{
+				Name:     "username",
+				Email:    "username@test.com",
+				Password: "pass",
+			},
+			wantErr: ErrFailedValidation,
+		},
+		"too long password": {
+			input: &AddAccountInput{
+				Name:     "username",
+				Email:    "username@test.com",
+				Password: strings.Repeat("p", MaxPasswordLen+1),
+			},
+			wantErr: ErrFailedValidation,
+		},
+		"longest password": {
+			input: &AddAccountInput{
+				Name:     "username",
+				Email:    "username@test.com",
+				Password: strings.Repeat("p", MaxPasswordLen),
 			},
 			wantErr: nil,
 		},
 		"invalid name": {
 			input: &AddAccountInput{
-				Name:  "",
-				Email: "username@test.com",
+				Name:     "",
+				Email:    "username@test.com",
+				Password: "password",
 			},
 			wantErr: ErrFailedValidation,
 		},
 		"invalid email": {
 			input: &AddAccountInput{
-				Name:  "Project",
-				Email: "",
+				Name:     "Project",
+				Email:    "",
+				Password: "password",
 			},
 			wantErr: ErrFailedValidation,
 		},
@@ -146,20 +160,27 @@ func TestAddAccount(t *testing.T) {
 
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("accounts"))
+			s, _ := setupMemory()
 
 			ctx := context.Background()
-			err := s.AddAccount(ctx, tt.input)
+			got, err := s.AddAccount(ctx, tt.input)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("AddAccount() mismatch (-want +got):\n%s", diff)
 			}
+			if err != nil {
+				return
+			}
+			if _, err := uuid.Parse(got.Id); err != nil {
+				t.Fatalf("AddAccount() returned invalid id %q", got.Id)
+			}
+			if diff := cmp.Diff(tt.input.Name, got.Name); diff != "" {
+				t.Fatalf("AddAccount() mismatch (-want +got):\n%s", diff)
+			}
 		})
 	}
 }
 
 func TestUpdateAccount(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	acc := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -172,38 +193,47 @@ func TestUpdateAccount(t *testing.T) {
 		"succsessfull update": {
 			input: &UpdateAccountInput{
 				Id:    acc.Id,
-				Name:  "New project",
-				Email: "newusername@test.com",
+				Name:  types.PatchOf("New project"),
+				Email: types.PatchOf("newusername@test.com"),
 			},
 			wantErr: nil,
 		},
 		"non-existent id": {
 			input: &UpdateAccountInput{
 				Id:    uuid.NewString(),
-				Name:  "New project",
-				Email: "newusername@test.com",
+				Name:  types.PatchOf("New project"),
+				Email: types.PatchOf("newusername@test.com"),
 			},
 			wantErr: ErrFailedToUpdate,
 		},
 		"invalid id": {
 			input: &UpdateAccountInput{
 				Id:    "invalid-id",
-				Name:  "",
-				Email: "username@test.com",
+				Email: types.PatchOf("username@test.com"),
+			},
+			wantErr: ErrFailedValidation,
+		},
+		"short password": {
+			input: &UpdateAccountInput{
+				Id:       acc.Id,
+				Password: types.PatchOf("pass"),
+			},
+			wantErr: ErrFailedValidation,
+		},
+		"too long password": {
+			input: &UpdateAccountInput{
+				Id:       acc.Id,
+				Password: types.PatchOf(strings.Repeat("p", MaxPasswordLen+1)),
 			},
 			wantErr: ErrFailedValidation,
 		},
 	}
 
 	for name, tt := range tests {
-		_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", acc.Id, acc.Email, acc.Name)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
+		s, m := setupMemory()
+		m.PutAccount(acc)
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("accounts"))
-
-			ctx := context.Background()
+			ctx := WithAccountId(context.Background(), tt.input.Id)
 			err := s.UpdateAccount(ctx, tt.input)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("UpdateAccount() mismatch (-want +got):\n%s", diff)
@@ -213,13 +243,12 @@ func TestUpdateAccount(t *testing.T) {
 }
 
 func TestDeleteAccountById(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	acc := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
 		Email: "username@test.com",
 	}
+	pId := uuid.NewString()
 	tests := map[string]struct {
 		wantErr error
 		input   string
@@ -239,18 +268,58 @@ func TestDeleteAccountById(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", acc.Id, acc.Email, acc.Name)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
+		s, m := setupMemory()
+		m.PutAccount(acc)
+		m.PutProject(types.Project{Id: pId, Name: "project", OwnerId: acc.Id})
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("accounts"))
-
-			ctx := context.Background()
+			ctx := WithAccountId(context.Background(), tt.input)
 			err := s.DeleteAccountById(ctx, tt.input)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("DeleteAccountById() mismatch (-want +got):\n%s", diff)
 			}
+			if err != nil {
+				return
+			}
+			if !m.projects[pId].Deleted {
+				t.Fatal("DeleteAccountById() left the owned project")
+			}
+		})
+	}
+}
+
+func TestAccountCallerCheck(t *testing.T) {
+	acc := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "username",
+		Email: "username@test.com",
+	}
+	tests := map[string]struct {
+		wantErr error
+		caller  string
+	}{
+		"anonymous": {
+			caller:  "",
+			wantErr: ErrUnauthorized,
+		},
+		"foreign account": {
+			caller:  uuid.NewString(),
+			wantErr: ErrForbidden,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(acc)
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), tt.caller)
+			err := s.UpdateAccount(ctx, &UpdateAccountInput{Id: acc.Id, Name: types.PatchOf("New name")})
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("UpdateAccount() mismatch (-want +got):\n%s", diff)
+			}
+			err = s.DeleteAccountById(ctx, acc.Id)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("DeleteAccountById() mismatch (-want +got):\n%s", diff)
+			}
 		})
 	}
 }
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/uuid"
+)
+
+type AssignTaskInput struct {
+	TaskId    string `param:"id"`
+	AccountId string `json:"account_id"`
+}
+
+// Returned errors: ErrFailedValidation, ErrInternal
+func (s *Service) GetAssigneesByTaskId(ctx context.Context, tId string) ([]types.Account, error) {
+	accs := make([]types.Account, 0)
+	if _, err := uuid.Parse(tId); err != nil {
+		return accs, invalid("task_id", "must be a valid UUID")
+	}
+
+	return s.assignees().ListByTask(ctx, tId)
+}
+
+// taskAssignees returns accounts assigned to the task tId in order of assignment.
+//
+// Returned errors: ErrInternal
+func taskAssignees(ctx context.Context, db querier, tId string) ([]types.Account, error) {
+	accs := make([]types.Account, 0)
+	query := `SELECT a.id, a.email, a.name, a.avatar, a.version, a.deleted, a.created_at, a.updated_at
+		FROM accounts a JOIN tasks_to_accounts ta ON ta.account_id=a.id
+		WHERE ta.task_id=$1 AND a.deleted=false
+		ORDER BY ta.created_at`
+	rows, err := db.QueryContext(ctx, query, tId)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	for rows.Next() {
+		var acc types.Account
+		err = rows.Scan(&acc.Id, &acc.Email, &acc.Name, &acc.Avatar, &acc.Version, &acc.Deleted, &acc.CreatedAt, &acc.UpdatedAt)
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		accs = append(accs, acc)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return accs, nil
+}
+
+// GetAssignedTasks returns tasks assigned to the account across all projects.
+//
+// Returned errors: ErrFailedValidation, ErrInternal
+func (s *Service) GetAssignedTasks(ctx context.Context, aId string, params *ListParams) (*types.Page[types.Task], error) {
+	if _, err := uuid.Parse(aId); err != nil {
+		return nil, invalid("account_id", "must be a valid UUID")
+	}
+
+	return s.assignees().ListTasks(ctx, aId, params)
+}
+
+// Only the owner and contributors of the task's project can be assigned to it.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToInsert, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) AssignTask(ctx context.Context, input *AssignTaskInput) error {
+	if _, err := uuid.Parse(input.TaskId); err != nil {
+		return invalid("task_id", "must be a valid UUID")
+	}
+	if _, err := uuid.Parse(input.AccountId); err != nil {
+		return invalid("account_id", "must be a valid UUID")
+	}
+
+	pId, err := s.taskProjectId(ctx, input.TaskId)
+	if err != nil {
+		return err
+	}
+	if err = s.authorize(ctx, pId, PermManageTasks); err != nil {
+		return err
+	}
+	role, err := s.roleInProject(ctx, pId, input.AccountId)
+	if err != nil {
+		return err
+	}
+	if role == "" {
+		return invalid("account_id", "must be the owner or a contributor of the project")
+	}
+
+	return s.assignees().Add(ctx, input.TaskId, input.AccountId)
+}
+
+// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) UnassignTask(ctx context.Context, tId, aId string) error {
+	if _, err := uuid.Parse(tId); err != nil {
+		return invalid("task_id", "must be a valid UUID")
+	}
+	if _, err := uuid.Parse(aId); err != nil {
+		return invalid("account_id", "must be a valid UUID")
+	}
+
+	pId, err := s.taskProjectId(ctx, tId)
+	if err != nil {
+		return err
+	}
+	if err = s.authorize(ctx, pId, PermManageTasks); err != nil {
+		return err
+	}
+
+	return s.assignees().Delete(ctx, tId, aId)
+}
+
+// PostgresAssignees keeps assignees of tasks in Postgres.
+type PostgresAssignees struct {
+	DB *sql.DB
+}
+
+func (r *PostgresAssignees) ListByTask(ctx context.Context, tId string) ([]types.Account, error) {
+	return taskAssignees(ctx, conn(ctx, r.DB), tId)
+}
+
+func (r *PostgresAssignees) ListTasks(ctx context.Context, aId string, params *ListParams) (*types.Page[types.Task], error) {
+	where := []string{
+		"id IN (SELECT task_id FROM tasks_to_accounts WHERE account_id=$1)",
+		"project_id IN (SELECT id FROM projects WHERE deleted=false)",
+		"deleted=false",
+	}
+	return list(ctx, conn(ctx, r.DB), taskList, params, where, []any{aId})
+}
+
+func (r *PostgresAssignees) Add(ctx context.Context, tId, aId string) error {
+	query := "INSERT INTO tasks_to_accounts (task_id, account_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+	res, err := conn(ctx, r.DB).ExecContext(ctx, query, tId, aId)
+	if err != nil {
+		return dbError(err)
+	}
+
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if ra < 1 {
+		return ErrFailedToInsert
+	}
+
+	return nil
+}
+
+func (r *PostgresAssignees) Delete(ctx context.Context, tId, aId string) error {
+	query := "DELETE FROM tasks_to_accounts WHERE task_id=$1 AND account_id=$2"
+	res, err := conn(ctx, r.DB).ExecContext(ctx, query, tId, aId)
+	if err != nil {
+		return dbError(err)
+	}
+
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if ra < 1 {
+		return ErrFailedToUpdate
+	}
+
+	return nil
+}
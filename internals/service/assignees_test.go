@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+)
+
+// setupAssigneesTest keeps an owner, a member, an outsider, a project,
+// a status and a task of the project in m.
+func setupAssigneesTest(m *Memory, owner, member, outsider *types.Account, task *types.Task) {
+	for _, acc := range []*types.Account{owner, member, outsider} {
+		m.PutAccount(*acc)
+	}
+	m.PutProject(types.Project{Id: task.ProjectId, Name: "project", OwnerId: owner.Id})
+	m.PutContributor(task.ProjectId, member.Id, types.RoleMember)
+	m.PutStatus(types.Status{Id: task.StatusId, Name: "todo", ProjectId: task.ProjectId})
+	m.PutTask(*task)
+}
+
+func TestAssignTask(t *testing.T) {
+	owner := types.Account{Id: uuid.NewString(), Name: "owner", Email: "owner@test.com"}
+	member := types.Account{Id: uuid.NewString(), Name: "member", Email: "member@test.com"}
+	outsider := types.Account{Id: uuid.NewString(), Name: "outsider", Email: "outsider@test.com"}
+	task := types.Task{Id: uuid.NewString(), Name: "task", ProjectId: uuid.NewString(), StatusId: uuid.NewString()}
+	tests := map[string]struct {
+		wantErr  error
+		caller   string
+		assigned []string
+		input    *AssignTaskInput
+	}{
+		"invalid task id": {
+			caller:  owner.Id,
+			input:   &AssignTaskInput{TaskId: "invalid-id", AccountId: member.Id},
+			wantErr: ErrFailedValidation,
+		},
+		"non-existent task": {
+			caller:  owner.Id,
+			input:   &AssignTaskInput{TaskId: uuid.NewString(), AccountId: member.Id},
+			wantErr: ErrNotFound,
+		},
+		"not a contributor": {
+			caller:  owner.Id,
+			input:   &AssignTaskInput{TaskId: task.Id, AccountId: outsider.Id},
+			wantErr: ErrFailedValidation,
+		},
+		"caller outside of the project": {
+			caller:  outsider.Id,
+			input:   &AssignTaskInput{TaskId: task.Id, AccountId: member.Id},
+			wantErr: ErrForbidden,
+		},
+		"already assigned": {
+			caller:   owner.Id,
+			assigned: []string{member.Id},
+			input:    &AssignTaskInput{TaskId: task.Id, AccountId: member.Id},
+			wantErr:  ErrFailedToInsert,
+		},
+		"assign the owner": {
+			caller:  member.Id,
+			input:   &AssignTaskInput{TaskId: task.Id, AccountId: owner.Id},
+			wantErr: nil,
+		},
+		"assign a contributor": {
+			caller:   owner.Id,
+			assigned: []string{owner.Id},
+			input:    &AssignTaskInput{TaskId: task.Id, AccountId: member.Id},
+			wantErr:  nil,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		setupAssigneesTest(m, &owner, &member, &outsider, &task)
+		for _, aId := range tt.assigned {
+			m.PutAssignee(task.Id, aId)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), tt.caller)
+			err := s.AssignTask(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("AssignTask() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnassignTask(t *testing.T) {
+	owner := types.Account{Id: uuid.NewString(), Name: "owner", Email: "owner@test.com"}
+	member := types.Account{Id: uuid.NewString(), Name: "member", Email: "member@test.com"}
+	outsider := types.Account{Id: uuid.NewString(), Name: "outsider", Email: "outsider@test.com"}
+	task := types.Task{Id: uuid.NewString(), Name: "task", ProjectId: uuid.NewString(), StatusId: uuid.NewString()}
+	tests := map[string]struct {
+		wantErr error
+		caller  string
+		input   string
+	}{
+		"invalid account id": {
+			caller:  owner.Id,
+			input:   "invalid-id",
+			wantErr: ErrFailedValidation,
+		},
+		"not assigned": {
+			caller:  owner.Id,
+			input:   owner.Id,
+			wantErr: ErrFailedToUpdate,
+		},
+		"caller outside of the project": {
+			caller:  outsider.Id,
+			input:   member.Id,
+			wantErr: ErrForbidden,
+		},
+		"successfull unassign": {
+			caller:  owner.Id,
+			input:   member.Id,
+			wantErr: nil,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		setupAssigneesTest(m, &owner, &member, &outsider, &task)
+		m.PutAssignee(task.Id, member.Id)
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), tt.caller)
+			err := s.UnassignTask(ctx, task.Id, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("UnassignTask() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetAssignedTasks(t *testing.T) {
+	owner := types.Account{Id: uuid.NewString(), Name: "owner", Email: "owner@test.com"}
+	member := types.Account{Id: uuid.NewString(), Name: "member", Email: "member@test.com"}
+	outsider := types.Account{Id: uuid.NewString(), Name: "outsider", Email: "outsider@test.com"}
+	task := types.Task{Id: uuid.NewString(), Name: "task", ProjectId: uuid.NewString(), StatusId: uuid.NewString()}
+	tests := map[string]struct {
+		wantErr error
+		input   string
+		want    []string
+	}{
+		"invalid account id": {
+			input:   "invalid-id",
+			wantErr: ErrFailedValidation,
+			want:    []string{},
+		},
+		"no tasks": {
+			input:   owner.Id,
+			wantErr: nil,
+			want:    []string{},
+		},
+		"assigned task": {
+			input:   member.Id,
+			wantErr: nil,
+			want:    []string{task.Id},
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		setupAssigneesTest(m, &owner, &member, &outsider, &task)
+		m.PutAssignee(task.Id, member.Id)
+
+		t.Run(name, func(t *testing.T) {
+			got, err := s.GetAssignedTasks(context.Background(), tt.input, nil)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("GetAssignedTasks() mismatch (-want +got):\n%s", diff)
+			}
+			ids := make([]string, 0)
+			for _, t := range pageItems(got) {
+				ids = append(ids, t.Id)
+			}
+			if diff := cmp.Diff(tt.want, ids); diff != "" {
+				t.Fatalf("GetAssignedTasks() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
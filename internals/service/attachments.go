@@ -0,0 +1,245 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+
+	"github.com/danblok/pm/internals/storage"
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/uuid"
+)
+
+// MaxAttachmentSize is the maximum size of an attachment in bytes.
+const MaxAttachmentSize = 10 << 20
+
+// sniffLen is the number of bytes http.DetectContentType considers.
+const sniffLen = 512
+
+// AddAttachmentInput is read from a multipart form, so it has no tags.
+type AddAttachmentInput struct {
+	TaskId  string
+	Name    string
+	Content io.Reader
+}
+
+const attachmentColumns = "id, task_id, COALESCE(uploader_id::text, ''), name, content_type, size, deleted, created_at, updated_at"
+
+func scanAttachment(row scanner, a *types.Attachment) error {
+	return row.Scan(&a.Id, &a.TaskId, &a.UploaderId, &a.Name, &a.ContentType, &a.Size, &a.Deleted, &a.CreatedAt, &a.UpdatedAt)
+}
+
+// Returned errors: ErrFailedValidation, ErrInternal
+func (s *Service) GetAttachmentsByTaskId(ctx context.Context, tId string) ([]types.Attachment, error) {
+	if _, err := uuid.Parse(tId); err != nil {
+		return make([]types.Attachment, 0), invalid("task_id", "must be a valid UUID")
+	}
+
+	return s.attachments().ListByTask(ctx, tId)
+}
+
+// OpenAttachment returns metadata and contents of the attachment,
+// the caller must close the contents.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrNotFound
+func (s *Service) OpenAttachment(ctx context.Context, tId, id string) (*types.Attachment, io.ReadCloser, error) {
+	if _, err := uuid.Parse(tId); err != nil {
+		return nil, nil, invalid("task_id", "must be a valid UUID")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, nil, invalid("id", "must be a valid UUID")
+	}
+
+	a, err := s.attachments().GetById(ctx, tId, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, err := s.Storage.Open(ctx, a.Id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotExist) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, ErrInternal
+	}
+
+	return a, r, nil
+}
+
+// AddAttachment stores the contents and attaches them to the task on behalf
+// of the caller. The content type is sniffed from the contents.
+//
+// Returned errors: ErrFailedValidation, ErrTooLarge, ErrInternal, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) AddAttachment(ctx context.Context, input *AddAttachmentInput) (*types.Attachment, error) {
+	if _, err := uuid.Parse(input.TaskId); err != nil {
+		return nil, invalid("task_id", "must be a valid UUID")
+	}
+	name := filepath.Base(filepath.Clean("/" + input.Name))
+	if name == "/" || len(name) > 255 {
+		return nil, invalid("name", "must be a file name of 1 to 255 characters")
+	}
+	if input.Content == nil {
+		return nil, invalid("content", "must be provided")
+	}
+	caller, err := callerId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pId, err := s.taskProjectId(ctx, input.TaskId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, pId, PermManageTasks); err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(input.Content, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, ErrInternal
+	}
+	head = head[:n]
+
+	id := uuid.NewString()
+	content := &sizeLimiter{r: io.MultiReader(bytes.NewReader(head), input.Content), max: MaxAttachmentSize}
+	err = s.Storage.Put(ctx, id, content)
+	if err != nil {
+		if errors.Is(err, ErrTooLarge) {
+			return nil, &Error{Err: ErrTooLarge, Message: fmt.Sprintf("attachments must not exceed %d bytes", MaxAttachmentSize)}
+		}
+		return nil, ErrInternal
+	}
+
+	a, err := s.attachments().Add(ctx, &types.Attachment{
+		Id:          id,
+		TaskId:      input.TaskId,
+		UploaderId:  caller,
+		Name:        name,
+		ContentType: http.DetectContentType(head),
+		Size:        content.n,
+	})
+	if err != nil {
+		s.Storage.Delete(ctx, id)
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Deleted attachments keep their contents in the storage.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) DeleteAttachment(ctx context.Context, tId, id string) error {
+	if _, err := uuid.Parse(tId); err != nil {
+		return invalid("task_id", "must be a valid UUID")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return invalid("id", "must be a valid UUID")
+	}
+	pId, err := s.taskProjectId(ctx, tId)
+	if err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, pId, PermManageTasks); err != nil {
+		return err
+	}
+
+	return s.attachments().Delete(ctx, tId, id)
+}
+
+// PostgresAttachments keeps metadata of attachments in Postgres.
+type PostgresAttachments struct {
+	DB *sql.DB
+}
+
+func (r *PostgresAttachments) ListByTask(ctx context.Context, tId string) ([]types.Attachment, error) {
+	query := "SELECT " + attachmentColumns + " FROM attachments WHERE task_id=$1 AND deleted=false ORDER BY created_at, id"
+	rows, err := conn(ctx, r.DB).QueryContext(ctx, query, tId)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	as := make([]types.Attachment, 0)
+	for rows.Next() {
+		var a types.Attachment
+		err = scanAttachment(rows, &a)
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		as = append(as, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return as, nil
+}
+
+func (r *PostgresAttachments) GetById(ctx context.Context, tId, id string) (*types.Attachment, error) {
+	var a types.Attachment
+	query := "SELECT " + attachmentColumns + " FROM attachments WHERE id=$1 AND task_id=$2 AND deleted=false"
+	err := scanAttachment(conn(ctx, r.DB).QueryRowContext(ctx, query, id, tId), &a)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, dbError(err)
+	}
+
+	return &a, nil
+}
+
+func (r *PostgresAttachments) Add(ctx context.Context, a *types.Attachment) (*types.Attachment, error) {
+	var res types.Attachment
+	query := `INSERT INTO attachments (id, task_id, uploader_id, name, content_type, size)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING ` + attachmentColumns
+	row := conn(ctx, r.DB).QueryRowContext(ctx, query, a.Id, a.TaskId, a.UploaderId, a.Name, a.ContentType, a.Size)
+	if err := scanAttachment(row, &res); err != nil {
+		return nil, dbError(err)
+	}
+
+	return &res, nil
+}
+
+func (r *PostgresAttachments) Delete(ctx context.Context, tId, id string) error {
+	res, err := conn(ctx, r.DB).ExecContext(ctx, "UPDATE attachments SET deleted=true, updated_at=now() WHERE id=$1 AND task_id=$2 AND deleted=false", id, tId)
+	if err != nil {
+		return dbError(err)
+	}
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+
+	if ra < 1 {
+		return ErrFailedToUpdate
+	}
+
+	return nil
+}
+
+// sizeLimiter counts bytes read from r and fails with ErrTooLarge
+// once more than max bytes are read.
+type sizeLimiter struct {
+	r   io.Reader
+	n   int64
+	max int64
+}
+
+func (l *sizeLimiter) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	if l.n > l.max {
+		return n, ErrTooLarge
+	}
+
+	return n, err
+}
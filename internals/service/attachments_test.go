@@ -0,0 +1,153 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/danblok/pm/internals/storage"
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+)
+
+func TestSizeLimiter(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		max     int64
+		wantErr error
+	}{
+		"under limit": {
+			input: "abc",
+			max:   4,
+		},
+		"at limit": {
+			input: "abcd",
+			max:   4,
+		},
+		"over limit": {
+			input:   "abcde",
+			max:     4,
+			wantErr: ErrTooLarge,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			l := &sizeLimiter{r: strings.NewReader(tt.input), max: tt.max}
+			_, err := io.Copy(io.Discard, l)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("Read() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAddAttachment(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	viewer := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "viewer",
+		Email: "viewer@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	task := types.Task{
+		Id:        uuid.NewString(),
+		Name:      "task",
+		ProjectId: project.Id,
+		StatusId:  uuid.NewString(),
+	}
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 16)...)
+	tests := map[string]struct {
+		wantErr  error
+		wantType string
+		caller   string
+		name     string
+		content  []byte
+	}{
+		"empty name": {
+			caller:  owner.Id,
+			content: png,
+			wantErr: ErrFailedValidation,
+		},
+		"viewer uploads": {
+			caller:  viewer.Id,
+			name:    "image.png",
+			content: png,
+			wantErr: ErrForbidden,
+		},
+		"too large": {
+			caller:  owner.Id,
+			name:    "big.txt",
+			content: bytes.Repeat([]byte("a"), MaxAttachmentSize+1),
+			wantErr: ErrTooLarge,
+		},
+		"succsessfull png upload": {
+			caller:   owner.Id,
+			name:     "../image.png",
+			content:  png,
+			wantType: "image/png",
+		},
+		"succsessfull text upload": {
+			caller:   owner.Id,
+			name:     "notes.txt",
+			content:  []byte("notes"),
+			wantType: "text/plain; charset=utf-8",
+		},
+	}
+
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("storage: %s", err)
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		s.Storage = store
+		m.PutAccount(owner)
+		m.PutAccount(viewer)
+		m.PutProject(project)
+		m.PutContributor(project.Id, viewer.Id, types.RoleViewer)
+		m.PutStatus(types.Status{Id: task.StatusId, Name: "todo", ProjectId: project.Id})
+		m.PutTask(task)
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), tt.caller)
+			input := &AddAttachmentInput{TaskId: task.Id, Name: tt.name, Content: bytes.NewReader(tt.content)}
+			got, err := s.AddAttachment(ctx, input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("AddAttachment() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.wantType, got.ContentType); diff != "" {
+				t.Fatalf("AddAttachment() mismatch (-want +got):\n%s", diff)
+			}
+
+			_, r, err := s.OpenAttachment(ctx, task.Id, got.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			content, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.content, content); diff != "" {
+				t.Fatalf("OpenAttachment() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
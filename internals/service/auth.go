@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/golang-jwt/jwt"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+	SetupTokenTTL   = 7 * 24 * time.Hour
+	MinPasswordLen  = 8
+	// MaxPasswordLen is the length in bytes bcrypt can hash.
+	MaxPasswordLen = 72
+
+	accessTokenType  = "access"
+	refreshTokenType = "refresh"
+	setupTokenType   = "setup"
+)
+
+type LoginInput struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type SetPasswordInput struct {
+	SetupToken string `json:"setup_token"`
+	Password   string `json:"password"`
+}
+
+type claims struct {
+	jwt.StandardClaims
+	Type string `json:"type"`
+}
+
+type ctxKey int
+
+const (
+	accountIdKey ctxKey = iota
+	etagKey
+)
+
+// WithAccountId returns a copy of ctx that carries the id of the account making the request.
+func WithAccountId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, accountIdKey, id)
+}
+
+// AccountIdFromContext returns the id of the account making the request.
+func AccountIdFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(accountIdKey).(string)
+	return id, ok && id != ""
+}
+
+// Errors returned: ErrFailedValidation, ErrUnauthorized, ErrInternal
+func (s *Service) Login(ctx context.Context, input *LoginInput) (*types.Tokens, error) {
+	if input.Email == "" {
+		return nil, invalid("email", "must not be empty")
+	}
+	if input.Password == "" {
+		return nil, invalid("password", "must not be empty")
+	}
+
+	id, hash, err := s.accounts().Credentials(ctx, input.Email)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrUnauthorized
+		}
+		return nil, err
+	}
+
+	if hash == "" || bcrypt.CompareHashAndPassword([]byte(hash), []byte(input.Password)) != nil {
+		return nil, ErrUnauthorized
+	}
+
+	return s.issueTokens(id)
+}
+
+// Errors returned: ErrFailedValidation, ErrUnauthorized, ErrInternal
+func (s *Service) Refresh(ctx context.Context, input *RefreshInput) (*types.Tokens, error) {
+	if input.RefreshToken == "" {
+		return nil, invalid("refresh_token", "must not be empty")
+	}
+
+	id, err := s.parseToken(input.RefreshToken, refreshTokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = s.accounts().GetById(ctx, id); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrUnauthorized
+		}
+		return nil, err
+	}
+
+	return s.issueTokens(id)
+}
+
+// IssueSetupTokens issues setup tokens to accounts that have no password,
+// i.e. accounts created before accounts had passwords, keyed by their emails.
+// A setup token lets its account set a first password with SetPassword.
+//
+// Errors returned: ErrInternal
+func (s *Service) IssueSetupTokens(ctx context.Context) (map[string]string, error) {
+	accs, err := s.accounts().WithoutPassword(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make(map[string]string, len(accs))
+	for _, acc := range accs {
+		token, err := s.signToken(acc.Id, setupTokenType, SetupTokenTTL)
+		if err != nil {
+			return nil, ErrInternal
+		}
+		tokens[acc.Email] = token
+	}
+
+	return tokens, nil
+}
+
+// SetPassword sets the first password of the account a setup token was issued to
+// and logs it in. It fails if the account already has a password.
+//
+// Errors returned: ErrFailedValidation, ErrUnauthorized, ErrInternal
+func (s *Service) SetPassword(ctx context.Context, input *SetPasswordInput) (*types.Tokens, error) {
+	if input.SetupToken == "" {
+		return nil, invalid("setup_token", "must not be empty")
+	}
+	if err := validPassword(input.Password); err != nil {
+		return nil, err
+	}
+
+	id, err := s.parseToken(input.SetupToken, setupTokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := hashPassword(input.Password)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	if err = s.accounts().SetFirstPassword(ctx, id, hash); err != nil {
+		if errors.Is(err, ErrFailedToUpdate) {
+			return nil, ErrUnauthorized
+		}
+		return nil, err
+	}
+
+	return s.issueTokens(id)
+}
+
+// ParseAccessToken validates an access token and returns the id of the account it was issued to.
+//
+// Errors returned: ErrUnauthorized
+func (s *Service) ParseAccessToken(token string) (string, error) {
+	return s.parseToken(token, accessTokenType)
+}
+
+func (s *Service) parseToken(token, typ string) (string, error) {
+	var c claims
+	_, err := jwt.ParseWithClaims(token, &c, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrUnauthorized
+		}
+		return s.Secret, nil
+	})
+	if err != nil || c.Type != typ || c.Subject == "" {
+		return "", ErrUnauthorized
+	}
+
+	return c.Subject, nil
+}
+
+func (s *Service) issueTokens(id string) (*types.Tokens, error) {
+	access, err := s.signToken(id, accessTokenType, AccessTokenTTL)
+	if err != nil {
+		return nil, ErrInternal
+	}
+	refresh, err := s.signToken(id, refreshTokenType, RefreshTokenTTL)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	return &types.Tokens{
+		AccessToken:  access,
+		RefreshToken: refresh,
+	}, nil
+}
+
+func (s *Service) signToken(id, typ string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		StandardClaims: jwt.StandardClaims{
+			Subject:   id,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
+		},
+		Type: typ,
+	})
+
+	return token.SignedString(s.Secret)
+}
+
+// Returned errors: ErrFailedValidation
+func validPassword(password string) error {
+	if len(password) < MinPasswordLen {
+		return invalid("password", "must be at least 8 characters long")
+	}
+	if len(password) > MaxPasswordLen {
+		return invalid("password", "must be at most 72 bytes")
+	}
+
+	return nil
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// callerId returns the id of the account making the request.
+//
+// Errors returned: ErrUnauthorized
+func callerId(ctx context.Context) (string, error) {
+	id, ok := AccountIdFromContext(ctx)
+	if !ok {
+		return "", ErrUnauthorized
+	}
+
+	return id, nil
+}
+
+// checkSelf makes sure that the caller is the account with the given id.
+//
+// Errors returned: ErrUnauthorized, ErrForbidden
+func checkSelf(ctx context.Context, id string) error {
+	caller, err := callerId(ctx)
+	if err != nil {
+		return err
+	}
+	if caller != id {
+		return ErrForbidden
+	}
+
+	return nil
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+)
+
+func TestLogin(t *testing.T) {
+	acc := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "username",
+		Email: "username@test.com",
+	}
+	hash, err := hashPassword("password")
+	if err != nil {
+		t.Fatal(ErrFailedToPrepareTest, err)
+	}
+	tests := map[string]struct {
+		wantErr error
+		input   *LoginInput
+	}{
+		"succsessfull login": {
+			input:   &LoginInput{Email: acc.Email, Password: "password"},
+			wantErr: nil,
+		},
+		"wrong password": {
+			input:   &LoginInput{Email: acc.Email, Password: "wrong password"},
+			wantErr: ErrUnauthorized,
+		},
+		"non-existent email": {
+			input:   &LoginInput{Email: "nobody@test.com", Password: "password"},
+			wantErr: ErrUnauthorized,
+		},
+		"empty password": {
+			input:   &LoginInput{Email: acc.Email},
+			wantErr: ErrFailedValidation,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(acc)
+		m.PutPassword(acc.Id, hash)
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			got, err := s.Login(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("Login() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			id, err := s.ParseAccessToken(got.AccessToken)
+			if err != nil {
+				t.Fatalf("ParseAccessToken() unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(acc.Id, id); diff != "" {
+				t.Fatalf("ParseAccessToken() mismatch (-want +got):\n%s", diff)
+			}
+			if _, err := s.ParseAccessToken(got.RefreshToken); err == nil {
+				t.Fatal("ParseAccessToken() accepted a refresh token")
+			}
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	s, m := setupMemory()
+
+	acc := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "username",
+		Email: "username@test.com",
+	}
+	tokens, err := s.issueTokens(acc.Id)
+	if err != nil {
+		t.Fatal(ErrFailedToPrepareTest, err)
+	}
+	m.PutAccount(acc)
+	foreign, err := (&Service{Secret: []byte("another secret")}).issueTokens(acc.Id)
+	if err != nil {
+		t.Fatal(ErrFailedToPrepareTest, err)
+	}
+	tests := map[string]struct {
+		wantErr error
+		input   *RefreshInput
+	}{
+		"succsessfull refresh": {
+			input:   &RefreshInput{RefreshToken: tokens.RefreshToken},
+			wantErr: nil,
+		},
+		"access token": {
+			input:   &RefreshInput{RefreshToken: tokens.AccessToken},
+			wantErr: ErrUnauthorized,
+		},
+		"foreign signature": {
+			input:   &RefreshInput{RefreshToken: foreign.RefreshToken},
+			wantErr: ErrUnauthorized,
+		},
+		"empty token": {
+			input:   &RefreshInput{},
+			wantErr: ErrFailedValidation,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, err := s.Refresh(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("Refresh() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIssueSetupTokens(t *testing.T) {
+	s, m := setupMemory()
+	ctx := context.Background()
+
+	legacy := types.Account{Id: uuid.NewString(), Name: "legacy", Email: "legacy@test.com"}
+	m.PutAccount(legacy)
+	m.PutAccount(types.Account{Id: uuid.NewString(), Name: "deleted", Email: "deleted@test.com", Deleted: true})
+	_, err := s.AddAccount(ctx, &AddAccountInput{Name: "username", Email: "username@test.com", Password: "password"})
+	if err != nil {
+		t.Fatal(ErrFailedToPrepareTest, err)
+	}
+
+	tokens, err := s.IssueSetupTokens(ctx)
+	if err != nil {
+		t.Fatalf("IssueSetupTokens() unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(1, len(tokens)); diff != "" {
+		t.Fatalf("IssueSetupTokens() mismatch (-want +got):\n%s", diff)
+	}
+	id, err := s.parseToken(tokens[legacy.Email], setupTokenType)
+	if err != nil {
+		t.Fatalf("parseToken() unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(legacy.Id, id); diff != "" {
+		t.Fatalf("parseToken() mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := s.ParseAccessToken(tokens[legacy.Email]); err == nil {
+		t.Fatal("ParseAccessToken() accepted a setup token")
+	}
+}
+
+func TestSetPassword(t *testing.T) {
+	legacy := types.Account{Id: uuid.NewString(), Name: "legacy", Email: "legacy@test.com"}
+	tests := map[string]struct {
+		wantErr  error
+		password string
+		token    func(s *Service, tokens map[string]string) string
+	}{
+		"succsessfull set": {
+			password: "password",
+			token: func(s *Service, tokens map[string]string) string {
+				return tokens[legacy.Email]
+			},
+		},
+		"short password": {
+			password: "pass",
+			token: func(s *Service, tokens map[string]string) string {
+				return tokens[legacy.Email]
+			},
+			wantErr: ErrFailedValidation,
+		},
+		"empty token": {
+			password: "password",
+			token: func(s *Service, tokens map[string]string) string {
+				return ""
+			},
+			wantErr: ErrFailedValidation,
+		},
+		"access token": {
+			password: "password",
+			token: func(s *Service, tokens map[string]string) string {
+				got, err := s.issueTokens(legacy.Id)
+				if err != nil {
+					t.Fatal(ErrFailedToPrepareTest, err)
+				}
+				return got.AccessToken
+			},
+			wantErr: ErrUnauthorized,
+		},
+		"password already set": {
+			password: "password",
+			token: func(s *Service, tokens map[string]string) string {
+				_, err := s.SetPassword(context.Background(), &SetPasswordInput{SetupToken: tokens[legacy.Email], Password: "first password"})
+				if err != nil {
+					t.Fatal(ErrFailedToPrepareTest, err)
+				}
+				return tokens[legacy.Email]
+			},
+			wantErr: ErrUnauthorized,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, m := setupMemory()
+			m.PutAccount(legacy)
+			ctx := context.Background()
+			tokens, err := s.IssueSetupTokens(ctx)
+			if err != nil {
+				t.Fatal(ErrFailedToPrepareTest, err)
+			}
+
+			input := &SetPasswordInput{SetupToken: tt.token(s, tokens), Password: tt.password}
+			_, err = s.SetPassword(ctx, input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("SetPassword() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if _, err := s.Login(ctx, &LoginInput{Email: legacy.Email, Password: tt.password}); err != nil {
+				t.Fatalf("Login() unexpected error: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type AddCommentInput struct {
+	TaskId   string `param:"id"`
+	ParentId string `json:"parent_id,omitempty"`
+	Body     string `json:"body"`
+}
+
+type UpdateCommentInput struct {
+	TaskId string `param:"id"`
+	Id     string `param:"cid"`
+	Body   string `json:"body"`
+}
+
+const commentColumns = `id, task_id, COALESCE(author_id::text, ''), COALESCE(parent_id::text, ''), body,
+	EXISTS (SELECT 1 FROM comment_revisions r WHERE r.comment_id=comments.id), deleted, created_at, updated_at`
+
+func scanComment(row scanner, c *types.Comment) error {
+	return row.Scan(&c.Id, &c.TaskId, &c.AuthorId, &c.ParentId, &c.Body, &c.Edited, &c.Deleted, &c.CreatedAt, &c.UpdatedAt)
+}
+
+var commentList = &listSpec[types.Comment]{
+	query: "SELECT " + commentColumns + " FROM comments",
+	sorts: map[string]sortField[types.Comment]{
+		"id":         {"id", sortUUID, func(c *types.Comment) string { return c.Id }},
+		"created_at": {"created_at", sortTime, func(c *types.Comment) string { return timeValue(c.CreatedAt) }},
+		"updated_at": {"updated_at", sortTime, func(c *types.Comment) string { return timeValue(c.UpdatedAt) }},
+	},
+	scan: scanComment,
+}
+
+// GetCommentsByTaskId returns a page of top-level comments of the task,
+// each with all of its replies.
+//
+// Returned errors: ErrFailedValidation, ErrInternal
+func (s *Service) GetCommentsByTaskId(ctx context.Context, tId string, params *ListParams) (*types.Page[types.Comment], error) {
+	if _, err := uuid.Parse(tId); err != nil {
+		return nil, invalid("task_id", "must be a valid UUID")
+	}
+
+	return s.comments().ListByTask(ctx, tId, params)
+}
+
+// Comments are written on behalf of the caller. A reply can only be
+// added to a top-level comment of the same task.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) AddComment(ctx context.Context, input *AddCommentInput) (*types.Comment, error) {
+	if _, err := uuid.Parse(input.TaskId); err != nil {
+		return nil, invalid("task_id", "must be a valid UUID")
+	}
+	if input.ParentId != "" {
+		if _, err := uuid.Parse(input.ParentId); err != nil {
+			return nil, invalid("parent_id", "must be a valid UUID")
+		}
+	}
+	if input.Body == "" {
+		return nil, invalid("body", "must not be empty")
+	}
+	caller, err := callerId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pId, err := s.taskProjectId(ctx, input.TaskId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, pId, PermComment); err != nil {
+		return nil, err
+	}
+
+	return s.comments().Add(ctx, input, caller)
+}
+
+// Only the author can edit a comment, the previous body is kept in its revisions.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrConcurrentUpdate, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) UpdateComment(ctx context.Context, input *UpdateCommentInput) error {
+	if _, err := uuid.Parse(input.TaskId); err != nil {
+		return invalid("task_id", "must be a valid UUID")
+	}
+	if _, err := uuid.Parse(input.Id); err != nil {
+		return invalid("id", "must be a valid UUID")
+	}
+	if input.Body == "" {
+		return invalid("body", "must not be empty")
+	}
+	caller, err := callerId(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.comments().Update(ctx, input, caller)
+}
+
+// Comments can be deleted by their authors and by contributors with
+// PermModerateComments. Deleting a top-level comment deletes its replies.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) DeleteComment(ctx context.Context, tId, id string) error {
+	if _, err := uuid.Parse(tId); err != nil {
+		return invalid("task_id", "must be a valid UUID")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return invalid("id", "must be a valid UUID")
+	}
+	c, err := s.comments().GetById(ctx, tId, id)
+	if err != nil {
+		return err
+	}
+	if err := checkSelf(ctx, c.AuthorId); err != nil {
+		if !errors.Is(err, ErrForbidden) {
+			return err
+		}
+		pId, err := s.taskProjectId(ctx, tId)
+		if err != nil {
+			return err
+		}
+		if err := s.authorize(ctx, pId, PermModerateComments); err != nil {
+			return err
+		}
+	}
+
+	return s.comments().Delete(ctx, c.Id)
+}
+
+// Returned errors: ErrFailedValidation, ErrInternal, ErrNotFound
+func (s *Service) GetCommentRevisions(ctx context.Context, tId, id string) ([]types.CommentRevision, error) {
+	revs := make([]types.CommentRevision, 0)
+	if _, err := uuid.Parse(tId); err != nil {
+		return revs, invalid("task_id", "must be a valid UUID")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return revs, invalid("id", "must be a valid UUID")
+	}
+	if _, err := s.comments().GetById(ctx, tId, id); err != nil {
+		return nil, err
+	}
+
+	return s.comments().Revisions(ctx, id)
+}
+
+// PostgresComments keeps comments of tasks in Postgres.
+type PostgresComments struct {
+	DB *sql.DB
+}
+
+func (r *PostgresComments) ListByTask(ctx context.Context, tId string, params *ListParams) (*types.Page[types.Comment], error) {
+	page, err := list(ctx, conn(ctx, r.DB), commentList, params, []string{"task_id=$1", "parent_id IS NULL", "deleted=false"}, []any{tId})
+	if err != nil {
+		return nil, err
+	}
+	if len(page.Items) == 0 {
+		return page, nil
+	}
+
+	ids := make([]string, 0, len(page.Items))
+	parents := make(map[string]*types.Comment, len(page.Items))
+	for i := range page.Items {
+		ids = append(ids, page.Items[i].Id)
+		parents[page.Items[i].Id] = &page.Items[i]
+	}
+
+	query := "SELECT " + commentColumns + " FROM comments WHERE parent_id = ANY($1) AND deleted=false ORDER BY created_at, id"
+	rows, err := conn(ctx, r.DB).QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c types.Comment
+		if err = scanComment(rows, &c); err != nil {
+			return nil, dbError(err)
+		}
+
+		p := parents[c.ParentId]
+		p.Replies = append(p.Replies, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return page, nil
+}
+
+func (r *PostgresComments) GetById(ctx context.Context, tId, id string) (*types.Comment, error) {
+	var c types.Comment
+	query := "SELECT " + commentColumns + " FROM comments WHERE id=$1 AND task_id=$2 AND deleted=false"
+	err := scanComment(conn(ctx, r.DB).QueryRowContext(ctx, query, id, tId), &c)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, dbError(err)
+	}
+
+	return &c, nil
+}
+
+func (r *PostgresComments) Add(ctx context.Context, input *AddCommentInput, authorId string) (*types.Comment, error) {
+	if input.ParentId != "" {
+		var exists bool
+		query := "SELECT EXISTS (SELECT 1 FROM comments WHERE id=$1 AND task_id=$2 AND parent_id IS NULL AND deleted=false)"
+		if err := conn(ctx, r.DB).QueryRowContext(ctx, query, input.ParentId, input.TaskId).Scan(&exists); err != nil {
+			return nil, dbError(err)
+		}
+		if !exists {
+			return nil, invalid("parent_id", "must be a top-level comment of the task")
+		}
+	}
+
+	var c types.Comment
+	query := "INSERT INTO comments (task_id, author_id, parent_id, body) VALUES ($1, $2, NULLIF($3, '')::uuid, $4) RETURNING " + commentColumns
+	row := conn(ctx, r.DB).QueryRowContext(ctx, query, input.TaskId, authorId, input.ParentId, input.Body)
+	if err := scanComment(row, &c); err != nil {
+		return nil, dbError(err)
+	}
+
+	return &c, nil
+}
+
+// Update locks the comment while its body is compared and replaced,
+// so concurrent edits can't record a stale revision.
+func (r *PostgresComments) Update(ctx context.Context, input *UpdateCommentInput, authorId string) error {
+	return transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		var c types.Comment
+		query := "SELECT " + commentColumns + " FROM comments WHERE id=$1 AND task_id=$2 AND deleted=false FOR UPDATE"
+		err := scanComment(tx.QueryRowContext(ctx, query, input.Id, input.TaskId), &c)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotFound
+			}
+			return dbError(err)
+		}
+		if c.AuthorId != authorId {
+			return ErrForbidden
+		}
+		if c.Body == input.Body {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO comment_revisions (comment_id, body) VALUES ($1, $2)", c.Id, c.Body)
+		if err != nil {
+			return dbError(err)
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE comments SET body=$1, updated_at=now() WHERE id=$2", input.Body, c.Id)
+		if err != nil {
+			return dbError(err)
+		}
+
+		return nil
+	})
+}
+
+func (r *PostgresComments) Delete(ctx context.Context, id string) error {
+	query := "UPDATE comments SET deleted=true, updated_at=now() WHERE (id=$1 OR parent_id=$1) AND deleted=false"
+	_, err := conn(ctx, r.DB).ExecContext(ctx, query, id)
+	if err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (r *PostgresComments) Revisions(ctx context.Context, id string) ([]types.CommentRevision, error) {
+	query := "SELECT id, comment_id, body, created_at FROM comment_revisions WHERE comment_id=$1 ORDER BY created_at, id"
+	rows, err := conn(ctx, r.DB).QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	revs := make([]types.CommentRevision, 0)
+	for rows.Next() {
+		var rev types.CommentRevision
+		err = rows.Scan(&rev.Id, &rev.CommentId, &rev.Body, &rev.CreatedAt)
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		revs = append(revs, rev)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return revs, nil
+}
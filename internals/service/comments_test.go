@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+)
+
+func TestAddComment(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	stranger := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "stranger",
+		Email: "stranger@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	task := types.Task{
+		Id:        uuid.NewString(),
+		Name:      "task",
+		ProjectId: project.Id,
+		StatusId:  uuid.NewString(),
+	}
+	comment := types.Comment{
+		Id:       uuid.NewString(),
+		TaskId:   task.Id,
+		AuthorId: owner.Id,
+		Body:     "comment",
+	}
+	reply := types.Comment{
+		Id:       uuid.NewString(),
+		TaskId:   task.Id,
+		AuthorId: owner.Id,
+		ParentId: comment.Id,
+		Body:     "reply",
+	}
+	tests := map[string]struct {
+		wantErr error
+		caller  string
+		input   *AddCommentInput
+	}{
+		"empty body": {
+			caller:  owner.Id,
+			input:   &AddCommentInput{TaskId: task.Id},
+			wantErr: ErrFailedValidation,
+		},
+		"stranger comments": {
+			caller:  stranger.Id,
+			input:   &AddCommentInput{TaskId: task.Id, Body: "body"},
+			wantErr: ErrForbidden,
+		},
+		"non-existent task": {
+			caller:  owner.Id,
+			input:   &AddCommentInput{TaskId: uuid.NewString(), Body: "body"},
+			wantErr: ErrNotFound,
+		},
+		"reply to reply": {
+			caller:  owner.Id,
+			input:   &AddCommentInput{TaskId: task.Id, ParentId: reply.Id, Body: "body"},
+			wantErr: ErrFailedValidation,
+		},
+		"succsessfull reply": {
+			caller:  owner.Id,
+			input:   &AddCommentInput{TaskId: task.Id, ParentId: comment.Id, Body: "body"},
+			wantErr: nil,
+		},
+		"succsessfull add": {
+			caller:  owner.Id,
+			input:   &AddCommentInput{TaskId: task.Id, Body: "body"},
+			wantErr: nil,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutAccount(stranger)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: task.StatusId, Name: "todo", ProjectId: project.Id})
+		m.PutTask(task)
+		m.PutComment(comment)
+		m.PutComment(reply)
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), tt.caller)
+			got, err := s.AddComment(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("AddComment() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			want := &types.Comment{Id: got.Id, TaskId: task.Id, AuthorId: tt.caller, ParentId: tt.input.ParentId, Body: tt.input.Body}
+			if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(types.Comment{}, "CreatedAt", "UpdatedAt")); diff != "" {
+				t.Fatalf("AddComment() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUpdateComment(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	author := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "author",
+		Email: "author@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	task := types.Task{
+		Id:        uuid.NewString(),
+		Name:      "task",
+		ProjectId: project.Id,
+		StatusId:  uuid.NewString(),
+	}
+	comment := types.Comment{
+		Id:       uuid.NewString(),
+		TaskId:   task.Id,
+		AuthorId: author.Id,
+		Body:     "comment",
+	}
+	tests := map[string]struct {
+		wantErr error
+		caller  string
+		input   *UpdateCommentInput
+	}{
+		"invalid id": {
+			caller:  author.Id,
+			input:   &UpdateCommentInput{TaskId: task.Id, Id: "invalid-id", Body: "edited"},
+			wantErr: ErrFailedValidation,
+		},
+		"non-existent comment": {
+			caller:  author.Id,
+			input:   &UpdateCommentInput{TaskId: task.Id, Id: uuid.NewString(), Body: "edited"},
+			wantErr: ErrNotFound,
+		},
+		"owner edits": {
+			caller:  owner.Id,
+			input:   &UpdateCommentInput{TaskId: task.Id, Id: comment.Id, Body: "edited"},
+			wantErr: ErrForbidden,
+		},
+		"succsessfull edit": {
+			caller:  author.Id,
+			input:   &UpdateCommentInput{TaskId: task.Id, Id: comment.Id, Body: "edited"},
+			wantErr: nil,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutAccount(author)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: task.StatusId, Name: "todo", ProjectId: project.Id})
+		m.PutTask(task)
+		m.PutComment(comment)
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), tt.caller)
+			err := s.UpdateComment(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("UpdateComment() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			revs, err := s.GetCommentRevisions(ctx, task.Id, comment.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(revs))
+			for _, rev := range revs {
+				got = append(got, rev.Body)
+			}
+			if diff := cmp.Diff([]string{comment.Body}, got); diff != "" {
+				t.Fatalf("GetCommentRevisions() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDeleteComment(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	member := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "member",
+		Email: "member@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	task := types.Task{
+		Id:        uuid.NewString(),
+		Name:      "task",
+		ProjectId: project.Id,
+		StatusId:  uuid.NewString(),
+	}
+	comment := types.Comment{
+		Id:       uuid.NewString(),
+		TaskId:   task.Id,
+		AuthorId: owner.Id,
+		Body:     "comment",
+	}
+	reply := types.Comment{
+		Id:       uuid.NewString(),
+		TaskId:   task.Id,
+		AuthorId: member.Id,
+		ParentId: comment.Id,
+		Body:     "reply",
+	}
+	tests := map[string]struct {
+		wantErr  error
+		caller   string
+		input    string
+		wantLeft int
+	}{
+		"member deletes others comment": {
+			caller:  member.Id,
+			input:   comment.Id,
+			wantErr: ErrForbidden,
+		},
+		"author deletes reply": {
+			caller:   member.Id,
+			input:    reply.Id,
+			wantErr:  nil,
+			wantLeft: 1,
+		},
+		"owner moderates reply": {
+			caller:   owner.Id,
+			input:    reply.Id,
+			wantErr:  nil,
+			wantLeft: 1,
+		},
+		"succsessfull delete with replies": {
+			caller:   owner.Id,
+			input:    comment.Id,
+			wantErr:  nil,
+			wantLeft: 0,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutAccount(member)
+		m.PutProject(project)
+		m.PutContributor(project.Id, member.Id, types.RoleMember)
+		m.PutStatus(types.Status{Id: task.StatusId, Name: "todo", ProjectId: project.Id})
+		m.PutTask(task)
+		m.PutComment(comment)
+		m.PutComment(reply)
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), tt.caller)
+			err := s.DeleteComment(ctx, task.Id, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("DeleteComment() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			page, err := s.GetCommentsByTaskId(ctx, task.Id, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var left int
+			for _, c := range page.Items {
+				left += 1 + len(c.Replies)
+			}
+			if diff := cmp.Diff(tt.wantLeft, left); diff != "" {
+				t.Fatalf("DeleteComment() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/uuid"
+)
+
+type AddContributorInput struct {
+	ProjectId string     `param:"id"`
+	AccountId string     `json:"account_id"`
+	Role      types.Role `json:"role,omitempty"`
+}
+
+type UpdateContributorInput struct {
+	ProjectId string     `param:"id"`
+	AccountId string     `param:"aid"`
+	Role      types.Role `json:"role"`
+}
+
+// Returned errors: ErrFailedValidation, ErrInternal
+func (s *Service) GetContributorsByProjectId(ctx context.Context, pId string) ([]types.Account, error) {
+	accs := make([]types.Account, 0)
+	if _, err := uuid.Parse(pId); err != nil {
+		return accs, invalid("project_id", "must be a valid UUID")
+	}
+
+	return s.projects().Contributors(ctx, pId)
+}
+
+// Returned errors: ErrFailedValidation, ErrInternal
+func (s *Service) GetContributedProjectsByAccountId(ctx context.Context, aId string) ([]types.Project, error) {
+	pjs := make([]types.Project, 0)
+	if _, err := uuid.Parse(aId); err != nil {
+		return pjs, invalid("account_id", "must be a valid UUID")
+	}
+
+	return s.projects().Contributed(ctx, aId)
+}
+
+// Contributors are added with RoleMember unless another role is given.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToInsert, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) AddContributor(ctx context.Context, input *AddContributorInput) error {
+	if _, err := uuid.Parse(input.ProjectId); err != nil {
+		return invalid("project_id", "must be a valid UUID")
+	}
+	if _, err := uuid.Parse(input.AccountId); err != nil {
+		return invalid("account_id", "must be a valid UUID")
+	}
+	if input.Role == "" {
+		input.Role = types.RoleMember
+	}
+	if !validContributorRole(input.Role) {
+		return invalid("role", "must be one of maintainer, member, viewer")
+	}
+
+	if err := s.authorize(ctx, input.ProjectId, PermManageContributors); err != nil {
+		return err
+	}
+	ownerId, err := s.projectOwnerId(ctx, input.ProjectId)
+	if err != nil {
+		return err
+	}
+	if input.AccountId == ownerId {
+		return invalid("account_id", "must not be the owner of the project")
+	}
+
+	return s.projects().AddContributor(ctx, input)
+}
+
+// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) UpdateContributor(ctx context.Context, input *UpdateContributorInput) error {
+	if _, err := uuid.Parse(input.ProjectId); err != nil {
+		return invalid("project_id", "must be a valid UUID")
+	}
+	if _, err := uuid.Parse(input.AccountId); err != nil {
+		return invalid("account_id", "must be a valid UUID")
+	}
+	if !validContributorRole(input.Role) {
+		return invalid("role", "must be one of maintainer, member, viewer")
+	}
+
+	if err := s.authorize(ctx, input.ProjectId, PermManageContributors); err != nil {
+		return err
+	}
+
+	return s.projects().UpdateContributor(ctx, input)
+}
+
+// Contributors with PermManageContributors can remove anyone, others can only remove themselves.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) DeleteContributor(ctx context.Context, pId, aId string) error {
+	if _, err := uuid.Parse(pId); err != nil {
+		return invalid("project_id", "must be a valid UUID")
+	}
+	if _, err := uuid.Parse(aId); err != nil {
+		return invalid("account_id", "must be a valid UUID")
+	}
+
+	if err := s.authorize(ctx, pId, PermManageContributors); err != nil {
+		if !errors.Is(err, ErrForbidden) {
+			return err
+		}
+		if err := checkSelf(ctx, aId); err != nil {
+			return err
+		}
+	}
+
+	return s.projects().DeleteContributor(ctx, pId, aId)
+}
+
+// Returned errors: ErrInternal, ErrNotFound
+func (s *Service) projectOwnerId(ctx context.Context, pId string) (string, error) {
+	pj, err := s.projects().GetById(ctx, pId)
+	if err != nil {
+		return "", err
+	}
+
+	return pj.OwnerId, nil
+}
+
+func (r *PostgresProjects) Contributors(ctx context.Context, pId string) ([]types.Account, error) {
+	accs := make([]types.Account, 0)
+	query := `SELECT a.id, a.email, a.name, a.avatar, pa.role, a.version, a.deleted, a.created_at, a.updated_at
+		FROM accounts a JOIN projects_to_accounts pa ON pa.account_id=a.id
+		WHERE pa.project_id=$1 AND a.deleted=false`
+	rows, err := conn(ctx, r.DB).QueryContext(ctx, query, pId)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	for rows.Next() {
+		var acc types.Account
+		err = rows.Scan(&acc.Id, &acc.Email, &acc.Name, &acc.Avatar, &acc.Role, &acc.Version, &acc.Deleted, &acc.CreatedAt, &acc.UpdatedAt)
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		accs = append(accs, acc)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return accs, nil
+}
+
+func (r *PostgresProjects) Contributed(ctx context.Context, aId string) ([]types.Project, error) {
+	pjs := make([]types.Project, 0)
+	query := `SELECT p.id, p.name, p.description, p.owner_id, p.strict_dependencies, p.strict_wip_limits, p.version, p.deleted, p.created_at, p.updated_at, p.deleted_at
+		FROM projects p JOIN projects_to_accounts pa ON pa.project_id=p.id
+		WHERE pa.account_id=$1 AND p.deleted=false`
+	rows, err := conn(ctx, r.DB).QueryContext(ctx, query, aId)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	for rows.Next() {
+		var pj types.Project
+		err = scanProject(rows, &pj)
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		pjs = append(pjs, pj)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return pjs, nil
+}
+
+func (r *PostgresProjects) AddContributor(ctx context.Context, input *AddContributorInput) error {
+	query := "INSERT INTO projects_to_accounts (project_id, account_id, role) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING"
+	res, err := conn(ctx, r.DB).ExecContext(ctx, query, input.ProjectId, input.AccountId, input.Role)
+	if err != nil {
+		return dbError(err)
+	}
+
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if ra < 1 {
+		return ErrFailedToInsert
+	}
+
+	return nil
+}
+
+func (r *PostgresProjects) UpdateContributor(ctx context.Context, input *UpdateContributorInput) error {
+	query := "UPDATE projects_to_accounts SET role=$1 WHERE project_id=$2 AND account_id=$3"
+	res, err := conn(ctx, r.DB).ExecContext(ctx, query, input.Role, input.ProjectId, input.AccountId)
+	if err != nil {
+		return dbError(err)
+	}
+
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if ra < 1 {
+		return ErrFailedToUpdate
+	}
+
+	return nil
+}
+
+func (r *PostgresProjects) DeleteContributor(ctx context.Context, pId, aId string) error {
+	return transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		query := "DELETE FROM projects_to_accounts WHERE project_id=$1 AND account_id=$2"
+		res, err := tx.ExecContext(ctx, query, pId, aId)
+		if err != nil {
+			return dbError(err)
+		}
+
+		ra, err := res.RowsAffected()
+		if err != nil {
+			return dbError(err)
+		}
+		if ra < 1 {
+			return ErrFailedToUpdate
+		}
+
+		// Former contributors can't stay assigned to tasks of the project.
+		query = "DELETE FROM tasks_to_accounts WHERE account_id=$1 AND task_id IN (SELECT id FROM tasks WHERE project_id=$2)"
+		if _, err = tx.ExecContext(ctx, query, aId, pId); err != nil {
+			return dbError(err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+)
+
+func TestGetContributorsByProjectId(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	tests := map[string]struct {
+		wantErr error
+		input   string
+		want    []types.Account
+	}{
+		"invalid project id": {
+			input:   "invalid-id",
+			wantErr: ErrFailedValidation,
+			want:    []types.Account{},
+		},
+		"2 contributors": {
+			input:   project.Id,
+			wantErr: nil,
+			want: []types.Account{
+				{
+					Id:    uuid.NewString(),
+					Name:  "username 1",
+					Email: "username1@test.com",
+					Role:  types.RoleMember,
+				},
+				{
+					Id:    uuid.NewString(),
+					Name:  "username 2",
+					Email: "username2@test.com",
+					Role:  types.RoleMember,
+				},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		for _, acc := range tt.want {
+			m.PutAccount(acc)
+			m.PutContributor(project.Id, acc.Id, types.RoleMember)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			got, err := s.GetContributorsByProjectId(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("GetContributorsByProjectId() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(types.Account{}, "CreatedAt", "UpdatedAt", "Version"), cmpopts.SortSlices(func(a, b types.Account) bool { return a.Id < b.Id })); diff != "" {
+				t.Fatalf("GetContributorsByProjectId() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetContributedProjectsByAccountId(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	contributor := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "contributor",
+		Email: "contributor@test.com",
+	}
+	tests := map[string]struct {
+		wantErr error
+		input   string
+		want    []types.Project
+	}{
+		"invalid account id": {
+			input:   "invalid-id",
+			wantErr: ErrFailedValidation,
+			want:    []types.Project{},
+		},
+		"1 project": {
+			input:   contributor.Id,
+			wantErr: nil,
+			want: []types.Project{
+				{
+					Id:                 uuid.NewString(),
+					Name:               "project",
+					OwnerId:            owner.Id,
+					StrictDependencies: true,
+					StrictWipLimits:    true,
+				},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutAccount(contributor)
+		for _, pj := range tt.want {
+			m.PutProject(pj)
+			m.PutContributor(pj.Id, contributor.Id, types.RoleMember)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			got, err := s.GetContributedProjectsByAccountId(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("GetContributedProjectsByAccountId() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(types.Project{}, "CreatedAt", "UpdatedAt", "Version")); diff != "" {
+				t.Fatalf("GetContributedProjectsByAccountId() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAddContributor(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	contributor := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "contributor",
+		Email: "contributor@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	tests := map[string]struct {
+		wantErr error
+		caller  string
+		input   *AddContributorInput
+	}{
+		"succsessfull add": {
+			caller:  owner.Id,
+			input:   &AddContributorInput{ProjectId: project.Id, AccountId: contributor.Id},
+			wantErr: nil,
+		},
+		"invalid account id": {
+			caller:  owner.Id,
+			input:   &AddContributorInput{ProjectId: project.Id, AccountId: "invalid-id"},
+			wantErr: ErrFailedValidation,
+		},
+		"owner as contributor": {
+			caller:  owner.Id,
+			input:   &AddContributorInput{ProjectId: project.Id, AccountId: owner.Id},
+			wantErr: ErrFailedValidation,
+		},
+		"non-existent project": {
+			caller:  owner.Id,
+			input:   &AddContributorInput{ProjectId: uuid.NewString(), AccountId: contributor.Id},
+			wantErr: ErrNotFound,
+		},
+		"invalid role": {
+			caller:  owner.Id,
+			input:   &AddContributorInput{ProjectId: project.Id, AccountId: contributor.Id, Role: types.RoleOwner},
+			wantErr: ErrFailedValidation,
+		},
+		"not an owner": {
+			caller:  contributor.Id,
+			input:   &AddContributorInput{ProjectId: project.Id, AccountId: contributor.Id},
+			wantErr: ErrForbidden,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutAccount(contributor)
+		m.PutProject(project)
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), tt.caller)
+			err := s.AddContributor(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("AddContributor() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDeleteContributor(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	contributor := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "contributor",
+		Email: "contributor@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	tests := map[string]struct {
+		wantErr   error
+		caller    string
+		accountId string
+	}{
+		"removed by owner": {
+			caller:    owner.Id,
+			accountId: contributor.Id,
+			wantErr:   nil,
+		},
+		"left by contributor": {
+			caller:    contributor.Id,
+			accountId: contributor.Id,
+			wantErr:   nil,
+		},
+		"removed by other": {
+			caller:    uuid.NewString(),
+			accountId: contributor.Id,
+			wantErr:   ErrForbidden,
+		},
+		"non-existent contributor": {
+			caller:    owner.Id,
+			accountId: uuid.NewString(),
+			wantErr:   ErrFailedToUpdate,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutAccount(contributor)
+		m.PutProject(project)
+		m.PutContributor(project.Id, contributor.Id, types.RoleMember)
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), tt.caller)
+			err := s.DeleteContributor(ctx, project.Id, tt.accountId)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("DeleteContributor() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/uuid"
+)
+
+// AddDependencyInput makes the task BlockerId block the task TaskId.
+type AddDependencyInput struct {
+	TaskId    string `param:"id"`
+	BlockerId string `json:"blocker_id"`
+}
+
+// Returned errors: ErrFailedValidation, ErrInternal
+func (s *Service) GetDependenciesByTaskId(ctx context.Context, tId string) (*types.Dependencies, error) {
+	if _, err := uuid.Parse(tId); err != nil {
+		return nil, invalid("task_id", "must be a valid UUID")
+	}
+
+	return s.dependencies().ListByTask(ctx, tId)
+}
+
+// Both tasks must be in the same project and the dependency must not create
+// a cycle. In projects with StrictDependencies the blocker must end before
+// the blocked task starts.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrConflict, ErrNotFound, ErrUnprocessable, ErrUnauthorized, ErrForbidden
+func (s *Service) AddDependency(ctx context.Context, input *AddDependencyInput) (*types.Dependency, error) {
+	if _, err := uuid.Parse(input.TaskId); err != nil {
+		return nil, invalid("task_id", "must be a valid UUID")
+	}
+	if _, err := uuid.Parse(input.BlockerId); err != nil {
+		return nil, invalid("blocker_id", "must be a valid UUID")
+	}
+	if input.BlockerId == input.TaskId {
+		return nil, invalid("blocker_id", "must not be the task itself")
+	}
+	pId, err := s.taskProjectId(ctx, input.TaskId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, pId, PermManageTasks); err != nil {
+		return nil, err
+	}
+
+	return s.dependencies().Add(ctx, pId, input)
+}
+
+// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) RemoveDependency(ctx context.Context, tId, blockerId string) error {
+	if _, err := uuid.Parse(tId); err != nil {
+		return invalid("task_id", "must be a valid UUID")
+	}
+	if _, err := uuid.Parse(blockerId); err != nil {
+		return invalid("blocker_id", "must be a valid UUID")
+	}
+	pId, err := s.taskProjectId(ctx, tId)
+	if err != nil {
+		return err
+	}
+	if err = s.authorize(ctx, pId, PermManageTasks); err != nil {
+		return err
+	}
+
+	return s.dependencies().Delete(ctx, tId, blockerId)
+}
+
+// PostgresDependencies keeps dependencies between tasks in Postgres.
+type PostgresDependencies struct {
+	DB *sql.DB
+}
+
+func (r *PostgresDependencies) ListByTask(ctx context.Context, tId string) (*types.Dependencies, error) {
+	var (
+		deps types.Dependencies
+		err  error
+	)
+	query := "SELECT " + taskColumns + ` FROM tasks
+		WHERE id IN (SELECT blocker_id FROM task_dependencies WHERE blocked_id=$1) AND deleted=false
+		ORDER BY "end", id`
+	deps.BlockedBy, err = queryTasks(ctx, conn(ctx, r.DB), query, tId)
+	if err != nil {
+		return nil, err
+	}
+
+	query = "SELECT " + taskColumns + ` FROM tasks
+		WHERE id IN (SELECT blocked_id FROM task_dependencies WHERE blocker_id=$1) AND deleted=false
+		ORDER BY "start", id`
+	deps.Blocks, err = queryTasks(ctx, conn(ctx, r.DB), query, tId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &deps, nil
+}
+
+func (r *PostgresDependencies) ListByProject(ctx context.Context, pId string) ([]types.Task, []types.Dependency, error) {
+	query := "SELECT " + taskColumns + " FROM tasks WHERE project_id=$1 AND deleted=false"
+	tks, err := queryTasks(ctx, conn(ctx, r.DB), query, pId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query = `SELECT d.blocker_id, d.blocked_id, d.created_at
+		FROM task_dependencies d
+		JOIN tasks b ON b.id=d.blocker_id
+		JOIN tasks t ON t.id=d.blocked_id
+		WHERE t.project_id=$1 AND t.deleted=false AND b.deleted=false
+		ORDER BY d.created_at`
+	rows, err := conn(ctx, r.DB).QueryContext(ctx, query, pId)
+	if err != nil {
+		return nil, nil, dbError(err)
+	}
+	defer rows.Close()
+
+	deps := make([]types.Dependency, 0)
+	for rows.Next() {
+		var d types.Dependency
+		if err = rows.Scan(&d.BlockerId, &d.BlockedId, &d.CreatedAt); err != nil {
+			return nil, nil, dbError(err)
+		}
+		deps = append(deps, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, dbError(err)
+	}
+
+	return tks, deps, nil
+}
+
+func (r *PostgresDependencies) Add(ctx context.Context, pId string, input *AddDependencyInput) (*types.Dependency, error) {
+	var d types.Dependency
+	err := transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		if err := lockProject(ctx, tx, pId); err != nil {
+			return err
+		}
+
+		var (
+			blockerProject string
+			blockerEnd     time.Time
+		)
+		query := "SELECT project_id, \"end\" FROM tasks WHERE id=$1 AND deleted=false"
+		err := tx.QueryRowContext(ctx, query, input.BlockerId).Scan(&blockerProject, &blockerEnd)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return dbError(err)
+		}
+		if blockerProject != pId {
+			return invalid("blocker_id", "must be a task of the same project")
+		}
+
+		// The new edge closes a cycle if the blocker is already blocked by the task.
+		var cycle bool
+		query = `WITH RECURSIVE blocked(id) AS (
+				SELECT $1::uuid
+				UNION
+				SELECT d.blocked_id FROM task_dependencies d JOIN blocked b ON d.blocker_id=b.id
+			) SELECT EXISTS (SELECT 1 FROM blocked WHERE id=$2)`
+		err = tx.QueryRowContext(ctx, query, input.TaskId, input.BlockerId).Scan(&cycle)
+		if err != nil {
+			return dbError(err)
+		}
+		if cycle {
+			return &Error{
+				Err:     ErrUnprocessable,
+				Message: "the dependency would create a cycle",
+				Fields:  []types.FieldError{{Field: "blocker_id", Message: "must not be blocked by the task directly or transitively"}},
+			}
+		}
+
+		var (
+			strict bool
+			start  time.Time
+		)
+		query = "SELECT p.strict_dependencies, t.\"start\" FROM tasks t JOIN projects p ON p.id=t.project_id WHERE t.id=$1"
+		err = tx.QueryRowContext(ctx, query, input.TaskId).Scan(&strict, &start)
+		if err != nil {
+			return dbError(err)
+		}
+		if strict && blockerEnd.After(start) {
+			return invalid("blocker_id", "must end before the task starts")
+		}
+
+		query = "INSERT INTO task_dependencies (blocker_id, blocked_id) VALUES ($1, $2) RETURNING blocker_id, blocked_id, created_at"
+		err = tx.QueryRowContext(ctx, query, input.BlockerId, input.TaskId).Scan(&d.BlockerId, &d.BlockedId, &d.CreatedAt)
+		if err != nil {
+			return dbError(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+func (r *PostgresDependencies) Delete(ctx context.Context, tId, blockerId string) error {
+	res, err := conn(ctx, r.DB).ExecContext(ctx, "DELETE FROM task_dependencies WHERE blocker_id=$1 AND blocked_id=$2", blockerId, tId)
+	if err != nil {
+		return dbError(err)
+	}
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if ra < 1 {
+		return ErrFailedToUpdate
+	}
+
+	return nil
+}
+
+// checkDependencyDates checks that the task tId of the project pId starting at
+// start and ending at end doesn't overlap with the tasks it depends on, unless
+// the project has no StrictDependencies.
+//
+// Returned errors: ErrFailedValidation, ErrConcurrentUpdate, ErrInternal
+func checkDependencyDates(ctx context.Context, tx *sql.Tx, pId, tId string, start, end time.Time) error {
+	var strict bool
+	err := tx.QueryRowContext(ctx, "SELECT strict_dependencies FROM projects WHERE id=$1", pId).Scan(&strict)
+	if err != nil {
+		return dbError(err)
+	}
+	if !strict {
+		return nil
+	}
+
+	var early, late bool
+	query := `SELECT
+		EXISTS (SELECT 1 FROM task_dependencies d JOIN tasks t ON t.id=d.blocker_id
+			WHERE d.blocked_id=$1 AND t.deleted=false AND t."end" > $2),
+		EXISTS (SELECT 1 FROM task_dependencies d JOIN tasks t ON t.id=d.blocked_id
+			WHERE d.blocker_id=$1 AND t.deleted=false AND t."start" < $3)`
+	err = tx.QueryRowContext(ctx, query, tId, start, end).Scan(&early, &late)
+	if err != nil {
+		return dbError(err)
+	}
+	if early {
+		return invalid("start", "must not be before the end of a task blocking it")
+	}
+	if late {
+		return invalid("end", "must not be after the start of a task it blocks")
+	}
+
+	return nil
+}
+
+// queryTasks returns the tasks the query selects with taskColumns.
+//
+// Returned errors: ErrInternal
+func queryTasks(ctx context.Context, db querier, query string, args ...any) ([]types.Task, error) {
+	tks := make([]types.Task, 0)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t types.Task
+		err = scanTask(rows, &t)
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		tks = append(tks, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return tks, nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+)
+
+func TestAddDependency(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	project := types.Project{
+		Id:                 uuid.NewString(),
+		Name:               "project",
+		OwnerId:            owner.Id,
+		StrictDependencies: true,
+	}
+	other := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "other",
+		OwnerId: owner.Id,
+	}
+	statusId := uuid.NewString()
+	otherStatusId := uuid.NewString()
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// design blocks build, build blocks release.
+	design := types.Task{Id: uuid.NewString(), Name: "design", ProjectId: project.Id, StatusId: statusId, Start: day, End: day.AddDate(0, 0, 1)}
+	build := types.Task{Id: uuid.NewString(), Name: "build", ProjectId: project.Id, StatusId: statusId, Start: day.AddDate(0, 0, 1), End: day.AddDate(0, 0, 3)}
+	release := types.Task{Id: uuid.NewString(), Name: "release", ProjectId: project.Id, StatusId: statusId, Start: day.AddDate(0, 0, 3), End: day.AddDate(0, 0, 4)}
+	docs := types.Task{Id: uuid.NewString(), Name: "docs", ProjectId: project.Id, StatusId: statusId, Start: day, End: day.AddDate(0, 0, 2)}
+	foreign := types.Task{Id: uuid.NewString(), Name: "foreign", ProjectId: other.Id, StatusId: otherStatusId, Start: day, End: day}
+	tests := map[string]struct {
+		wantErr      error
+		input        *AddDependencyInput
+		wantBlockers []string
+	}{
+		"self dependency": {
+			input:   &AddDependencyInput{TaskId: design.Id, BlockerId: design.Id},
+			wantErr: ErrFailedValidation,
+		},
+		"blocker of another project": {
+			input:   &AddDependencyInput{TaskId: design.Id, BlockerId: foreign.Id},
+			wantErr: ErrFailedValidation,
+		},
+		"transitive cycle": {
+			input:   &AddDependencyInput{TaskId: design.Id, BlockerId: release.Id},
+			wantErr: ErrUnprocessable,
+		},
+		"blocker ends after start": {
+			input:   &AddDependencyInput{TaskId: build.Id, BlockerId: docs.Id},
+			wantErr: ErrFailedValidation,
+		},
+		"duplicate": {
+			input:   &AddDependencyInput{TaskId: build.Id, BlockerId: design.Id},
+			wantErr: ErrConflict,
+		},
+		"succsessfull add": {
+			input:        &AddDependencyInput{TaskId: release.Id, BlockerId: docs.Id},
+			wantErr:      nil,
+			wantBlockers: []string{build.Id, docs.Id},
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutProject(other)
+		m.PutStatus(types.Status{Id: statusId, Name: "todo", ProjectId: project.Id})
+		m.PutStatus(types.Status{Id: otherStatusId, Name: "todo", ProjectId: other.Id})
+		for _, tk := range []types.Task{design, build, release, docs, foreign} {
+			m.PutTask(tk)
+		}
+		m.PutDependency(build.Id, design.Id)
+		m.PutDependency(release.Id, build.Id)
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), owner.Id)
+			_, err := s.AddDependency(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("AddDependency() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			deps, err := s.GetDependenciesByTaskId(ctx, tt.input.TaskId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(deps.BlockedBy))
+			for _, tk := range deps.BlockedBy {
+				got = append(got, tk.Id)
+			}
+			if diff := cmp.Diff(tt.wantBlockers, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+				t.Fatalf("GetDependenciesByTaskId() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUpdateTaskDependencyDates(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	statusId := uuid.NewString()
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	design := types.Task{Id: uuid.NewString(), Name: "design", ProjectId: project.Id, StatusId: statusId, Start: day, End: day.AddDate(0, 0, 1)}
+	build := types.Task{Id: uuid.NewString(), Name: "build", ProjectId: project.Id, StatusId: statusId, Start: day.AddDate(0, 0, 1), End: day.AddDate(0, 0, 3)}
+	tests := map[string]struct {
+		wantErr error
+		strict  bool
+		input   *UpdateTaskInput
+	}{
+		"starts before blocker ends": {
+			strict:  true,
+			input:   &UpdateTaskInput{Id: build.Id, StatusId: types.PatchOf(statusId), Start: types.PatchOf("2024-01-01 12:00:00"), End: types.PatchOf("2024-01-04 00:00:00")},
+			wantErr: ErrFailedValidation,
+		},
+		"blocker ends after blocked starts": {
+			strict:  true,
+			input:   &UpdateTaskInput{Id: design.Id, StatusId: types.PatchOf(statusId), Start: types.PatchOf("2024-01-01 00:00:00"), End: types.PatchOf("2024-01-03 00:00:00")},
+			wantErr: ErrFailedValidation,
+		},
+		"not strict project": {
+			strict:  false,
+			input:   &UpdateTaskInput{Id: build.Id, StatusId: types.PatchOf(statusId), Start: types.PatchOf("2024-01-01 12:00:00"), End: types.PatchOf("2024-01-04 00:00:00")},
+			wantErr: nil,
+		},
+		"succsessfull update": {
+			strict:  true,
+			input:   &UpdateTaskInput{Id: build.Id, StatusId: types.PatchOf(statusId), Start: types.PatchOf("2024-01-02 12:00:00"), End: types.PatchOf("2024-01-04 00:00:00")},
+			wantErr: nil,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(types.Project{Id: project.Id, Name: project.Name, OwnerId: project.OwnerId, StrictDependencies: tt.strict})
+		m.PutStatus(types.Status{Id: statusId, Name: "todo", ProjectId: project.Id})
+		for _, tk := range []types.Task{design, build} {
+			m.PutTask(tk)
+		}
+		m.PutDependency(build.Id, design.Id)
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), owner.Id)
+			err := s.UpdateTask(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("UpdateTask() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
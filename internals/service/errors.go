@@ -0,0 +1,87 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/lib/pq"
+)
+
+// Error is an error with details. It wraps one of the sentinel errors,
+// so it can be checked with errors.Is.
+type Error struct {
+	Err     error
+	Message string
+	Fields  []types.FieldError
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Err.Error())
+	if e.Message != "" {
+		b.WriteString(": ")
+		b.WriteString(e.Message)
+	}
+	for _, f := range e.Fields {
+		fmt.Fprintf(&b, "; %s %s", f.Field, f.Message)
+	}
+
+	return b.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// invalid returns ErrFailedValidation with details about the invalid field.
+func invalid(field, msg string) error {
+	return &Error{
+		Err:    ErrFailedValidation,
+		Fields: []types.FieldError{{Field: field, Message: msg}},
+	}
+}
+
+// Postgres error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pqForeignKeyViolation  = "23503"
+	pqUniqueViolation      = "23505"
+	pqCheckViolation       = "23514"
+	pqSerializationFailure = "40001"
+	pqDeadlockDetected     = "40P01"
+)
+
+// dbError maps errors returned by the database to the service errors.
+// Constraint violations become ErrConflict, ErrUnprocessable or ErrFailedValidation,
+// failures of concurrent transactions become ErrConcurrentUpdate,
+// everything else becomes ErrInternal.
+func dbError(err error) error {
+	if retryable(err) {
+		return concurrentUpdate()
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return ErrInternal
+	}
+
+	switch pqErr.Code {
+	case pqUniqueViolation:
+		return &Error{Err: ErrConflict, Message: constraintMessage(pqErr, "already exists")}
+	case pqForeignKeyViolation:
+		return &Error{Err: ErrUnprocessable, Message: constraintMessage(pqErr, "references a non-existent entity")}
+	case pqCheckViolation:
+		return &Error{Err: ErrFailedValidation, Message: constraintMessage(pqErr, "violates a check")}
+	}
+
+	return ErrInternal
+}
+
+func constraintMessage(pqErr *pq.Error, msg string) string {
+	if pqErr.Constraint == "" {
+		return msg
+	}
+
+	return fmt.Sprintf("%s (%s)", msg, pqErr.Constraint)
+}
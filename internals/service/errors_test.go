@@ -0,0 +1,65 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/lib/pq"
+)
+
+func TestDbError(t *testing.T) {
+	tests := map[string]struct {
+		input   error
+		wantErr error
+	}{
+		"unique violation": {
+			input:   &pq.Error{Code: pqUniqueViolation, Constraint: "accounts_email_key"},
+			wantErr: ErrConflict,
+		},
+		"foreign key violation": {
+			input:   &pq.Error{Code: pqForeignKeyViolation, Constraint: "fk_tasks_statuses"},
+			wantErr: ErrUnprocessable,
+		},
+		"check violation": {
+			input:   &pq.Error{Code: pqCheckViolation},
+			wantErr: ErrFailedValidation,
+		},
+		"serialization failure": {
+			input:   &pq.Error{Code: pqSerializationFailure},
+			wantErr: ErrConcurrentUpdate,
+		},
+		"deadlock": {
+			input:   &pq.Error{Code: pqDeadlockDetected},
+			wantErr: ErrConcurrentUpdate,
+		},
+		"other postgres error": {
+			input:   &pq.Error{Code: "42P01"},
+			wantErr: ErrInternal,
+		},
+		"non-postgres error": {
+			input:   errors.New("connection reset"),
+			wantErr: ErrInternal,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := dbError(tt.input)
+			if diff := cmp.Diff(tt.wantErr, got, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("dbError() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	err := invalid("name", "must not be empty")
+	if !errors.Is(err, ErrFailedValidation) {
+		t.Fatalf("invalid() = %v, want it to wrap %v", err, ErrFailedValidation)
+	}
+	if diff := cmp.Diff("failed validation; name must not be empty", err.Error()); diff != "" {
+		t.Fatalf("invalid() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/uuid"
+)
+
+const InvitationTTL = 7 * 24 * time.Hour
+
+type AddInvitationInput struct {
+	ProjectId string     `param:"id"`
+	Email     string     `json:"email"`
+	Role      types.Role `json:"role,omitempty"`
+}
+
+type RespondInvitationInput struct {
+	Token string `json:"token"`
+}
+
+const invitationColumns = "id, project_id, inviter_id, COALESCE(invitee_id::text, ''), email, role, status, token, expires_at, deleted, created_at, updated_at"
+
+// The invitee is resolved by email. If there is no account with the email yet,
+// the invitation is held until AddAccount creates one. The owner of the project
+// can't be invited. The token isn't returned, the invitee gets it
+// from GetPendingInvitations.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) AddInvitation(ctx context.Context, input *AddInvitationInput) (*types.Invitation, error) {
+	if _, err := uuid.Parse(input.ProjectId); err != nil {
+		return nil, invalid("project_id", "must be a valid UUID")
+	}
+	email := normalizeEmail(input.Email)
+	if email == "" {
+		return nil, invalid("email", "must not be empty")
+	}
+	if input.Role == "" {
+		input.Role = types.RoleMember
+	}
+	if !validContributorRole(input.Role) {
+		return nil, invalid("role", "must be one of maintainer, member, viewer")
+	}
+
+	if err := s.authorize(ctx, input.ProjectId, PermManageContributors); err != nil {
+		return nil, err
+	}
+	inviterId, err := callerId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pj, err := s.projects().GetById(ctx, input.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := s.accounts().GetById(ctx, pj.OwnerId)
+	if err != nil {
+		return nil, err
+	}
+	if normalizeEmail(owner.Email) == email {
+		return nil, invalid("email", "must not be the email of the project's owner")
+	}
+	token, err := newInvitationToken()
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	inv, err := s.invitations().Add(ctx, &types.Invitation{
+		ProjectId: input.ProjectId,
+		InviterId: inviterId,
+		Email:     email,
+		Role:      input.Role,
+		Token:     token,
+		ExpiresAt: time.Now().Add(InvitationTTL).UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	inv.Token = ""
+
+	return inv, nil
+}
+
+// Tokens aren't returned, they are only visible to the invitee.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) GetInvitationsByProjectId(ctx context.Context, pId string) ([]types.Invitation, error) {
+	invs := make([]types.Invitation, 0)
+	if _, err := uuid.Parse(pId); err != nil {
+		return invs, invalid("project_id", "must be a valid UUID")
+	}
+	if err := s.authorize(ctx, pId, PermManageContributors); err != nil {
+		return nil, err
+	}
+
+	invs, err := s.invitations().ListByProject(ctx, pId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range invs {
+		invs[i].Token = ""
+	}
+
+	return invs, nil
+}
+
+// Returns pending and not expired invitations of the caller.
+//
+// Returned errors: ErrInternal, ErrUnauthorized
+func (s *Service) GetPendingInvitations(ctx context.Context) ([]types.Invitation, error) {
+	caller, err := callerId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.invitations().Pending(ctx, caller)
+}
+
+// Accepting an invitation adds the caller to the project's contributors with the invitation's role.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrNotFound, ErrUnauthorized
+func (s *Service) AcceptInvitation(ctx context.Context, input *RespondInvitationInput) error {
+	if input.Token == "" {
+		return invalid("token", "must not be empty")
+	}
+	caller, err := callerId(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.invitations().Accept(ctx, input.Token, caller)
+}
+
+// Returned errors: ErrFailedValidation, ErrInternal, ErrNotFound, ErrUnauthorized
+func (s *Service) DeclineInvitation(ctx context.Context, input *RespondInvitationInput) error {
+	if input.Token == "" {
+		return invalid("token", "must not be empty")
+	}
+	caller, err := callerId(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.invitations().Decline(ctx, input.Token, caller)
+}
+
+// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) DeleteInvitation(ctx context.Context, pId, id string) error {
+	if _, err := uuid.Parse(pId); err != nil {
+		return invalid("project_id", "must be a valid UUID")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return invalid("id", "must be a valid UUID")
+	}
+	if err := s.authorize(ctx, pId, PermManageContributors); err != nil {
+		return err
+	}
+
+	return s.invitations().Delete(ctx, pId, id)
+}
+
+// claimInvitations binds invitations held for the email to the account that was created with it.
+func claimInvitations(ctx context.Context, db querier, email string) error {
+	query := `UPDATE invitations SET invitee_id=(` + inviteeOf("$1") + `)
+		WHERE lower(email)=$1 AND invitee_id IS NULL AND status='pending' AND deleted=false`
+	_, err := db.ExecContext(ctx, query, normalizeEmail(email))
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanInvitation(row scanner, inv *types.Invitation) error {
+	return row.Scan(&inv.Id, &inv.ProjectId, &inv.InviterId, &inv.InviteeId, &inv.Email, &inv.Role, &inv.Status, &inv.Token, &inv.ExpiresAt, &inv.Deleted, &inv.CreatedAt, &inv.UpdatedAt)
+}
+
+func newInvitationToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// inviteeOf returns a query that selects the id of the account an invitation
+// to the normalized email param is for. Emails of accounts are only unique
+// as given, so of accounts whose emails differ in case the oldest one is taken.
+func inviteeOf(param string) string {
+	return "SELECT id FROM accounts WHERE lower(email)=" + param + " AND deleted=false ORDER BY created_at, id LIMIT 1"
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// PostgresInvitations keeps invitations in Postgres.
+type PostgresInvitations struct {
+	DB *sql.DB
+}
+
+func (r *PostgresInvitations) ListByProject(ctx context.Context, pId string) ([]types.Invitation, error) {
+	query := "SELECT " + invitationColumns + " FROM invitations WHERE project_id=$1 AND deleted=false"
+	return queryInvitations(ctx, conn(ctx, r.DB), query, pId)
+}
+
+func (r *PostgresInvitations) Pending(ctx context.Context, inviteeId string) ([]types.Invitation, error) {
+	query := "SELECT " + invitationColumns + " FROM invitations WHERE invitee_id=$1 AND status='pending' AND expires_at > now() AND deleted=false"
+	return queryInvitations(ctx, conn(ctx, r.DB), query, inviteeId)
+}
+
+func (r *PostgresInvitations) Add(ctx context.Context, inv *types.Invitation) (*types.Invitation, error) {
+	var res types.Invitation
+	query := `INSERT INTO invitations (project_id, inviter_id, invitee_id, email, role, token, expires_at)
+		VALUES ($1, $2, (` + inviteeOf("$3") + `), $3, $4, $5, $6)
+		RETURNING ` + invitationColumns
+	row := conn(ctx, r.DB).QueryRowContext(ctx, query, inv.ProjectId, inv.InviterId, inv.Email, inv.Role, inv.Token, inv.ExpiresAt)
+	err := scanInvitation(row, &res)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	return &res, nil
+}
+
+func (r *PostgresInvitations) Accept(ctx context.Context, token, inviteeId string) error {
+	return transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		var pId string
+		var role types.Role
+		query := `UPDATE invitations SET status='accepted', updated_at=now()
+			WHERE token=$1 AND invitee_id=$2 AND status='pending' AND expires_at > now() AND deleted=false
+			RETURNING project_id, role`
+		err := tx.QueryRowContext(ctx, query, token, inviteeId).Scan(&pId, &role)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotFound
+			}
+			return dbError(err)
+		}
+
+		query = `INSERT INTO projects_to_accounts (project_id, account_id, role) VALUES ($1, $2, $3)
+			ON CONFLICT (project_id, account_id) DO UPDATE SET role=EXCLUDED.role`
+		_, err = tx.ExecContext(ctx, query, pId, inviteeId, role)
+		if err != nil {
+			return dbError(err)
+		}
+
+		return nil
+	})
+}
+
+func (r *PostgresInvitations) Decline(ctx context.Context, token, inviteeId string) error {
+	query := `UPDATE invitations SET status='declined', updated_at=now()
+		WHERE token=$1 AND invitee_id=$2 AND status='pending' AND deleted=false`
+	res, err := conn(ctx, r.DB).ExecContext(ctx, query, token, inviteeId)
+	if err != nil {
+		return dbError(err)
+	}
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if ra < 1 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *PostgresInvitations) Delete(ctx context.Context, pId, id string) error {
+	query := "UPDATE invitations SET deleted=true WHERE id=$1 AND project_id=$2"
+	res, err := conn(ctx, r.DB).ExecContext(ctx, query, id, pId)
+	if err != nil {
+		return dbError(err)
+	}
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if ra < 1 {
+		return ErrFailedToUpdate
+	}
+
+	return nil
+}
+
+func queryInvitations(ctx context.Context, db querier, query string, args ...any) ([]types.Invitation, error) {
+	invs := make([]types.Invitation, 0)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	for rows.Next() {
+		var inv types.Invitation
+		err = scanInvitation(rows, &inv)
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		invs = append(invs, inv)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return invs, nil
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+)
+
+func TestAddInvitation(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	invitee := types.Account{
+		Id:        uuid.NewString(),
+		Name:      "invitee",
+		Email:     "invitee@test.com",
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	// twin is a newer account whose email only differs from the invitee's in case.
+	twin := types.Account{
+		Id:        uuid.NewString(),
+		Name:      "twin",
+		Email:     "INVITEE@test.com",
+		CreatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	tests := map[string]struct {
+		wantErr       error
+		caller        string
+		input         *AddInvitationInput
+		wantInviteeId string
+		twin          bool
+	}{
+		"existing account": {
+			caller:        owner.Id,
+			input:         &AddInvitationInput{ProjectId: project.Id, Email: "Invitee@Test.com"},
+			wantInviteeId: invitee.Id,
+			wantErr:       nil,
+		},
+		"accounts with emails differing in case": {
+			caller:        owner.Id,
+			input:         &AddInvitationInput{ProjectId: project.Id, Email: "invitee@TEST.com"},
+			wantInviteeId: invitee.Id,
+			wantErr:       nil,
+			twin:          true,
+		},
+		"held for unknown email": {
+			caller:        owner.Id,
+			input:         &AddInvitationInput{ProjectId: project.Id, Email: "newcomer@test.com"},
+			wantInviteeId: "",
+			wantErr:       nil,
+		},
+		"empty email": {
+			caller:  owner.Id,
+			input:   &AddInvitationInput{ProjectId: project.Id},
+			wantErr: ErrFailedValidation,
+		},
+		"invalid role": {
+			caller:  owner.Id,
+			input:   &AddInvitationInput{ProjectId: project.Id, Email: invitee.Email, Role: types.RoleOwner},
+			wantErr: ErrFailedValidation,
+		},
+		"owner of the project": {
+			caller:  owner.Id,
+			input:   &AddInvitationInput{ProjectId: project.Id, Email: "Owner@Test.com"},
+			wantErr: ErrFailedValidation,
+		},
+		"not a manager": {
+			caller:  invitee.Id,
+			input:   &AddInvitationInput{ProjectId: project.Id, Email: invitee.Email},
+			wantErr: ErrForbidden,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutAccount(invitee)
+		if tt.twin {
+			m.PutAccount(twin)
+		}
+		m.PutProject(project)
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), tt.caller)
+			got, err := s.AddInvitation(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("AddInvitation() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.wantInviteeId, got.InviteeId); diff != "" {
+				t.Fatalf("AddInvitation() mismatch (-want +got):\n%s", diff)
+			}
+			if got.Token != "" || got.Status != types.InvitationPending {
+				t.Fatalf("AddInvitation() returned unexpected invitation: %+v", got)
+			}
+		})
+	}
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	tests := map[string]struct {
+		wantErr   error
+		expiresAt time.Time
+		stranger  bool
+	}{
+		"succsessfull accept": {
+			expiresAt: time.Now().Add(time.Hour).UTC(),
+			wantErr:   nil,
+		},
+		"expired": {
+			expiresAt: time.Now().Add(-time.Hour).UTC(),
+			wantErr:   ErrNotFound,
+		},
+		"another account": {
+			expiresAt: time.Now().Add(time.Hour).UTC(),
+			stranger:  true,
+			wantErr:   ErrNotFound,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		token := uuid.NewString()
+		m.PutInvitation(types.Invitation{ProjectId: project.Id, InviterId: owner.Id, Email: "newcomer@test.com", Role: types.RoleViewer, Token: token, ExpiresAt: tt.expiresAt})
+
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, err := s.AddAccount(ctx, &AddAccountInput{Name: "newcomer", Email: "newcomer@test.com", Password: "password"})
+			if err != nil {
+				t.Fatal(ErrFailedToPrepareTest, err)
+			}
+			tokens, err := s.Login(ctx, &LoginInput{Email: "newcomer@test.com", Password: "password"})
+			if err != nil {
+				t.Fatal(ErrFailedToPrepareTest, err)
+			}
+			caller, err := s.ParseAccessToken(tokens.AccessToken)
+			if err != nil {
+				t.Fatal(ErrFailedToPrepareTest, err)
+			}
+			if tt.stranger {
+				caller = owner.Id
+			}
+
+			err = s.AcceptInvitation(WithAccountId(ctx, caller), &RespondInvitationInput{Token: token})
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("AcceptInvitation() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			role, err := s.roleInProject(ctx, project.Id, caller)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(types.RoleViewer, role); diff != "" {
+				t.Fatalf("AcceptInvitation() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDeclineInvitation(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	invitee := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "invitee",
+		Email: "invitee@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	token := uuid.NewString()
+	tests := map[string]struct {
+		wantErr error
+		caller  string
+		input   *RespondInvitationInput
+	}{
+		"succsessfull decline": {
+			caller:  invitee.Id,
+			input:   &RespondInvitationInput{Token: token},
+			wantErr: nil,
+		},
+		"unknown token": {
+			caller:  invitee.Id,
+			input:   &RespondInvitationInput{Token: uuid.NewString()},
+			wantErr: ErrNotFound,
+		},
+		"empty token": {
+			caller:  invitee.Id,
+			input:   &RespondInvitationInput{},
+			wantErr: ErrFailedValidation,
+		},
+		"anonymous": {
+			caller:  "",
+			input:   &RespondInvitationInput{Token: token},
+			wantErr: ErrUnauthorized,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutAccount(invitee)
+		m.PutProject(project)
+		m.PutInvitation(types.Invitation{ProjectId: project.Id, InviterId: owner.Id, InviteeId: invitee.Id, Email: invitee.Email, Token: token, ExpiresAt: time.Now().Add(time.Hour).UTC()})
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), tt.caller)
+			err := s.DeclineInvitation(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("DeclineInvitation() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
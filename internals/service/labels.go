@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type AddLabelInput struct {
+	ProjectId string `param:"id"`
+	Name      string `json:"name"`
+	Color     string `json:"color"`
+}
+
+type UpdateLabelInput struct {
+	ProjectId string `param:"id"`
+	Id        string `param:"lid"`
+	Name      string `json:"name,omitempty"`
+	Color     string `json:"color,omitempty"`
+}
+
+var labelColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+const labelColumns = "id, name, color, project_id, deleted, created_at, updated_at"
+
+func scanLabel(row scanner, l *types.Label) error {
+	return row.Scan(&l.Id, &l.Name, &l.Color, &l.ProjectId, &l.Deleted, &l.CreatedAt, &l.UpdatedAt)
+}
+
+// Returned errors: ErrFailedValidation, ErrInternal
+func (s *Service) GetLabelsByProjectId(ctx context.Context, pId string) ([]types.Label, error) {
+	if _, err := uuid.Parse(pId); err != nil {
+		return make([]types.Label, 0), invalid("project_id", "must be a valid UUID")
+	}
+
+	return s.labels().ListByProject(ctx, pId)
+}
+
+// Returned errors: ErrFailedValidation, ErrInternal
+func (s *Service) GetLabelsByTaskId(ctx context.Context, tId string) ([]types.Label, error) {
+	if _, err := uuid.Parse(tId); err != nil {
+		return make([]types.Label, 0), invalid("task_id", "must be a valid UUID")
+	}
+
+	return s.labels().ListByTask(ctx, tId)
+}
+
+// Returned errors: ErrFailedValidation, ErrInternal, ErrConflict, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) AddLabel(ctx context.Context, input *AddLabelInput) (*types.Label, error) {
+	if _, err := uuid.Parse(input.ProjectId); err != nil {
+		return nil, invalid("project_id", "must be a valid UUID")
+	}
+	if input.Name == "" {
+		return nil, invalid("name", "must not be empty")
+	}
+	if !labelColor.MatchString(input.Color) {
+		return nil, invalid("color", "must be in format \"#rrggbb\"")
+	}
+	if err := s.authorize(ctx, input.ProjectId, PermManageLabels); err != nil {
+		return nil, err
+	}
+
+	return s.labels().Add(ctx, input)
+}
+
+// Returned errors: ErrFailedValidation, ErrInternal, ErrConflict, ErrFailedToUpdate, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) UpdateLabel(ctx context.Context, input *UpdateLabelInput) error {
+	if _, err := uuid.Parse(input.ProjectId); err != nil {
+		return invalid("project_id", "must be a valid UUID")
+	}
+	if _, err := uuid.Parse(input.Id); err != nil {
+		return invalid("id", "must be a valid UUID")
+	}
+	if input.Color != "" && !labelColor.MatchString(input.Color) {
+		return invalid("color", "must be in format \"#rrggbb\"")
+	}
+	if err := s.authorize(ctx, input.ProjectId, PermManageLabels); err != nil {
+		return err
+	}
+
+	return s.labels().Update(ctx, input)
+}
+
+// Deleted labels are detached from all tasks.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) DeleteLabel(ctx context.Context, pId, id string) error {
+	if _, err := uuid.Parse(pId); err != nil {
+		return invalid("project_id", "must be a valid UUID")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return invalid("id", "must be a valid UUID")
+	}
+	if err := s.authorize(ctx, pId, PermManageLabels); err != nil {
+		return err
+	}
+
+	return s.labels().Delete(ctx, pId, id)
+}
+
+// PostgresLabels keeps labels in Postgres.
+type PostgresLabels struct {
+	DB *sql.DB
+}
+
+func (r *PostgresLabels) ListByProject(ctx context.Context, pId string) ([]types.Label, error) {
+	query := "SELECT " + labelColumns + " FROM labels WHERE project_id=$1 AND deleted=false ORDER BY name"
+	return queryLabels(ctx, conn(ctx, r.DB), query, pId)
+}
+
+func (r *PostgresLabels) ListByTask(ctx context.Context, tId string) ([]types.Label, error) {
+	return taskLabels(ctx, conn(ctx, r.DB), tId)
+}
+
+func (r *PostgresLabels) Add(ctx context.Context, input *AddLabelInput) (*types.Label, error) {
+	var l types.Label
+	query := "INSERT INTO labels (name, color, project_id) VALUES ($1, $2, $3) RETURNING " + labelColumns
+	row := conn(ctx, r.DB).QueryRowContext(ctx, query, input.Name, input.Color, input.ProjectId)
+	err := scanLabel(row, &l)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	return &l, nil
+}
+
+func (r *PostgresLabels) Update(ctx context.Context, input *UpdateLabelInput) error {
+	query := `UPDATE labels SET name=COALESCE(NULLIF($1, ''), name), color=COALESCE(NULLIF($2, ''), color), updated_at=now()
+		WHERE id=$3 AND project_id=$4 AND deleted=false`
+	res, err := conn(ctx, r.DB).ExecContext(ctx, query, input.Name, input.Color, input.Id, input.ProjectId)
+	if err != nil {
+		return dbError(err)
+	}
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+
+	if ra < 1 {
+		return ErrFailedToUpdate
+	}
+
+	return nil
+}
+
+func (r *PostgresLabels) Delete(ctx context.Context, pId, id string) error {
+	return transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "UPDATE labels SET deleted=true, updated_at=now() WHERE id=$1 AND project_id=$2 AND deleted=false", id, pId)
+		if err != nil {
+			return dbError(err)
+		}
+		ra, err := res.RowsAffected()
+		if err != nil {
+			return dbError(err)
+		}
+		if ra < 1 {
+			return ErrFailedToUpdate
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM tasks_to_labels WHERE label_id=$1", id)
+		if err != nil {
+			return dbError(err)
+		}
+
+		return nil
+	})
+}
+
+// taskLabels returns labels of the task tId ordered by name.
+//
+// Returned errors: ErrInternal
+func taskLabels(ctx context.Context, db querier, tId string) ([]types.Label, error) {
+	query := `SELECT l.id, l.name, l.color, l.project_id, l.deleted, l.created_at, l.updated_at
+		FROM labels l JOIN tasks_to_labels tl ON tl.label_id=l.id
+		WHERE tl.task_id=$1 AND l.deleted=false
+		ORDER BY l.name`
+	return queryLabels(ctx, db, query, tId)
+}
+
+func queryLabels(ctx context.Context, db querier, query string, args ...any) ([]types.Label, error) {
+	ls := make([]types.Label, 0)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var l types.Label
+		err = scanLabel(rows, &l)
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		ls = append(ls, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return ls, nil
+}
+
+// Returned errors: ErrFailedValidation
+func validLabelIds(ids []string) error {
+	for _, id := range ids {
+		if _, err := uuid.Parse(id); err != nil {
+			return invalid("label_ids", "must be valid UUIDs")
+		}
+	}
+
+	return nil
+}
+
+// setTaskLabels replaces labels of the task tId with ids, all of them
+// must be labels of the project pId.
+//
+// Returned errors: ErrFailedValidation, ErrConcurrentUpdate, ErrInternal
+func setTaskLabels(ctx context.Context, tx *sql.Tx, tId, pId string, ids []string) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM tasks_to_labels WHERE task_id=$1", tId)
+	if err != nil {
+		return dbError(err)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	unique := make(map[string]bool)
+	for _, id := range ids {
+		unique[id] = true
+	}
+
+	query := `INSERT INTO tasks_to_labels (task_id, label_id)
+		SELECT $1, id FROM labels WHERE id = ANY($2) AND project_id=$3 AND deleted=false`
+	res, err := tx.ExecContext(ctx, query, tId, pq.Array(ids), pId)
+	if err != nil {
+		return dbError(err)
+	}
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if int(ra) != len(unique) {
+		return invalid("label_ids", "must be labels of the task's project")
+	}
+
+	return nil
+}
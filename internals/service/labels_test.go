@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+)
+
+func TestAddLabel(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	member := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "member",
+		Email: "member@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	tests := map[string]struct {
+		wantErr error
+		caller  string
+		input   *AddLabelInput
+	}{
+		"invalid project id": {
+			caller:  owner.Id,
+			input:   &AddLabelInput{ProjectId: "invalid-id", Name: "bug", Color: "#ff0000"},
+			wantErr: ErrFailedValidation,
+		},
+		"invalid color": {
+			caller:  owner.Id,
+			input:   &AddLabelInput{ProjectId: project.Id, Name: "bug", Color: "red"},
+			wantErr: ErrFailedValidation,
+		},
+		"member adds": {
+			caller:  member.Id,
+			input:   &AddLabelInput{ProjectId: project.Id, Name: "bug", Color: "#ff0000"},
+			wantErr: ErrForbidden,
+		},
+		"duplicate name": {
+			caller:  owner.Id,
+			input:   &AddLabelInput{ProjectId: project.Id, Name: "feature", Color: "#00ff00"},
+			wantErr: ErrConflict,
+		},
+		"succsessfull add": {
+			caller:  owner.Id,
+			input:   &AddLabelInput{ProjectId: project.Id, Name: "bug", Color: "#ff0000"},
+			wantErr: nil,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutAccount(member)
+		m.PutProject(project)
+		m.PutContributor(project.Id, member.Id, types.RoleMember)
+		m.PutLabel(types.Label{Name: "feature", Color: "#00ff00", ProjectId: project.Id})
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), tt.caller)
+			got, err := s.AddLabel(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("AddLabel() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			want := &types.Label{Id: got.Id, Name: tt.input.Name, Color: tt.input.Color, ProjectId: project.Id}
+			if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(types.Label{}, "CreatedAt", "UpdatedAt")); diff != "" {
+				t.Fatalf("AddLabel() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDeleteLabel(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	task := types.Task{
+		Id:        uuid.NewString(),
+		Name:      "task",
+		ProjectId: project.Id,
+		StatusId:  uuid.NewString(),
+	}
+	label := types.Label{
+		Id:        uuid.NewString(),
+		Name:      "bug",
+		Color:     "#ff0000",
+		ProjectId: project.Id,
+	}
+	tests := map[string]struct {
+		wantErr error
+		input   string
+	}{
+		"invalid id": {
+			input:   "invalid-id",
+			wantErr: ErrFailedValidation,
+		},
+		"non-existent label": {
+			input:   uuid.NewString(),
+			wantErr: ErrFailedToUpdate,
+		},
+		"succsessfull delete": {
+			input:   label.Id,
+			wantErr: nil,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: task.StatusId, Name: "todo", ProjectId: project.Id})
+		m.PutTask(task)
+		m.PutLabel(label)
+		m.PutTaskLabel(task.Id, label.Id)
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), owner.Id)
+			err := s.DeleteLabel(ctx, project.Id, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("DeleteLabel() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			got, err := s.GetLabelsByTaskId(ctx, task.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(0, len(got)); diff != "" {
+				t.Fatalf("GetLabelsByTaskId() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAddTaskWithLabels(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	statusId := uuid.NewString()
+	label := types.Label{
+		Id:        uuid.NewString(),
+		Name:      "bug",
+		Color:     "#ff0000",
+		ProjectId: project.Id,
+	}
+	tests := map[string]struct {
+		wantErr error
+		input   []string
+	}{
+		"label of another project": {
+			input:   []string{uuid.NewString()},
+			wantErr: ErrFailedValidation,
+		},
+		"succsessfull add": {
+			input:   []string{label.Id},
+			wantErr: nil,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: statusId, Name: "todo", ProjectId: project.Id})
+		m.PutLabel(label)
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), owner.Id)
+			input := &AddTaskInput{
+				Name:      "task",
+				ProjectId: project.Id,
+				StatusId:  statusId,
+				LabelIds:  tt.input,
+				Start:     "2024-01-01 00:00:00",
+				End:       "2024-01-02 00:00:00",
+			}
+			got, err := s.AddTask(ctx, input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("AddTask() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff([]types.Label{label}, got.Labels, cmpopts.IgnoreFields(types.Label{}, "CreatedAt", "UpdatedAt")); diff != "" {
+				t.Fatalf("AddTask() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
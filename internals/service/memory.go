@@ -0,0 +1,2790 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/uuid"
+)
+
+// Memory keeps accounts, projects, statuses, tasks, labels, assignees,
+// dependencies, comments, attachments and invitations in memory, e.g. for tests that don't need
+// a database. Its repositories share the data the way tables do in Postgres:
+// deleting an account deletes its projects with their statuses and tasks,
+// restoring it restores them, and adding one claims invitations held for its email.
+type Memory struct {
+	mu          sync.RWMutex
+	accounts    map[string]*memoryAccount
+	projects    map[string]*memoryProject
+	statuses    map[string]*types.Status
+	tasks       map[string]*types.Task
+	invitations map[string]*types.Invitation
+	labels      map[string]*types.Label
+	// taskLabels holds ids of the labels of every task.
+	taskLabels  map[string]map[string]bool
+	assignments []memoryAssignment
+	// dependencies are kept in order of creation.
+	dependencies []types.Dependency
+	comments     map[string]*types.Comment
+	// revisions are kept in order of creation.
+	revisions   []types.CommentRevision
+	attachments map[string]*types.Attachment
+}
+
+// memoryAccount is an account with its password hash
+// and the time it was deleted at.
+type memoryAccount struct {
+	types.Account
+	hash      string
+	deletedAt *time.Time
+}
+
+// memoryAssignment is an account assigned to a task,
+// assignments are kept in order of assignment.
+type memoryAssignment struct {
+	taskId    string
+	accountId string
+}
+
+// memoryProject is a project with the roles of its contributors
+// and the transitions of its workflow.
+type memoryProject struct {
+	types.Project
+	roles       map[string]types.Role
+	transitions []types.Transition
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		accounts:    make(map[string]*memoryAccount),
+		projects:    make(map[string]*memoryProject),
+		statuses:    make(map[string]*types.Status),
+		tasks:       make(map[string]*types.Task),
+		invitations: make(map[string]*types.Invitation),
+		labels:      make(map[string]*types.Label),
+		taskLabels:  make(map[string]map[string]bool),
+		comments:    make(map[string]*types.Comment),
+		attachments: make(map[string]*types.Attachment),
+	}
+}
+
+// Service returns a service without a database that keeps its data in m.
+func (m *Memory) Service() *Service {
+	return &Service{
+		Accounts:     &MemoryAccounts{m},
+		Projects:     &MemoryProjects{m},
+		Statuses:     &MemoryStatuses{m},
+		Tasks:        &MemoryTasks{m},
+		Invitations:  &MemoryInvitations{m},
+		Labels:       &MemoryLabels{m},
+		Assignees:    &MemoryAssignees{m},
+		Dependencies: &MemoryDependencies{m},
+		Comments:     &MemoryComments{m},
+		Attachments:  &MemoryAttachments{m},
+		Trash:        &MemoryTrash{m},
+	}
+}
+
+// PutAccount keeps the account acc in m as is, e.g. a fixture of a test.
+// Like the other Put methods, it only fills in what Postgres gives to
+// columns left empty: the first version, the time of creation and so on.
+func (m *Memory) PutAccount(acc types.Account) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	putDefaults(&acc.Id, &acc.Version, &acc.CreatedAt, &acc.UpdatedAt)
+	stored := &memoryAccount{Account: acc}
+	if acc.Deleted {
+		stored.deletedAt = &stored.UpdatedAt
+	}
+	m.accounts[acc.Id] = stored
+}
+
+// PutPassword sets the password hash of the account aId.
+func (m *Memory) PutPassword(aId, hash string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.accounts[aId].hash = hash
+}
+
+func (m *Memory) PutProject(pj types.Project) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	putDefaults(&pj.Id, &pj.Version, &pj.CreatedAt, &pj.UpdatedAt)
+	if pj.Deleted && pj.DeletedAt == nil {
+		pj.DeletedAt = &pj.UpdatedAt
+	}
+	m.projects[pj.Id] = &memoryProject{Project: pj, roles: make(map[string]types.Role)}
+}
+
+func (m *Memory) PutStatus(st types.Status) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	putDefaults(&st.Id, &st.Version, &st.CreatedAt, &st.UpdatedAt)
+	if st.Category == "" {
+		st.Category = types.CategoryTodo
+	}
+	if st.Deleted && st.DeletedAt == nil {
+		st.DeletedAt = &st.UpdatedAt
+	}
+	m.statuses[st.Id] = &st
+}
+
+func (m *Memory) PutTask(t types.Task) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	putDefaults(&t.Id, &t.Version, &t.CreatedAt, &t.UpdatedAt)
+	if t.Priority == "" {
+		t.Priority = types.PriorityNone
+	}
+	if t.Deleted && t.DeletedAt == nil {
+		t.DeletedAt = &t.UpdatedAt
+	}
+	m.tasks[t.Id] = &t
+}
+
+func (m *Memory) PutInvitation(inv types.Invitation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var version int64
+	putDefaults(&inv.Id, &version, &inv.CreatedAt, &inv.UpdatedAt)
+	if inv.Role == "" {
+		inv.Role = types.RoleMember
+	}
+	if inv.Status == "" {
+		inv.Status = types.InvitationPending
+	}
+	m.invitations[inv.Id] = &inv
+}
+
+func (m *Memory) PutLabel(l types.Label) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var version int64
+	putDefaults(&l.Id, &version, &l.CreatedAt, &l.UpdatedAt)
+	m.labels[l.Id] = &l
+}
+
+// PutTaskLabel attaches the label lId to the task tId.
+func (m *Memory) PutTaskLabel(tId, lId string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.taskLabels[tId] == nil {
+		m.taskLabels[tId] = make(map[string]bool)
+	}
+	m.taskLabels[tId][lId] = true
+}
+
+// PutContributor makes the account aId a contributor of the project pId with the role.
+func (m *Memory) PutContributor(pId, aId string, role types.Role) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if role == "" {
+		role = types.RoleMember
+	}
+	m.projects[pId].roles[aId] = role
+}
+
+// PutTransition adds the transition tr to the workflow of the project pId.
+func (m *Memory) PutTransition(pId string, tr types.Transition) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pj := m.projects[pId]
+	pj.transitions = append(pj.transitions, tr)
+}
+
+// PutDependency makes the task blockerId block the task tId.
+func (m *Memory) PutDependency(tId, blockerId string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.dependencies = append(m.dependencies, types.Dependency{BlockerId: blockerId, BlockedId: tId, CreatedAt: memoryNow()})
+}
+
+// PutAssignee assigns the account aId to the task tId.
+func (m *Memory) PutAssignee(tId, aId string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.assignments = append(m.assignments, memoryAssignment{taskId: tId, accountId: aId})
+}
+
+func (m *Memory) PutComment(c types.Comment) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var version int64
+	putDefaults(&c.Id, &version, &c.CreatedAt, &c.UpdatedAt)
+	m.comments[c.Id] = &c
+}
+
+// PutAttachment keeps metadata of the attachment a, its contents
+// aren't put into the storage of the service.
+func (m *Memory) PutAttachment(a types.Attachment) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var version int64
+	putDefaults(&a.Id, &version, &a.CreatedAt, &a.UpdatedAt)
+	m.attachments[a.Id] = &a
+}
+
+func putDefaults(id *string, version *int64, createdAt, updatedAt *time.Time) {
+	if *id == "" {
+		*id = uuid.NewString()
+	}
+	if *version == 0 {
+		*version = 1
+	}
+	if createdAt.IsZero() {
+		*createdAt = memoryNow()
+	}
+	if updatedAt.IsZero() {
+		*updatedAt = *createdAt
+	}
+}
+
+// MemoryAccounts keeps accounts in a Memory.
+type MemoryAccounts struct {
+	*Memory
+}
+
+func (m *MemoryAccounts) GetById(ctx context.Context, id string) (*types.Account, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	acc, ok := m.accounts[id]
+	if !ok || acc.Deleted {
+		return nil, ErrNotFound
+	}
+
+	res := acc.Account
+	return &res, nil
+}
+
+func (m *MemoryAccounts) List(ctx context.Context, params *ListParams) (*types.Page[types.Account], error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	accs := make([]types.Account, 0, len(m.accounts))
+	for _, acc := range m.accounts {
+		if !acc.Deleted {
+			accs = append(accs, acc.Account)
+		}
+	}
+
+	return listMemory(accountList, params, accs)
+}
+
+func (m *MemoryAccounts) Credentials(ctx context.Context, email string) (string, string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, acc := range m.accounts {
+		if acc.Email == email && !acc.Deleted {
+			return acc.Id, acc.hash, nil
+		}
+	}
+
+	return "", "", ErrNotFound
+}
+
+func (m *MemoryAccounts) WithoutPassword(ctx context.Context) ([]types.Account, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	accs := make([]types.Account, 0)
+	for _, acc := range m.accounts {
+		if acc.hash == "" && !acc.Deleted {
+			accs = append(accs, acc.Account)
+		}
+	}
+	sort.Slice(accs, func(i, j int) bool {
+		if !accs[i].CreatedAt.Equal(accs[j].CreatedAt) {
+			return accs[i].CreatedAt.Before(accs[j].CreatedAt)
+		}
+		return accs[i].Id < accs[j].Id
+	})
+
+	return accs, nil
+}
+
+func (m *MemoryAccounts) SetFirstPassword(ctx context.Context, id, hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	acc, ok := m.accounts[id]
+	if !ok || acc.Deleted || acc.hash != "" {
+		return ErrFailedToUpdate
+	}
+	acc.hash = hash
+	acc.Version++
+	acc.UpdatedAt = memoryNow()
+
+	return nil
+}
+
+func (m *MemoryAccounts) Add(ctx context.Context, input *AddAccountInput, hash string) (*types.Account, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.emailTaken(input.Email, "") {
+		return nil, &Error{Err: ErrConflict, Message: "already exists (accounts_email_key)"}
+	}
+
+	now := memoryNow()
+	acc := &memoryAccount{
+		Account: types.Account{
+			Id:        uuid.NewString(),
+			Email:     input.Email,
+			Name:      input.Name,
+			Avatar:    input.Avatar,
+			Version:   1,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		hash: hash,
+	}
+	m.accounts[acc.Id] = acc
+
+	email := normalizeEmail(acc.Email)
+	for _, inv := range m.invitations {
+		if strings.ToLower(inv.Email) == email && inv.InviteeId == "" && inv.Status == types.InvitationPending && !inv.Deleted {
+			inv.InviteeId = acc.Id
+		}
+	}
+
+	res := acc.Account
+	return &res, nil
+}
+
+func (m *MemoryAccounts) Update(ctx context.Context, input *UpdateAccountInput, hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fields := []struct {
+		dst func(*memoryAccount) *string
+		src types.Patch[string]
+	}{
+		{func(a *memoryAccount) *string { return &a.Name }, input.Name},
+		{func(a *memoryAccount) *string { return &a.Email }, input.Email},
+		{func(a *memoryAccount) *string { return &a.Avatar }, input.Avatar},
+		{func(a *memoryAccount) *string { return &a.hash }, types.Patch[string]{Value: hash, Set: hash != ""}},
+	}
+	if !anySet(input.Name.Set, input.Email.Set, input.Avatar.Set, hash != "") {
+		return nil
+	}
+
+	acc, ok := m.accounts[input.Id]
+	if !ok {
+		return ErrFailedToUpdate
+	}
+	if input.Email.Set && m.emailTaken(input.Email.Value, acc.Id) {
+		return &Error{Err: ErrConflict, Message: "already exists (accounts_email_key)"}
+	}
+
+	for _, f := range fields {
+		if f.src.Set {
+			*f.dst(acc) = f.src.Value
+		}
+	}
+	acc.Version++
+	acc.UpdatedAt = memoryNow()
+
+	return nil
+}
+
+func (m *MemoryAccounts) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	acc, ok := m.accounts[id]
+	if !ok || acc.Deleted {
+		return ErrFailedToUpdate
+	}
+	now := memoryNow()
+	acc.Deleted = true
+	acc.deletedAt = &now
+	acc.Version++
+	acc.UpdatedAt = now
+
+	for _, pj := range m.projects {
+		if pj.OwnerId == id && !pj.Deleted {
+			m.deleteProject(pj, now)
+		}
+	}
+
+	return nil
+}
+
+// emailTaken reports whether an account other than the account id has the email,
+// deleted accounts keep their emails as they do in Postgres.
+func (m *Memory) emailTaken(email, id string) bool {
+	for _, acc := range m.accounts {
+		if acc.Email == email && acc.Id != id {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryProjects keeps projects in a Memory.
+type MemoryProjects struct {
+	*Memory
+}
+
+func (m *MemoryProjects) GetById(ctx context.Context, id string) (*types.Project, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	pj, ok := m.projects[id]
+	if !ok || pj.Deleted {
+		return nil, ErrNotFound
+	}
+
+	res := pj.Project
+	return &res, nil
+}
+
+func (m *MemoryProjects) ListByOwner(ctx context.Context, ownerId string, params *ListParams) (*types.Page[types.Project], error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	pjs := make([]types.Project, 0)
+	for _, pj := range m.projects {
+		if pj.OwnerId == ownerId && !pj.Deleted {
+			pjs = append(pjs, pj.Project)
+		}
+	}
+
+	return listMemory(projectList, params, pjs)
+}
+
+func (m *MemoryProjects) Role(ctx context.Context, pId, aId string) (types.Role, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	pj, ok := m.projects[pId]
+	if !ok || pj.Deleted {
+		return "", ErrNotFound
+	}
+	if pj.OwnerId == aId {
+		return types.RoleOwner, nil
+	}
+
+	return pj.roles[aId], nil
+}
+
+func (m *MemoryProjects) Add(ctx context.Context, input *AddProjectInput, wf *types.Workflow) (*types.Project, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.accounts[input.OwnerId]; !ok {
+		return nil, &Error{Err: ErrUnprocessable, Message: "references a non-existent entity (fk_projects_accounts)"}
+	}
+
+	now := memoryNow()
+	pj := &memoryProject{
+		Project: types.Project{
+			Id:                 uuid.NewString(),
+			Name:               input.Name,
+			Description:        input.Description,
+			OwnerId:            input.OwnerId,
+			StrictDependencies: true,
+			StrictWipLimits:    true,
+			Version:            1,
+			CreatedAt:          now,
+			UpdatedAt:          now,
+		},
+		roles: make(map[string]types.Role),
+	}
+
+	// The project is only kept once the whole workflow is seeded,
+	// as the transaction of PostgresProjects.Add is only committed then.
+	sts := make([]*types.Status, 0, len(wf.Statuses))
+	ids := make(map[string]string, len(wf.Statuses))
+	for i, ws := range wf.Statuses {
+		if _, ok := ids[ws.Name]; ok {
+			return nil, &Error{Err: ErrConflict, Message: "already exists (status_name_project_id_unique)"}
+		}
+		st := &types.Status{
+			Id:        uuid.NewString(),
+			Name:      ws.Name,
+			ProjectId: pj.Id,
+			Category:  ws.Category,
+			Position:  int64(i+1) * positionGap,
+			Version:   1,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		sts = append(sts, st)
+		ids[st.Name] = st.Id
+	}
+	for _, tr := range wf.Transitions {
+		from, okFrom := ids[tr.From]
+		to, okTo := ids[tr.To]
+		if !okFrom || !okTo {
+			return nil, ErrInternal
+		}
+		if from == to {
+			return nil, &Error{Err: ErrFailedValidation, Message: "violates a check (status_transitions_self_check)"}
+		}
+		if tr := (types.Transition{FromStatusId: from, ToStatusId: to}); !hasTransition(pj.transitions, tr) {
+			pj.transitions = append(pj.transitions, tr)
+		}
+	}
+
+	m.projects[pj.Id] = pj
+	res := pj.Project
+	res.Statuses = make([]types.Status, 0, len(sts))
+	for _, st := range sts {
+		m.statuses[st.Id] = st
+		res.Statuses = append(res.Statuses, m.status(st))
+	}
+
+	return &res, nil
+}
+
+func (m *MemoryProjects) Update(ctx context.Context, input *UpdateProjectInput) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !anySet(input.Name.Set, input.Description.Set, input.StrictDependencies.Set, input.StrictWipLimits.Set) {
+		return nil
+	}
+	pj, ok := m.projects[input.Id]
+	if !ok {
+		return ErrFailedToUpdate
+	}
+
+	if input.Name.Set {
+		pj.Name = input.Name.Value
+	}
+	if input.Description.Set {
+		pj.Description = input.Description.Value
+	}
+	if input.StrictDependencies.Set {
+		pj.StrictDependencies = input.StrictDependencies.Value
+	}
+	if input.StrictWipLimits.Set {
+		pj.StrictWipLimits = input.StrictWipLimits.Value
+	}
+	pj.Version++
+	pj.UpdatedAt = memoryNow()
+
+	return nil
+}
+
+func (m *MemoryProjects) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pj, ok := m.projects[id]
+	if !ok || pj.Deleted {
+		return ErrFailedToUpdate
+	}
+	m.deleteProject(pj, memoryNow())
+
+	return nil
+}
+
+func (m *MemoryProjects) Contributors(ctx context.Context, pId string) ([]types.Account, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	accs := make([]types.Account, 0)
+	pj, ok := m.projects[pId]
+	if !ok {
+		return accs, nil
+	}
+	for aId, role := range pj.roles {
+		if acc, ok := m.accounts[aId]; ok && !acc.Deleted {
+			res := acc.Account
+			res.Role = role
+			accs = append(accs, res)
+		}
+	}
+	sort.Slice(accs, func(i, j int) bool { return accs[i].Id < accs[j].Id })
+
+	return accs, nil
+}
+
+func (m *MemoryProjects) Contributed(ctx context.Context, aId string) ([]types.Project, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	pjs := make([]types.Project, 0)
+	for _, pj := range m.projects {
+		if _, ok := pj.roles[aId]; ok && !pj.Deleted {
+			pjs = append(pjs, pj.Project)
+		}
+	}
+	sort.Slice(pjs, func(i, j int) bool { return pjs[i].Id < pjs[j].Id })
+
+	return pjs, nil
+}
+
+func (m *MemoryProjects) AddContributor(ctx context.Context, input *AddContributorInput) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pj, ok := m.projects[input.ProjectId]
+	if !ok {
+		return &Error{Err: ErrUnprocessable, Message: "references a non-existent entity (fk_projects_to_accounts_projects)"}
+	}
+	if _, ok := m.accounts[input.AccountId]; !ok {
+		return &Error{Err: ErrUnprocessable, Message: "references a non-existent entity (fk_projects_to_accounts_accounts)"}
+	}
+	if _, ok := pj.roles[input.AccountId]; ok {
+		return ErrFailedToInsert
+	}
+	pj.roles[input.AccountId] = input.Role
+
+	return nil
+}
+
+func (m *MemoryProjects) UpdateContributor(ctx context.Context, input *UpdateContributorInput) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pj, ok := m.projects[input.ProjectId]
+	if !ok {
+		return ErrFailedToUpdate
+	}
+	if _, ok := pj.roles[input.AccountId]; !ok {
+		return ErrFailedToUpdate
+	}
+	pj.roles[input.AccountId] = input.Role
+
+	return nil
+}
+
+func (m *MemoryProjects) DeleteContributor(ctx context.Context, pId, aId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pj, ok := m.projects[pId]
+	if !ok {
+		return ErrFailedToUpdate
+	}
+	if _, ok := pj.roles[aId]; !ok {
+		return ErrFailedToUpdate
+	}
+	delete(pj.roles, aId)
+
+	// Former contributors can't stay assigned to tasks of the project.
+	m.unassign(func(as memoryAssignment) bool {
+		t, ok := m.tasks[as.taskId]
+		return as.accountId == aId && ok && t.ProjectId == pId
+	})
+
+	return nil
+}
+
+func (m *MemoryProjects) Transitions(ctx context.Context, pId string) ([]types.Transition, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	trs := make([]types.Transition, 0)
+	pj, ok := m.projects[pId]
+	if !ok {
+		return trs, nil
+	}
+	for _, tr := range pj.transitions {
+		from, to := m.statuses[tr.FromStatusId], m.statuses[tr.ToStatusId]
+		if from != nil && to != nil && !from.Deleted && !to.Deleted {
+			trs = append(trs, tr)
+		}
+	}
+	// The transitions of a workflow are set at once,
+	// so Postgres orders them by their statuses.
+	sort.Slice(trs, func(i, j int) bool {
+		if trs[i].FromStatusId != trs[j].FromStatusId {
+			return trs[i].FromStatusId < trs[j].FromStatusId
+		}
+		return trs[i].ToStatusId < trs[j].ToStatusId
+	})
+
+	return trs, nil
+}
+
+func (m *MemoryProjects) SetTransitions(ctx context.Context, input *SetTransitionsInput) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	trs := make([]types.Transition, 0, len(input.Transitions))
+	for _, tr := range input.Transitions {
+		for _, id := range []string{tr.FromStatusId, tr.ToStatusId} {
+			st, ok := m.statuses[id]
+			if !ok || st.ProjectId != input.ProjectId || st.Deleted {
+				return invalid("transitions", "must be between statuses of the project")
+			}
+		}
+		if !hasTransition(trs, tr) {
+			trs = append(trs, tr)
+		}
+	}
+	if pj, ok := m.projects[input.ProjectId]; ok {
+		pj.transitions = trs
+	}
+
+	return nil
+}
+
+// deleteProject soft deletes the project pj with its statuses and tasks
+// like PostgresProjects.Delete does.
+func (m *Memory) deleteProject(pj *memoryProject, now time.Time) {
+	pj.Deleted = true
+	pj.DeletedAt = &now
+	pj.Version++
+	pj.UpdatedAt = now
+
+	for _, st := range m.statuses {
+		if st.ProjectId == pj.Id && !st.Deleted {
+			deleteStatus(st, now)
+		}
+	}
+	for _, t := range m.tasks {
+		if t.ProjectId == pj.Id && !t.Deleted {
+			deleteTask(t, now)
+		}
+	}
+}
+
+func hasTransition(trs []types.Transition, tr types.Transition) bool {
+	for _, t := range trs {
+		if t == tr {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryStatuses keeps statuses in a Memory.
+type MemoryStatuses struct {
+	*Memory
+}
+
+func (m *MemoryStatuses) GetById(ctx context.Context, id string) (*types.Status, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	st, ok := m.statuses[id]
+	if !ok || st.Deleted {
+		return nil, ErrNotFound
+	}
+
+	res := m.status(st)
+	return &res, nil
+}
+
+func (m *MemoryStatuses) ListByProject(ctx context.Context, pId string, params *ListParams) (*types.Page[types.Status], error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sts := make([]types.Status, 0)
+	for _, st := range m.statuses {
+		if st.ProjectId == pId && !st.Deleted {
+			sts = append(sts, m.status(st))
+		}
+	}
+
+	return listMemory(statusList, params, sts)
+}
+
+func (m *MemoryStatuses) ProjectId(ctx context.Context, id string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	st, ok := m.statuses[id]
+	if !ok || st.Deleted {
+		return "", ErrNotFound
+	}
+
+	return st.ProjectId, nil
+}
+
+func (m *MemoryStatuses) Add(ctx context.Context, input *AddStatusInput) (*types.Status, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.projects[input.ProjectId]; !ok {
+		return nil, &Error{Err: ErrUnprocessable, Message: "references a non-existent entity (fk_statuses_projects)"}
+	}
+	if m.statusNameTaken(input.ProjectId, input.Name, "") {
+		return nil, &Error{Err: ErrConflict, Message: "already exists (status_name_project_id_unique)"}
+	}
+
+	var position int64
+	for _, st := range m.statuses {
+		if st.ProjectId == input.ProjectId && !st.Deleted {
+			position = max(position, st.Position)
+		}
+	}
+
+	now := memoryNow()
+	st := &types.Status{
+		Id:        uuid.NewString(),
+		Name:      input.Name,
+		ProjectId: input.ProjectId,
+		Category:  input.Category,
+		Position:  position + positionGap,
+		Version:   1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if input.WipLimit != nil {
+		limit := *input.WipLimit
+		st.WipLimit = &limit
+	}
+	m.statuses[st.Id] = st
+
+	res := m.status(st)
+	return &res, nil
+}
+
+func (m *MemoryStatuses) Update(ctx context.Context, input *UpdateStatusInput) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !anySet(input.Name.Set, input.Category.Set, input.WipLimit.Set) {
+		return nil
+	}
+	st, ok := m.statuses[input.Id]
+	if !ok {
+		return ErrFailedToUpdate
+	}
+	if input.Name.Set && m.statusNameTaken(st.ProjectId, input.Name.Value, st.Id) {
+		return &Error{Err: ErrConflict, Message: "already exists (status_name_project_id_unique)"}
+	}
+
+	if input.Name.Set {
+		st.Name = input.Name.Value
+	}
+	if input.Category.Set {
+		st.Category = input.Category.Value
+	}
+	if input.WipLimit.Set {
+		st.WipLimit = nil
+		if limit := input.WipLimit.Value; limit != 0 {
+			st.WipLimit = &limit
+		}
+	}
+	st.Version++
+	st.UpdatedAt = memoryNow()
+
+	return nil
+}
+
+func (m *MemoryStatuses) Delete(ctx context.Context, id, targetId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st, ok := m.statuses[id]
+	if !ok || st.Deleted {
+		return ErrNotFound
+	}
+
+	now := memoryNow()
+	if targetId == "" {
+		if count := m.status(st).TaskCount; count > 0 {
+			return &Error{
+				Err:     ErrConflict,
+				Message: fmt.Sprintf("status has %d tasks, a target status for them is required", count),
+			}
+		}
+	} else {
+		target, ok := m.statuses[targetId]
+		if !ok || target.Deleted || target.ProjectId != st.ProjectId {
+			return invalid("target_id", "must be a status of the same project")
+		}
+
+		// Deleted tasks are moved as well, so they can be restored to a visible status.
+		moved := make([]*types.Task, 0)
+		for _, t := range m.tasks {
+			if t.StatusId == id {
+				moved = append(moved, t)
+			}
+		}
+		sort.Slice(moved, func(i, j int) bool {
+			if moved[i].Position != moved[j].Position {
+				return moved[i].Position < moved[j].Position
+			}
+			return moved[i].Id < moved[j].Id
+		})
+		position := m.lastTaskPosition(targetId)
+		for i, t := range moved {
+			t.StatusId = targetId
+			t.Position = position + int64(i+1)*positionGap
+			t.Version++
+			t.UpdatedAt = now
+		}
+	}
+
+	deleteStatus(st, now)
+	pj := m.projects[st.ProjectId]
+	trs := make([]types.Transition, 0, len(pj.transitions))
+	for _, tr := range pj.transitions {
+		if tr.FromStatusId != id && tr.ToStatusId != id {
+			trs = append(trs, tr)
+		}
+	}
+	pj.transitions = trs
+
+	return nil
+}
+
+func (m *MemoryStatuses) Move(ctx context.Context, pId string, input *MoveStatusInput) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st, ok := m.statuses[input.Id]
+	if !ok {
+		return nil
+	}
+	scope := make([]memoryPosition, 0)
+	for _, o := range m.statuses {
+		if o.ProjectId == pId && !o.Deleted {
+			scope = append(scope, memoryPosition{id: o.Id, position: &o.Position, version: &o.Version, updatedAt: &o.UpdatedAt})
+		}
+	}
+	now := memoryNow()
+	pos, err := placeMemory(scope, input.Id, input.AfterId, now)
+	if err != nil {
+		return err
+	}
+
+	st.Position = pos
+	st.Version++
+	st.UpdatedAt = now
+
+	return nil
+}
+
+// status returns a copy of the status st with the fields Postgres computes.
+func (m *Memory) status(st *types.Status) types.Status {
+	res := *st
+	res.TaskCount = 0
+	for _, t := range m.tasks {
+		if t.StatusId == st.Id && !t.Deleted {
+			res.TaskCount++
+		}
+	}
+	res.OverLimit = res.WipLimit != nil && res.TaskCount > *res.WipLimit
+	res.Done = st.Category == types.CategoryDone || st.Category == types.CategoryCancelled
+
+	return res
+}
+
+// statusNameTaken reports whether a status of the project pId other than
+// the status id has the name, deleted statuses keep their names as they do in Postgres.
+func (m *Memory) statusNameTaken(pId, name, id string) bool {
+	for _, st := range m.statuses {
+		if st.ProjectId == pId && st.Name == name && st.Id != id {
+			return true
+		}
+	}
+	return false
+}
+
+func deleteStatus(st *types.Status, now time.Time) {
+	st.Deleted = true
+	st.DeletedAt = &now
+	st.Version++
+	st.UpdatedAt = now
+}
+
+// MemoryTasks keeps tasks in a Memory.
+type MemoryTasks struct {
+	*Memory
+}
+
+func (m *MemoryTasks) GetById(ctx context.Context, id string) (*types.Task, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.tasks[id]
+	if !ok || t.Deleted {
+		return nil, ErrNotFound
+	}
+
+	res := *t
+	res.Assignees = m.assigneesOf(id)
+	res.Labels = m.labelsOf(id)
+	res.Progress = &types.Progress{}
+	for _, sub := range m.subtree(id)[1:] {
+		res.Progress.Total++
+		if st, ok := m.statuses[sub.StatusId]; ok && m.status(st).Done {
+			res.Progress.Done++
+		}
+	}
+
+	return &res, nil
+}
+
+func (m *MemoryTasks) ListByProject(ctx context.Context, pId string, params *ListParams) (*types.Page[types.Task], error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.listTasks(params, func(t *types.Task) bool {
+		return t.ProjectId == pId
+	})
+}
+
+func (m *MemoryTasks) ProjectId(ctx context.Context, id string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.tasks[id]
+	if !ok || t.Deleted {
+		return "", ErrNotFound
+	}
+
+	return t.ProjectId, nil
+}
+
+func (m *MemoryTasks) Add(ctx context.Context, input *AddTaskInput, start, end time.Time) (*types.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if input.ParentId != "" {
+		if err := m.checkParent(input.ProjectId, "", input.ParentId); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.checkStatus(input.ProjectId, input.StatusId); err != nil {
+		return nil, err
+	}
+	if err := m.checkWipLimit(input.StatusId, ""); err != nil {
+		return nil, err
+	}
+	lIds, err := m.checkLabels(input.ProjectId, input.LabelIds)
+	if err != nil {
+		return nil, err
+	}
+
+	now := memoryNow()
+	t := &types.Task{
+		Id:          uuid.NewString(),
+		Name:        input.Name,
+		Description: input.Description,
+		Priority:    input.Priority,
+		Start:       start.UTC(),
+		End:         end.UTC(),
+		StatusId:    input.StatusId,
+		ProjectId:   input.ProjectId,
+		ParentId:    input.ParentId,
+		Position:    m.lastTaskPosition(input.StatusId) + positionGap,
+		Version:     1,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	m.tasks[t.Id] = t
+	m.taskLabels[t.Id] = lIds
+
+	res := *t
+	res.Labels = m.labelsOf(t.Id)
+	return &res, nil
+}
+
+func (m *MemoryTasks) Update(ctx context.Context, input *UpdateTaskInput, start, end time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[input.Id]
+	if !ok || t.Deleted {
+		return ErrFailedToUpdate
+	}
+
+	// Changes are made to a copy, so the task stays as it was
+	// if one of them fails like the transaction of PostgresTasks.Update.
+	next := *t
+	if input.Name.Set {
+		next.Name = input.Name.Value
+	}
+	if input.Description.Set {
+		next.Description = input.Description.Value
+	}
+	if input.Priority.Set {
+		next.Priority = input.Priority.Value
+	}
+
+	if input.Start.Set || input.End.Set {
+		if !input.Start.Set {
+			start = t.Start
+		}
+		if !input.End.Set {
+			end = t.End
+		}
+		if end.Before(start) {
+			return invalid("end", "must not be before start")
+		}
+		if err := m.checkDependencyDates(t.ProjectId, t.Id, start, end); err != nil {
+			return err
+		}
+		next.Start = start.UTC()
+		next.End = end.UTC()
+	}
+
+	if input.StatusId.Set {
+		if err := m.checkStatus(t.ProjectId, input.StatusId.Value); err != nil {
+			return err
+		}
+		if err := m.checkTransition(t.ProjectId, t.Id, input.StatusId.Value); err != nil {
+			return err
+		}
+		if err := m.checkWipLimit(input.StatusId.Value, t.Id); err != nil {
+			return err
+		}
+		// A task moved to another status goes to the end of it.
+		if t.StatusId != input.StatusId.Value {
+			next.Position = m.lastTaskPosition(input.StatusId.Value) + positionGap
+		}
+		next.StatusId = input.StatusId.Value
+	}
+
+	if input.ParentId.Set {
+		if input.ParentId.Null {
+			next.ParentId = ""
+		} else {
+			if err := m.checkParent(t.ProjectId, t.Id, input.ParentId.Value); err != nil {
+				return err
+			}
+			next.ParentId = input.ParentId.Value
+		}
+	}
+
+	var lIds map[string]bool
+	if input.LabelIds.Set {
+		var err error
+		if lIds, err = m.checkLabels(t.ProjectId, input.LabelIds.Value); err != nil {
+			return err
+		}
+	}
+
+	if !anySet(input.Name.Set, input.Description.Set, input.Priority.Set, input.Start.Set, input.End.Set,
+		input.StatusId.Set, input.ParentId.Set, input.LabelIds.Set) {
+		return nil
+	}
+	next.Version++
+	next.UpdatedAt = memoryNow()
+	*t = next
+	if input.LabelIds.Set {
+		m.taskLabels[t.Id] = lIds
+	}
+
+	return nil
+}
+
+func (m *MemoryTasks) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tks := m.subtree(id)
+	if len(tks) == 0 {
+		return ErrFailedToUpdate
+	}
+	now := memoryNow()
+	for _, t := range tks {
+		deleteTask(t, now)
+	}
+
+	return nil
+}
+
+func (m *MemoryTasks) Move(ctx context.Context, pId string, input *MoveTaskInput) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[input.Id]
+	if !ok || t.Deleted {
+		return ErrFailedToUpdate
+	}
+	sId := input.StatusId
+	if sId == "" {
+		sId = t.StatusId
+	} else {
+		if err := m.checkStatus(pId, sId); err != nil {
+			return err
+		}
+		if err := m.checkTransition(pId, t.Id, sId); err != nil {
+			return err
+		}
+		if err := m.checkWipLimit(sId, t.Id); err != nil {
+			return err
+		}
+	}
+
+	scope := make([]memoryPosition, 0)
+	for _, o := range m.tasks {
+		if o.StatusId == sId && !o.Deleted {
+			scope = append(scope, memoryPosition{id: o.Id, position: &o.Position, version: &o.Version, updatedAt: &o.UpdatedAt})
+		}
+	}
+	now := memoryNow()
+	pos, err := placeMemory(scope, t.Id, input.AfterId, now)
+	if err != nil {
+		return err
+	}
+
+	t.StatusId = sId
+	t.Position = pos
+	t.Version++
+	t.UpdatedAt = now
+
+	return nil
+}
+
+func (m *MemoryTasks) ListChildren(ctx context.Context, id string, params *ListParams) (*types.Page[types.Task], error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.listTasks(params, func(t *types.Task) bool {
+		return t.ParentId == id
+	})
+}
+
+func (m *MemoryTasks) Tree(ctx context.Context, id string) (*types.Task, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tks := make([]types.Task, 0)
+	done := make(map[string]bool)
+	for _, t := range m.subtree(id) {
+		tks = append(tks, *t)
+		if st, ok := m.statuses[t.StatusId]; ok {
+			done[t.Id] = m.status(st).Done
+		}
+	}
+
+	root, ok := buildTree(id, tks, done)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return root, nil
+}
+
+// listTasks returns a page of tasks that aren't deleted, match and pass
+// the filters of params the way filterTasks does in Postgres.
+//
+// Returned errors: ErrFailedValidation, ErrInternal
+func (m *Memory) listTasks(params *ListParams, match func(*types.Task) bool) (*types.Page[types.Task], error) {
+	if params == nil {
+		params = new(ListParams)
+	}
+	if _, err := filterTasks(params, func(any) string { return "" }); err != nil {
+		return nil, err
+	}
+	var priorities []string
+	if params.Priority != "" {
+		priorities = strings.Split(params.Priority, ",")
+	}
+
+	tks := make([]types.Task, 0)
+	for _, t := range m.tasks {
+		if t.Deleted || !match(t) {
+			continue
+		}
+		if params.StatusId != "" && t.StatusId != params.StatusId {
+			continue
+		}
+		if priorities != nil && !contains(priorities, string(t.Priority)) {
+			continue
+		}
+		if params.LabelId != "" && !m.taskLabels[t.Id][params.LabelId] {
+			continue
+		}
+		tks = append(tks, *t)
+	}
+
+	return listMemory(taskList, params, tks)
+}
+
+// subtree returns the task id and its subtasks at any depth that aren't deleted,
+// the task id comes first. It's empty if the task id is deleted.
+func (m *Memory) subtree(id string) []*types.Task {
+	root, ok := m.tasks[id]
+	if !ok || root.Deleted {
+		return nil
+	}
+
+	tks := []*types.Task{root}
+	seen := map[string]bool{id: true}
+	for i := 0; i < len(tks); i++ {
+		for _, t := range m.tasks {
+			if t.ParentId == tks[i].Id && !t.Deleted && !seen[t.Id] {
+				seen[t.Id] = true
+				tks = append(tks, t)
+			}
+		}
+	}
+
+	return tks
+}
+
+// lastTaskPosition returns the position of the last task of the status sId, or 0 if it has none.
+func (m *Memory) lastTaskPosition(sId string) int64 {
+	var position int64
+	for _, t := range m.tasks {
+		if t.StatusId == sId && !t.Deleted {
+			position = max(position, t.Position)
+		}
+	}
+	return position
+}
+
+// checkParent is checkParent for tasks kept in memory.
+//
+// Returned errors: ErrFailedValidation
+func (m *Memory) checkParent(pId, tId, parentId string) error {
+	parent, ok := m.tasks[parentId]
+	if !ok || parent.Deleted || parent.ProjectId != pId {
+		return invalid("parent_id", "must be a task of the same project")
+	}
+	if tId == "" {
+		return nil
+	}
+
+	// The task can't become a subtask of itself or of any of its subtasks.
+	seen := make(map[string]bool)
+	for id := parentId; id != "" && !seen[id]; {
+		if id == tId {
+			return invalid("parent_id", "must not be the task itself or one of its subtasks")
+		}
+		seen[id] = true
+		t, ok := m.tasks[id]
+		if !ok {
+			break
+		}
+		id = t.ParentId
+	}
+
+	return nil
+}
+
+// checkLabels is setTaskLabels for labels kept in memory, it returns the set
+// of ids if all of them are labels of the project pId.
+//
+// Returned errors: ErrFailedValidation
+func (m *Memory) checkLabels(pId string, ids []string) (map[string]bool, error) {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		l, ok := m.labels[id]
+		if !ok || l.Deleted || l.ProjectId != pId {
+			return nil, invalid("label_ids", "must be labels of the task's project")
+		}
+		set[id] = true
+	}
+
+	return set, nil
+}
+
+// labelsOf returns labels of the task tId ordered by name.
+func (m *Memory) labelsOf(tId string) []types.Label {
+	ls := make([]types.Label, 0)
+	for id := range m.taskLabels[tId] {
+		if l, ok := m.labels[id]; ok && !l.Deleted {
+			ls = append(ls, *l)
+		}
+	}
+	sortLabels(ls)
+
+	return ls
+}
+
+// assigneesOf returns accounts assigned to the task tId in order of assignment.
+func (m *Memory) assigneesOf(tId string) []types.Account {
+	accs := make([]types.Account, 0)
+	for _, as := range m.assignments {
+		if acc, ok := m.accounts[as.accountId]; ok && as.taskId == tId && !acc.Deleted {
+			accs = append(accs, acc.Account)
+		}
+	}
+
+	return accs
+}
+
+// checkDependencyDates is checkDependencyDates for tasks kept in memory.
+//
+// Returned errors: ErrFailedValidation
+func (m *Memory) checkDependencyDates(pId, tId string, start, end time.Time) error {
+	if pj, ok := m.projects[pId]; !ok || !pj.StrictDependencies {
+		return nil
+	}
+
+	var early, late bool
+	for _, d := range m.dependencies {
+		if b, ok := m.tasks[d.BlockerId]; ok && d.BlockedId == tId && !b.Deleted && b.End.After(start) {
+			early = true
+		}
+		if t, ok := m.tasks[d.BlockedId]; ok && d.BlockerId == tId && !t.Deleted && t.Start.Before(end) {
+			late = true
+		}
+	}
+	if early {
+		return invalid("start", "must not be before the end of a task blocking it")
+	}
+	if late {
+		return invalid("end", "must not be after the start of a task it blocks")
+	}
+
+	return nil
+}
+
+// checkStatus is checkStatus for statuses kept in memory.
+//
+// Returned errors: ErrFailedValidation
+func (m *Memory) checkStatus(pId, sId string) error {
+	st, ok := m.statuses[sId]
+	if !ok || st.Deleted || st.ProjectId != pId {
+		return invalid("status_id", "must be a status of the task's project")
+	}
+
+	return nil
+}
+
+// checkWipLimit is checkWipLimit for statuses kept in memory.
+//
+// Returned errors: ErrUnprocessable
+func (m *Memory) checkWipLimit(sId, tId string) error {
+	st, ok := m.statuses[sId]
+	if !ok {
+		return nil
+	}
+	pj, ok := m.projects[st.ProjectId]
+	if !ok {
+		return nil
+	}
+	t, ok := m.tasks[tId]
+	moved := !ok || t.StatusId != sId
+	count := m.status(st).TaskCount
+	if !pj.StrictWipLimits || st.WipLimit == nil || !moved || count < *st.WipLimit {
+		return nil
+	}
+
+	return wipLimitReached(st.Name, *st.WipLimit)
+}
+
+// checkTransition is checkTransition for tasks kept in memory.
+//
+// Returned errors: ErrFailedToUpdate, ErrUnprocessable
+func (m *Memory) checkTransition(pId, tId, to string) error {
+	t, ok := m.tasks[tId]
+	if !ok {
+		return ErrFailedToUpdate
+	}
+	from := t.StatusId
+	if from == to {
+		return nil
+	}
+
+	// Any move is allowed in a project without transitions.
+	trs := m.projects[pId].transitions
+	if len(trs) == 0 || hasTransition(trs, types.Transition{FromStatusId: from, ToStatusId: to}) {
+		return nil
+	}
+
+	var fromName, toName string
+	if st, ok := m.statuses[from]; ok {
+		fromName = st.Name
+	}
+	if st, ok := m.statuses[to]; ok {
+		toName = st.Name
+	}
+	return transitionNotAllowed(fromName, toName)
+}
+
+func deleteTask(t *types.Task, now time.Time) {
+	t.Deleted = true
+	t.DeletedAt = &now
+	t.Version++
+	t.UpdatedAt = now
+}
+
+// MemoryLabels keeps labels in a Memory.
+type MemoryLabels struct {
+	*Memory
+}
+
+func (m *MemoryLabels) ListByProject(ctx context.Context, pId string) ([]types.Label, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ls := make([]types.Label, 0)
+	for _, l := range m.labels {
+		if l.ProjectId == pId && !l.Deleted {
+			ls = append(ls, *l)
+		}
+	}
+	sortLabels(ls)
+
+	return ls, nil
+}
+
+func (m *MemoryLabels) ListByTask(ctx context.Context, tId string) ([]types.Label, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.labelsOf(tId), nil
+}
+
+func (m *MemoryLabels) Add(ctx context.Context, input *AddLabelInput) (*types.Label, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.projects[input.ProjectId]; !ok {
+		return nil, &Error{Err: ErrUnprocessable, Message: "references a non-existent entity (fk_labels_projects)"}
+	}
+	if m.labelNameTaken(input.ProjectId, input.Name, "") {
+		return nil, &Error{Err: ErrConflict, Message: "already exists (label_name_project_id_unique)"}
+	}
+
+	now := memoryNow()
+	l := &types.Label{
+		Id:        uuid.NewString(),
+		Name:      input.Name,
+		Color:     input.Color,
+		ProjectId: input.ProjectId,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	m.labels[l.Id] = l
+
+	res := *l
+	return &res, nil
+}
+
+func (m *MemoryLabels) Update(ctx context.Context, input *UpdateLabelInput) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l, ok := m.labels[input.Id]
+	if !ok || l.ProjectId != input.ProjectId || l.Deleted {
+		return ErrFailedToUpdate
+	}
+	if input.Name != "" && m.labelNameTaken(l.ProjectId, input.Name, l.Id) {
+		return &Error{Err: ErrConflict, Message: "already exists (label_name_project_id_unique)"}
+	}
+
+	if input.Name != "" {
+		l.Name = input.Name
+	}
+	if input.Color != "" {
+		l.Color = input.Color
+	}
+	l.UpdatedAt = memoryNow()
+
+	return nil
+}
+
+func (m *MemoryLabels) Delete(ctx context.Context, pId, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l, ok := m.labels[id]
+	if !ok || l.ProjectId != pId || l.Deleted {
+		return ErrFailedToUpdate
+	}
+	l.Deleted = true
+	l.UpdatedAt = memoryNow()
+	for _, ids := range m.taskLabels {
+		delete(ids, id)
+	}
+
+	return nil
+}
+
+// labelNameTaken reports whether a label of the project pId other than the label id
+// has the name, deleted labels free their names as they do in Postgres.
+func (m *Memory) labelNameTaken(pId, name, id string) bool {
+	for _, l := range m.labels {
+		if l.ProjectId == pId && l.Name == name && l.Id != id && !l.Deleted {
+			return true
+		}
+	}
+	return false
+}
+
+func sortLabels(ls []types.Label) {
+	sort.Slice(ls, func(i, j int) bool {
+		if ls[i].Name != ls[j].Name {
+			return ls[i].Name < ls[j].Name
+		}
+		return ls[i].Id < ls[j].Id
+	})
+}
+
+// MemoryAssignees keeps assignees of tasks in a Memory.
+type MemoryAssignees struct {
+	*Memory
+}
+
+func (m *MemoryAssignees) ListByTask(ctx context.Context, tId string) ([]types.Account, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.assigneesOf(tId), nil
+}
+
+func (m *MemoryAssignees) ListTasks(ctx context.Context, aId string, params *ListParams) (*types.Page[types.Task], error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	assigned := make(map[string]bool)
+	for _, as := range m.assignments {
+		if as.accountId == aId {
+			assigned[as.taskId] = true
+		}
+	}
+
+	return m.listTasks(params, func(t *types.Task) bool {
+		pj, ok := m.projects[t.ProjectId]
+		return assigned[t.Id] && ok && !pj.Deleted
+	})
+}
+
+func (m *MemoryAssignees) Add(ctx context.Context, tId, aId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.tasks[tId]; !ok {
+		return &Error{Err: ErrUnprocessable, Message: "references a non-existent entity (fk_tasks_to_accounts_tasks)"}
+	}
+	if _, ok := m.accounts[aId]; !ok {
+		return &Error{Err: ErrUnprocessable, Message: "references a non-existent entity (fk_tasks_to_accounts_accounts)"}
+	}
+	for _, as := range m.assignments {
+		if as.taskId == tId && as.accountId == aId {
+			return ErrFailedToInsert
+		}
+	}
+	m.assignments = append(m.assignments, memoryAssignment{taskId: tId, accountId: aId})
+
+	return nil
+}
+
+func (m *MemoryAssignees) Delete(ctx context.Context, tId, aId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := len(m.assignments)
+	m.unassign(func(as memoryAssignment) bool {
+		return as.taskId == tId && as.accountId == aId
+	})
+	if len(m.assignments) == n {
+		return ErrFailedToUpdate
+	}
+
+	return nil
+}
+
+// unassign removes the assignments that match.
+func (m *Memory) unassign(match func(memoryAssignment) bool) {
+	kept := m.assignments[:0]
+	for _, as := range m.assignments {
+		if !match(as) {
+			kept = append(kept, as)
+		}
+	}
+	m.assignments = kept
+}
+
+// MemoryDependencies keeps dependencies between tasks in a Memory.
+type MemoryDependencies struct {
+	*Memory
+}
+
+func (m *MemoryDependencies) ListByTask(ctx context.Context, tId string) (*types.Dependencies, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	deps := &types.Dependencies{BlockedBy: make([]types.Task, 0), Blocks: make([]types.Task, 0)}
+	for _, d := range m.dependencies {
+		if b, ok := m.tasks[d.BlockerId]; ok && d.BlockedId == tId && !b.Deleted {
+			deps.BlockedBy = append(deps.BlockedBy, *b)
+		}
+		if t, ok := m.tasks[d.BlockedId]; ok && d.BlockerId == tId && !t.Deleted {
+			deps.Blocks = append(deps.Blocks, *t)
+		}
+	}
+	sortTasks(deps.BlockedBy, func(t *types.Task) time.Time { return t.End })
+	sortTasks(deps.Blocks, func(t *types.Task) time.Time { return t.Start })
+
+	return deps, nil
+}
+
+func (m *MemoryDependencies) ListByProject(ctx context.Context, pId string) ([]types.Task, []types.Dependency, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tks := make([]types.Task, 0)
+	for _, t := range m.tasks {
+		if t.ProjectId == pId && !t.Deleted {
+			tks = append(tks, *t)
+		}
+	}
+	sortTasks(tks, func(t *types.Task) time.Time { return t.CreatedAt })
+
+	deps := make([]types.Dependency, 0)
+	for _, d := range m.dependencies {
+		b, okB := m.tasks[d.BlockerId]
+		t, okT := m.tasks[d.BlockedId]
+		if okB && okT && t.ProjectId == pId && !t.Deleted && !b.Deleted {
+			deps = append(deps, d)
+		}
+	}
+
+	return tks, deps, nil
+}
+
+func (m *MemoryDependencies) Add(ctx context.Context, pId string, input *AddDependencyInput) (*types.Dependency, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	blocker, ok := m.tasks[input.BlockerId]
+	if !ok || blocker.Deleted || blocker.ProjectId != pId {
+		return nil, invalid("blocker_id", "must be a task of the same project")
+	}
+	t, ok := m.tasks[input.TaskId]
+	if !ok {
+		return nil, &Error{Err: ErrUnprocessable, Message: "references a non-existent entity (fk_task_dependencies_blocked)"}
+	}
+
+	// The new edge closes a cycle if the blocker is already blocked by the task.
+	blocked := []string{t.Id}
+	seen := map[string]bool{t.Id: true}
+	for i := 0; i < len(blocked); i++ {
+		for _, d := range m.dependencies {
+			if d.BlockerId == blocked[i] && !seen[d.BlockedId] {
+				seen[d.BlockedId] = true
+				blocked = append(blocked, d.BlockedId)
+			}
+		}
+	}
+	if seen[blocker.Id] {
+		return nil, &Error{
+			Err:     ErrUnprocessable,
+			Message: "the dependency would create a cycle",
+			Fields:  []types.FieldError{{Field: "blocker_id", Message: "must not be blocked by the task directly or transitively"}},
+		}
+	}
+
+	if pj, ok := m.projects[pId]; ok && pj.StrictDependencies && blocker.End.After(t.Start) {
+		return nil, invalid("blocker_id", "must end before the task starts")
+	}
+	for _, d := range m.dependencies {
+		if d.BlockerId == blocker.Id && d.BlockedId == t.Id {
+			return nil, &Error{Err: ErrConflict, Message: "already exists (task_dependencies_pkey)"}
+		}
+	}
+
+	d := types.Dependency{BlockerId: blocker.Id, BlockedId: t.Id, CreatedAt: memoryNow()}
+	m.dependencies = append(m.dependencies, d)
+
+	return &d, nil
+}
+
+func (m *MemoryDependencies) Delete(ctx context.Context, tId, blockerId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, d := range m.dependencies {
+		if d.BlockerId == blockerId && d.BlockedId == tId {
+			m.dependencies = append(m.dependencies[:i], m.dependencies[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrFailedToUpdate
+}
+
+// sortTasks orders tasks by the time key returns, then by id.
+func sortTasks(tks []types.Task, key func(*types.Task) time.Time) {
+	sort.Slice(tks, func(i, j int) bool {
+		if a, b := key(&tks[i]), key(&tks[j]); !a.Equal(b) {
+			return a.Before(b)
+		}
+		return tks[i].Id < tks[j].Id
+	})
+}
+
+// MemoryComments keeps comments of tasks in a Memory.
+type MemoryComments struct {
+	*Memory
+}
+
+func (m *MemoryComments) ListByTask(ctx context.Context, tId string, params *ListParams) (*types.Page[types.Comment], error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cs := make([]types.Comment, 0)
+	for _, c := range m.comments {
+		if c.TaskId == tId && c.ParentId == "" && !c.Deleted {
+			cs = append(cs, m.comment(c))
+		}
+	}
+	page, err := listMemory(commentList, params, cs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range page.Items {
+		for _, c := range m.comments {
+			if c.ParentId == page.Items[i].Id && !c.Deleted {
+				page.Items[i].Replies = append(page.Items[i].Replies, m.comment(c))
+			}
+		}
+		sort.Slice(page.Items[i].Replies, func(a, b int) bool {
+			ra, rb := page.Items[i].Replies[a], page.Items[i].Replies[b]
+			if !ra.CreatedAt.Equal(rb.CreatedAt) {
+				return ra.CreatedAt.Before(rb.CreatedAt)
+			}
+			return ra.Id < rb.Id
+		})
+	}
+
+	return page, nil
+}
+
+func (m *MemoryComments) GetById(ctx context.Context, tId, id string) (*types.Comment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, ok := m.comments[id]
+	if !ok || c.TaskId != tId || c.Deleted {
+		return nil, ErrNotFound
+	}
+
+	res := m.comment(c)
+	return &res, nil
+}
+
+func (m *MemoryComments) Add(ctx context.Context, input *AddCommentInput, authorId string) (*types.Comment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if input.ParentId != "" {
+		p, ok := m.comments[input.ParentId]
+		if !ok || p.TaskId != input.TaskId || p.ParentId != "" || p.Deleted {
+			return nil, invalid("parent_id", "must be a top-level comment of the task")
+		}
+	}
+	if _, ok := m.tasks[input.TaskId]; !ok {
+		return nil, &Error{Err: ErrUnprocessable, Message: "references a non-existent entity (fk_comments_tasks)"}
+	}
+
+	now := memoryNow()
+	c := &types.Comment{
+		Id:        uuid.NewString(),
+		TaskId:    input.TaskId,
+		AuthorId:  authorId,
+		ParentId:  input.ParentId,
+		Body:      input.Body,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	m.comments[c.Id] = c
+
+	res := *c
+	return &res, nil
+}
+
+func (m *MemoryComments) Update(ctx context.Context, input *UpdateCommentInput, authorId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.comments[input.Id]
+	if !ok || c.TaskId != input.TaskId || c.Deleted {
+		return ErrNotFound
+	}
+	if c.AuthorId != authorId {
+		return ErrForbidden
+	}
+	if c.Body == input.Body {
+		return nil
+	}
+
+	now := memoryNow()
+	m.revisions = append(m.revisions, types.CommentRevision{
+		Id:        uuid.NewString(),
+		CommentId: c.Id,
+		Body:      c.Body,
+		CreatedAt: now,
+	})
+	c.Body = input.Body
+	c.UpdatedAt = now
+
+	return nil
+}
+
+func (m *MemoryComments) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := memoryNow()
+	for _, c := range m.comments {
+		if (c.Id == id || c.ParentId == id) && !c.Deleted {
+			c.Deleted = true
+			c.UpdatedAt = now
+		}
+	}
+
+	return nil
+}
+
+func (m *MemoryComments) Revisions(ctx context.Context, id string) ([]types.CommentRevision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	revs := make([]types.CommentRevision, 0)
+	for _, rev := range m.revisions {
+		if rev.CommentId == id {
+			revs = append(revs, rev)
+		}
+	}
+
+	return revs, nil
+}
+
+// comment returns a copy of the comment c with the fields Postgres computes.
+func (m *Memory) comment(c *types.Comment) types.Comment {
+	res := *c
+	for _, rev := range m.revisions {
+		if rev.CommentId == c.Id {
+			res.Edited = true
+			break
+		}
+	}
+	return res
+}
+
+// MemoryAttachments keeps metadata of attachments in a Memory.
+type MemoryAttachments struct {
+	*Memory
+}
+
+func (m *MemoryAttachments) ListByTask(ctx context.Context, tId string) ([]types.Attachment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	as := make([]types.Attachment, 0)
+	for _, a := range m.attachments {
+		if a.TaskId == tId && !a.Deleted {
+			as = append(as, *a)
+		}
+	}
+	sort.Slice(as, func(i, j int) bool {
+		if !as[i].CreatedAt.Equal(as[j].CreatedAt) {
+			return as[i].CreatedAt.Before(as[j].CreatedAt)
+		}
+		return as[i].Id < as[j].Id
+	})
+
+	return as, nil
+}
+
+func (m *MemoryAttachments) GetById(ctx context.Context, tId, id string) (*types.Attachment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	a, ok := m.attachments[id]
+	if !ok || a.TaskId != tId || a.Deleted {
+		return nil, ErrNotFound
+	}
+
+	res := *a
+	return &res, nil
+}
+
+func (m *MemoryAttachments) Add(ctx context.Context, a *types.Attachment) (*types.Attachment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.tasks[a.TaskId]; !ok {
+		return nil, &Error{Err: ErrUnprocessable, Message: "references a non-existent entity (fk_attachments_tasks)"}
+	}
+
+	now := memoryNow()
+	stored := *a
+	stored.CreatedAt, stored.UpdatedAt = now, now
+	m.attachments[stored.Id] = &stored
+
+	res := stored
+	return &res, nil
+}
+
+func (m *MemoryAttachments) Delete(ctx context.Context, tId, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.attachments[id]
+	if !ok || a.TaskId != tId || a.Deleted {
+		return ErrFailedToUpdate
+	}
+	a.Deleted = true
+	a.UpdatedAt = memoryNow()
+
+	return nil
+}
+
+// MemoryTrash keeps deleted records in a Memory.
+type MemoryTrash struct {
+	*Memory
+}
+
+func (m *MemoryTrash) ProjectTrash(ctx context.Context, pId string) (*types.Trash, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	trash := &types.Trash{Statuses: make([]types.Status, 0), Tasks: make([]types.Task, 0)}
+	for _, st := range m.statuses {
+		if st.ProjectId == pId && st.Deleted {
+			trash.Statuses = append(trash.Statuses, m.status(st))
+		}
+	}
+	sort.Slice(trash.Statuses, func(i, j int) bool {
+		a, b := trash.Statuses[i], trash.Statuses[j]
+		return deletedLater(a.DeletedAt, b.DeletedAt, a.Id, b.Id)
+	})
+
+	for _, t := range m.tasks {
+		if t.ProjectId == pId && t.Deleted {
+			trash.Tasks = append(trash.Tasks, *t)
+		}
+	}
+	sort.Slice(trash.Tasks, func(i, j int) bool {
+		a, b := trash.Tasks[i], trash.Tasks[j]
+		return deletedLater(a.DeletedAt, b.DeletedAt, a.Id, b.Id)
+	})
+
+	return trash, nil
+}
+
+func (m *MemoryTrash) AccountTrash(ctx context.Context, aId string) (*types.Trash, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	trash := &types.Trash{Projects: make([]types.Project, 0)}
+	for _, pj := range m.projects {
+		if pj.OwnerId == aId && pj.Deleted {
+			trash.Projects = append(trash.Projects, pj.Project)
+		}
+	}
+	sort.Slice(trash.Projects, func(i, j int) bool {
+		a, b := trash.Projects[i], trash.Projects[j]
+		return deletedLater(a.DeletedAt, b.DeletedAt, a.Id, b.Id)
+	})
+
+	return trash, nil
+}
+
+func (m *MemoryTrash) DeletedCredentials(ctx context.Context, email string) (string, string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, acc := range m.accounts {
+		if acc.Email == email && acc.Deleted {
+			return acc.Id, acc.hash, nil
+		}
+	}
+
+	return "", "", ErrNotFound
+}
+
+func (m *MemoryTrash) RestoreAccount(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	acc, ok := m.accounts[id]
+	if !ok || !acc.Deleted || acc.deletedAt == nil {
+		return ErrNotFound
+	}
+	at, now := *acc.deletedAt, memoryNow()
+
+	for _, pj := range m.projects {
+		if pj.OwnerId == id && deletedWith(pj.Deleted, pj.DeletedAt, at) {
+			m.restoreProject(pj, at, now)
+		}
+	}
+	acc.Deleted = false
+	acc.deletedAt = nil
+	acc.Version++
+	acc.UpdatedAt = now
+
+	return nil
+}
+
+func (m *MemoryTrash) RestoreProject(ctx context.Context, id, ownerId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pj, ok := m.projects[id]
+	if !ok || !pj.Deleted || pj.DeletedAt == nil {
+		return ErrNotFound
+	}
+	if pj.OwnerId != ownerId {
+		return ErrForbidden
+	}
+	m.restoreProject(pj, *pj.DeletedAt, memoryNow())
+
+	return nil
+}
+
+func (m *MemoryTrash) StatusProjectId(ctx context.Context, id string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	st, ok := m.statuses[id]
+	if !ok || !st.Deleted {
+		return "", ErrNotFound
+	}
+
+	return st.ProjectId, nil
+}
+
+func (m *MemoryTrash) TaskProjectId(ctx context.Context, id string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.tasks[id]
+	if !ok || !t.Deleted {
+		return "", ErrNotFound
+	}
+
+	return t.ProjectId, nil
+}
+
+func (m *MemoryTrash) RestoreStatus(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st, ok := m.statuses[id]
+	if !ok || !st.Deleted || st.DeletedAt == nil {
+		return ErrNotFound
+	}
+	restoreStatus(st, memoryNow())
+
+	return nil
+}
+
+func (m *MemoryTrash) RestoreTask(ctx context.Context, pId, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if st, ok := m.statuses[t.StatusId]; ok && st.Deleted {
+		return &Error{Err: ErrConflict, Message: "the status of the task is deleted, restore it first"}
+	}
+	if p, ok := m.tasks[t.ParentId]; ok && p.Deleted {
+		return &Error{Err: ErrConflict, Message: "the parent of the task is deleted, restore it first"}
+	}
+	if !t.Deleted || t.DeletedAt == nil {
+		return ErrNotFound
+	}
+	at, now := *t.DeletedAt, memoryNow()
+
+	// Subtasks in deleted statuses stay deleted, so they can't end up hidden.
+	subtree := []*types.Task{t}
+	for i := 0; i < len(subtree); i++ {
+		for _, sub := range m.tasks {
+			st, ok := m.statuses[sub.StatusId]
+			if sub.ParentId == subtree[i].Id && deletedWith(sub.Deleted, sub.DeletedAt, at) && ok && !st.Deleted {
+				subtree = append(subtree, sub)
+			}
+		}
+	}
+	for _, sub := range subtree {
+		restoreTask(sub, now)
+	}
+
+	return nil
+}
+
+func (m *MemoryTrash) Purge(ctx context.Context, retention time.Duration) ([]string, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	at := memoryNow().Add(-retention)
+	expired := func(deleted bool, deletedAt *time.Time) bool {
+		return deleted && deletedAt != nil && deletedAt.Before(at)
+	}
+
+	blobs := make([]string, 0)
+	for _, a := range m.attachments {
+		t, ok := m.tasks[a.TaskId]
+		if a.Deleted && a.UpdatedAt.Before(at) || ok && m.taskExpired(t, expired) {
+			blobs = append(blobs, a.Id)
+			delete(m.attachments, a.Id)
+		}
+	}
+
+	purged := int64(len(blobs))
+	// Records deleted by the cascade of a purged record aren't counted,
+	// ranging over a map skips entries deleted before they are reached.
+	for _, t := range m.tasks {
+		if expired(t.Deleted, t.DeletedAt) {
+			m.purgeTask(t.Id)
+			purged++
+		}
+	}
+	for _, st := range m.statuses {
+		if expired(st.Deleted, st.DeletedAt) {
+			m.purgeStatus(st.Id)
+			purged++
+		}
+	}
+	for _, pj := range m.projects {
+		if expired(pj.Deleted, pj.DeletedAt) {
+			m.purgeProject(pj.Id)
+			purged++
+		}
+	}
+	for _, acc := range m.accounts {
+		if expired(acc.Deleted, acc.deletedAt) {
+			m.purgeAccount(acc.Id)
+			purged++
+		}
+	}
+
+	return blobs, purged, nil
+}
+
+// taskExpired reports whether the task t, its status, its project or
+// the owner of its project is expired.
+func (m *Memory) taskExpired(t *types.Task, expired func(bool, *time.Time) bool) bool {
+	if expired(t.Deleted, t.DeletedAt) {
+		return true
+	}
+	if st, ok := m.statuses[t.StatusId]; ok && expired(st.Deleted, st.DeletedAt) {
+		return true
+	}
+	pj, ok := m.projects[t.ProjectId]
+	if !ok {
+		return false
+	}
+	if expired(pj.Deleted, pj.DeletedAt) {
+		return true
+	}
+	owner, ok := m.accounts[pj.OwnerId]
+	return ok && expired(owner.Deleted, owner.deletedAt)
+}
+
+// purgeTask hard deletes the task id with its subtasks and everything
+// that belongs to them, the way foreign keys of tasks cascade in Postgres.
+func (m *Memory) purgeTask(id string) {
+	delete(m.tasks, id)
+	for _, sub := range m.tasks {
+		if sub.ParentId == id {
+			m.purgeTask(sub.Id)
+		}
+	}
+
+	for _, c := range m.comments {
+		if c.TaskId == id {
+			m.purgeComment(c.Id)
+		}
+	}
+	for _, a := range m.attachments {
+		if a.TaskId == id {
+			delete(m.attachments, a.Id)
+		}
+	}
+	delete(m.taskLabels, id)
+	m.unassign(func(a memoryAssignment) bool { return a.taskId == id })
+	deps := m.dependencies[:0]
+	for _, d := range m.dependencies {
+		if d.BlockerId != id && d.BlockedId != id {
+			deps = append(deps, d)
+		}
+	}
+	m.dependencies = deps
+}
+
+// purgeComment hard deletes the comment id with its replies and revisions.
+func (m *Memory) purgeComment(id string) {
+	delete(m.comments, id)
+	for _, c := range m.comments {
+		if c.ParentId == id {
+			m.purgeComment(c.Id)
+		}
+	}
+
+	revs := m.revisions[:0]
+	for _, rev := range m.revisions {
+		if rev.CommentId != id {
+			revs = append(revs, rev)
+		}
+	}
+	m.revisions = revs
+}
+
+// purgeStatus hard deletes the status id with its tasks and transitions.
+func (m *Memory) purgeStatus(id string) {
+	st, ok := m.statuses[id]
+	if !ok {
+		return
+	}
+	delete(m.statuses, id)
+
+	for _, t := range m.tasks {
+		if t.StatusId == id {
+			m.purgeTask(t.Id)
+		}
+	}
+	if pj, ok := m.projects[st.ProjectId]; ok {
+		trs := pj.transitions[:0]
+		for _, tr := range pj.transitions {
+			if tr.FromStatusId != id && tr.ToStatusId != id {
+				trs = append(trs, tr)
+			}
+		}
+		pj.transitions = trs
+	}
+}
+
+// purgeProject hard deletes the project id with everything that belongs to it.
+func (m *Memory) purgeProject(id string) {
+	delete(m.projects, id)
+
+	for _, st := range m.statuses {
+		if st.ProjectId == id {
+			m.purgeStatus(st.Id)
+		}
+	}
+	for _, t := range m.tasks {
+		if t.ProjectId == id {
+			m.purgeTask(t.Id)
+		}
+	}
+	for _, l := range m.labels {
+		if l.ProjectId != id {
+			continue
+		}
+		delete(m.labels, l.Id)
+		for _, ls := range m.taskLabels {
+			delete(ls, l.Id)
+		}
+	}
+	for _, inv := range m.invitations {
+		if inv.ProjectId == id {
+			delete(m.invitations, inv.Id)
+		}
+	}
+}
+
+// purgeAccount hard deletes the account id with the projects it owns.
+// Its comments and attachments are kept without their author.
+func (m *Memory) purgeAccount(id string) {
+	delete(m.accounts, id)
+
+	for _, pj := range m.projects {
+		if pj.OwnerId == id {
+			m.purgeProject(pj.Id)
+		}
+		delete(pj.roles, id)
+	}
+	for _, inv := range m.invitations {
+		if inv.InviterId == id || inv.InviteeId == id {
+			delete(m.invitations, inv.Id)
+		}
+	}
+	m.unassign(func(a memoryAssignment) bool { return a.accountId == id })
+	for _, c := range m.comments {
+		if c.AuthorId == id {
+			c.AuthorId = ""
+		}
+	}
+	for _, a := range m.attachments {
+		if a.UploaderId == id {
+			a.UploaderId = ""
+		}
+	}
+}
+
+// restoreProject restores the project pj with its statuses and tasks
+// that were deleted at the time at.
+func (m *Memory) restoreProject(pj *memoryProject, at, now time.Time) {
+	for _, st := range m.statuses {
+		if st.ProjectId == pj.Id && deletedWith(st.Deleted, st.DeletedAt, at) {
+			restoreStatus(st, now)
+		}
+	}
+	for _, t := range m.tasks {
+		if t.ProjectId == pj.Id && deletedWith(t.Deleted, t.DeletedAt, at) {
+			restoreTask(t, now)
+		}
+	}
+	pj.Deleted = false
+	pj.DeletedAt = nil
+	pj.Version++
+	pj.UpdatedAt = now
+}
+
+func restoreStatus(st *types.Status, now time.Time) {
+	st.Deleted = false
+	st.DeletedAt = nil
+	st.Version++
+	st.UpdatedAt = now
+}
+
+func restoreTask(t *types.Task, now time.Time) {
+	t.Deleted = false
+	t.DeletedAt = nil
+	t.Version++
+	t.UpdatedAt = now
+}
+
+// deletedWith reports whether a record was deleted at the time at,
+// i.e. together with the record deleted then.
+func deletedWith(deleted bool, deletedAt *time.Time, at time.Time) bool {
+	return deleted && deletedAt != nil && deletedAt.Equal(at)
+}
+
+// deletedLater orders records the most recently deleted first, then by id.
+func deletedLater(a, b *time.Time, aId, bId string) bool {
+	if a != nil && b != nil && !a.Equal(*b) {
+		return a.After(*b)
+	}
+	return aId < bId
+}
+
+// MemoryInvitations keeps invitations in a Memory.
+type MemoryInvitations struct {
+	*Memory
+}
+
+func (m *MemoryInvitations) ListByProject(ctx context.Context, pId string) ([]types.Invitation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.listInvitations(func(inv *types.Invitation) bool {
+		return inv.ProjectId == pId && !inv.Deleted
+	}), nil
+}
+
+func (m *MemoryInvitations) Pending(ctx context.Context, inviteeId string) ([]types.Invitation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	return m.listInvitations(func(inv *types.Invitation) bool {
+		return inv.InviteeId == inviteeId && inv.Status == types.InvitationPending && inv.ExpiresAt.After(now) && !inv.Deleted
+	}), nil
+}
+
+func (m *MemoryInvitations) Add(ctx context.Context, inv *types.Invitation) (*types.Invitation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.projects[inv.ProjectId]; !ok {
+		return nil, &Error{Err: ErrUnprocessable, Message: "references a non-existent entity (fk_invitations_projects)"}
+	}
+	if _, ok := m.accounts[inv.InviterId]; !ok {
+		return nil, &Error{Err: ErrUnprocessable, Message: "references a non-existent entity (fk_invitations_inviters)"}
+	}
+	for _, other := range m.invitations {
+		if other.Token == inv.Token {
+			return nil, &Error{Err: ErrConflict, Message: "already exists (invitations_token_key)"}
+		}
+	}
+
+	now := memoryNow()
+	res := *inv
+	res.Id = uuid.NewString()
+	res.InviteeId = ""
+	var invitee *memoryAccount
+	for _, acc := range m.accounts {
+		if strings.ToLower(acc.Email) != inv.Email || acc.Deleted {
+			continue
+		}
+		if invitee == nil || acc.CreatedAt.Before(invitee.CreatedAt) ||
+			(acc.CreatedAt.Equal(invitee.CreatedAt) && acc.Id < invitee.Id) {
+			invitee = acc
+		}
+	}
+	if invitee != nil {
+		res.InviteeId = invitee.Id
+	}
+	res.Status = types.InvitationPending
+	res.Deleted = false
+	res.CreatedAt = now
+	res.UpdatedAt = now
+	m.invitations[res.Id] = &res
+
+	out := res
+	return &out, nil
+}
+
+func (m *MemoryInvitations) Accept(ctx context.Context, token, inviteeId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inv := m.pendingInvitation(token, inviteeId)
+	if inv == nil || !inv.ExpiresAt.After(time.Now()) {
+		return ErrNotFound
+	}
+	inv.Status = types.InvitationAccepted
+	inv.UpdatedAt = memoryNow()
+	if pj, ok := m.projects[inv.ProjectId]; ok {
+		pj.roles[inviteeId] = inv.Role
+	}
+
+	return nil
+}
+
+func (m *MemoryInvitations) Decline(ctx context.Context, token, inviteeId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inv := m.pendingInvitation(token, inviteeId)
+	if inv == nil {
+		return ErrNotFound
+	}
+	inv.Status = types.InvitationDeclined
+	inv.UpdatedAt = memoryNow()
+
+	return nil
+}
+
+func (m *MemoryInvitations) Delete(ctx context.Context, pId, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inv, ok := m.invitations[id]
+	if !ok || inv.ProjectId != pId {
+		return ErrFailedToUpdate
+	}
+	inv.Deleted = true
+
+	return nil
+}
+
+// pendingInvitation returns the pending invitation of the account inviteeId
+// with the token, or nil if there's none.
+func (m *Memory) pendingInvitation(token, inviteeId string) *types.Invitation {
+	for _, inv := range m.invitations {
+		if inv.Token == token && inv.InviteeId == inviteeId && inv.Status == types.InvitationPending && !inv.Deleted {
+			return inv
+		}
+	}
+	return nil
+}
+
+// listInvitations returns invitations that match in order of creation.
+func (m *Memory) listInvitations(match func(*types.Invitation) bool) []types.Invitation {
+	invs := make([]types.Invitation, 0)
+	for _, inv := range m.invitations {
+		if match(inv) {
+			invs = append(invs, *inv)
+		}
+	}
+	sort.Slice(invs, func(i, j int) bool {
+		if !invs[i].CreatedAt.Equal(invs[j].CreatedAt) {
+			return invs[i].CreatedAt.Before(invs[j].CreatedAt)
+		}
+		return invs[i].Id < invs[j].Id
+	})
+
+	return invs
+}
+
+// memoryPosition is an item of a list ordered by position kept in memory,
+// e.g. a status of a project or a task of a status.
+type memoryPosition struct {
+	id        string
+	position  *int64
+	version   *int64
+	updatedAt *time.Time
+}
+
+// placeMemory is positionScope.place for the items of a scope kept in memory.
+//
+// Returned errors: ErrFailedValidation
+func placeMemory(scope []memoryPosition, id, afterId string, now time.Time) (int64, error) {
+	for rebalanced := false; ; rebalanced = true {
+		var (
+			prev  int64
+			found bool
+		)
+		for _, it := range scope {
+			if afterId != "" && it.id == afterId {
+				prev, found = *it.position, true
+			}
+		}
+		if afterId != "" && !found {
+			return 0, invalid("after_id", "must be in the same list")
+		}
+
+		var next sql.NullInt64
+		for _, it := range scope {
+			if it.id != id && *it.position > prev && (!next.Valid || *it.position < next.Int64) {
+				next = sql.NullInt64{Int64: *it.position, Valid: true}
+			}
+		}
+
+		if pos, ok := between(prev, next); ok || rebalanced {
+			return pos, nil
+		}
+		rebalanceMemory(scope, id, now)
+	}
+}
+
+// rebalanceMemory is positionScope.rebalance for the items of a scope kept in memory.
+func rebalanceMemory(scope []memoryPosition, id string, now time.Time) {
+	others := make([]memoryPosition, 0, len(scope))
+	for _, it := range scope {
+		if it.id != id {
+			others = append(others, it)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool {
+		if *others[i].position != *others[j].position {
+			return *others[i].position < *others[j].position
+		}
+		return others[i].id < others[j].id
+	})
+	for i, it := range others {
+		*it.position = int64(i+1) * positionGap
+		*it.version++
+		*it.updatedAt = now
+	}
+}
+
+// anySet reports whether any field of a patch is set.
+func anySet(set ...bool) bool {
+	for _, s := range set {
+		if s {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// memoryNow returns the current time with the precision of timestamps in Postgres.
+func memoryNow() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+// listMemory returns a page of items described by spec the same way list
+// does for rows of a table.
+//
+// Returned errors: ErrFailedValidation, ErrInternal
+func listMemory[T any](spec *listSpec[T], params *ListParams, items []T) (*types.Page[T], error) {
+	if params == nil {
+		params = new(ListParams)
+	}
+	q, err := parseList(spec, params)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &types.Page[T]{Items: make([]T, 0)}
+	for i := range items {
+		if !inTimes(spec, q.times, &items[i]) {
+			continue
+		}
+		if q.cursor != nil && compareKeys(q.keys, sortValues(spec, q.keys, &items[i]), q.cursor.Values) <= 0 {
+			continue
+		}
+		page.Items = append(page.Items, items[i])
+	}
+
+	sort.Slice(page.Items, func(i, j int) bool {
+		a := sortValues(spec, q.keys, &page.Items[i])
+		b := sortValues(spec, q.keys, &page.Items[j])
+		return compareKeys(q.keys, a, b) < 0
+	})
+
+	if err = paginate(q, spec, page); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// inTimes reports whether item passes all time filters.
+func inTimes[T any](spec *listSpec[T], filters []timeFilter, item *T) bool {
+	for _, f := range filters {
+		t, err := time.Parse(time.RFC3339Nano, spec.sorts[f.column].value(item))
+		if err != nil {
+			return false
+		}
+		if f.before && !t.Before(f.at) || !f.before && !t.After(f.at) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortValues[T any](spec *listSpec[T], keys []sortKey, item *T) []string {
+	values := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values = append(values, spec.sorts[k.field].value(item))
+	}
+	return values
+}
+
+// compareKeys compares values of sort keys in the order of keys.
+func compareKeys(keys []sortKey, a, b []string) int {
+	for i, k := range keys {
+		c := compareValues(a[i], b[i])
+		if k.desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareValues compares values of sort fields stored in cursors,
+// times and numbers are compared by their values.
+func compareValues(a, b string) int {
+	if ta, err := time.Parse(time.RFC3339Nano, a); err == nil {
+		if tb, err := time.Parse(time.RFC3339Nano, b); err == nil {
+			return ta.Compare(tb)
+		}
+	}
+	if na, err := strconv.ParseInt(a, 10, 64); err == nil {
+		if nb, err := strconv.ParseInt(b, 10, 64); err == nil {
+			switch {
+			case na < nb:
+				return -1
+			case na > nb:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(a, b)
+}
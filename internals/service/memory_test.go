@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// setupMemory returns a service that keeps its data in the returned memory,
+// tests put their fixtures into it with its Put methods.
+func setupMemory() (*Service, *Memory) {
+	m := NewMemory()
+	s := m.Service()
+	s.Secret = []byte("secret")
+	return s, m
+}
+
+func TestMemoryAccounts(t *testing.T) {
+	s, _ := setupMemory()
+	ctx := context.Background()
+
+	acc, err := s.AddAccount(ctx, &AddAccountInput{Email: "username@test.com", Name: "username", Password: "password"})
+	if err != nil {
+		t.Fatal(ErrFailedToPrepareTest, err)
+	}
+
+	tests := map[string]struct {
+		wantErr error
+		run     func() error
+	}{
+		"get": {
+			run: func() error {
+				got, err := s.GetAccountById(ctx, acc.Id)
+				if diff := cmp.Diff(acc, got); err == nil && diff != "" {
+					t.Fatalf("GetAccountById() mismatch (-want +got):\n%s", diff)
+				}
+				return err
+			},
+		},
+		"duplicate email": {
+			wantErr: ErrConflict,
+			run: func() error {
+				_, err := s.AddAccount(ctx, &AddAccountInput{Email: acc.Email, Name: "other", Password: "password"})
+				return err
+			},
+		},
+		"login": {
+			run: func() error {
+				_, err := s.Login(ctx, &LoginInput{Email: acc.Email, Password: "password"})
+				return err
+			},
+		},
+		"login with a wrong password": {
+			wantErr: ErrUnauthorized,
+			run: func() error {
+				_, err := s.Login(ctx, &LoginInput{Email: acc.Email, Password: "wrong password"})
+				return err
+			},
+		},
+		"update a changed account": {
+			wantErr: ErrPreconditionFailed,
+			run: func() error {
+				ctx := WithETag(WithAccountId(ctx, acc.Id), `"stale"`)
+				return s.UpdateAccount(ctx, &UpdateAccountInput{Id: acc.Id, Name: types.PatchOf("new")})
+			},
+		},
+		"update another account": {
+			wantErr: ErrForbidden,
+			run: func() error {
+				return s.UpdateAccount(WithAccountId(ctx, "other"), &UpdateAccountInput{Id: acc.Id, Name: types.PatchOf("new")})
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tt.run()
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("delete", func(t *testing.T) {
+		tokens, err := s.Login(ctx, &LoginInput{Email: acc.Email, Password: "password"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tag, err := ETag(acc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := WithETag(WithAccountId(ctx, acc.Id), tag)
+		if err = s.UpdateAccount(ctx, &UpdateAccountInput{Id: acc.Id, Name: types.PatchOf("new")}); err != nil {
+			t.Fatal(err)
+		}
+		if err = s.DeleteAccountById(ctx, acc.Id); !errors.Is(err, ErrPreconditionFailed) {
+			t.Fatalf("DeleteAccountById() with a stale entity tag error = %v, want %v", err, ErrPreconditionFailed)
+		}
+		updated, err := s.GetAccountById(ctx, acc.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tag, err = ETag(updated); err != nil {
+			t.Fatal(err)
+		}
+		if err = s.DeleteAccountById(WithETag(ctx, tag), acc.Id); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = s.GetAccountById(ctx, acc.Id); err != ErrNotFound {
+			t.Fatalf("GetAccountById() of a deleted account error = %v, want %v", err, ErrNotFound)
+		}
+		if _, err = s.Refresh(ctx, &RefreshInput{RefreshToken: tokens.RefreshToken}); err != ErrUnauthorized {
+			t.Fatalf("Refresh() of a deleted account error = %v, want %v", err, ErrUnauthorized)
+		}
+	})
+}
+
+func TestMemoryCascade(t *testing.T) {
+	s, m := setupMemory()
+	ctx := context.Background()
+
+	owner, err := s.AddAccount(ctx, &AddAccountInput{Email: "owner@test.com", Name: "owner", Password: "password"})
+	if err != nil {
+		t.Fatal(ErrFailedToPrepareTest, err)
+	}
+	ctx = WithAccountId(ctx, owner.Id)
+	pj, err := s.AddProject(ctx, &AddProjectInput{Name: "project", OwnerId: owner.Id})
+	if err != nil {
+		t.Fatal(ErrFailedToPrepareTest, err)
+	}
+	_, err = s.AddTask(ctx, &AddTaskInput{Name: "task", ProjectId: pj.Id, StatusId: pj.Statuses[0].Id, Start: "2024-01-01 00:00:00", End: "2024-01-02 00:00:00"})
+	if err != nil {
+		t.Fatal(ErrFailedToPrepareTest, err)
+	}
+	inv, err := s.AddInvitation(ctx, &AddInvitationInput{ProjectId: pj.Id, Email: "Newcomer@Test.com"})
+	if err != nil {
+		t.Fatal(ErrFailedToPrepareTest, err)
+	}
+
+	t.Run("claimed invitation", func(t *testing.T) {
+		acc, err := s.AddAccount(context.Background(), &AddAccountInput{Email: "newcomer@test.com", Name: "newcomer", Password: "password"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(acc.Id, m.invitations[inv.Id].InviteeId); diff != "" {
+			t.Fatalf("AddAccount() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("deleted account", func(t *testing.T) {
+		if err := s.DeleteAccountById(ctx, owner.Id); err != nil {
+			t.Fatal(err)
+		}
+		var visible int
+		for _, st := range m.statuses {
+			if !st.Deleted {
+				visible++
+			}
+		}
+		for _, t := range m.tasks {
+			if !t.Deleted {
+				visible++
+			}
+		}
+		if visible != 0 || !m.projects[pj.Id].Deleted {
+			t.Fatalf("DeleteAccountById() left the project with %d statuses and tasks", visible)
+		}
+	})
+}
+
+func TestListMemory(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Ids are UUIDs that differ in the last digit, pages list only the digit.
+	const idPrefix = "00000000-0000-0000-0000-00000000000"
+	accs := []types.Account{
+		{Id: idPrefix + "1", Name: "b", CreatedAt: created},
+		{Id: idPrefix + "2", Name: "a", CreatedAt: created.Add(time.Second)},
+		{Id: idPrefix + "3", Name: "c", CreatedAt: created.Add(1500 * time.Millisecond)},
+		{Id: idPrefix + "4", Name: "a", CreatedAt: created.Add(2 * time.Second)},
+	}
+
+	tests := map[string]struct {
+		wantErr error
+		params  *ListParams
+		want    [][]string
+	}{
+		"default sort": {
+			params: &ListParams{Limit: 3},
+			want:   [][]string{{"1", "2", "3"}, {"4"}},
+		},
+		"descending sort with ties": {
+			params: &ListParams{Sort: "-name", Limit: 2},
+			want:   [][]string{{"3", "1"}, {"2", "4"}},
+		},
+		"time filter": {
+			params: &ListParams{CreatedAfter: "2024-01-01T00:00:01Z"},
+			want:   [][]string{{"3", "4"}},
+		},
+		"task filter": {
+			params:  &ListParams{Priority: "high"},
+			wantErr: ErrFailedValidation,
+			want:    [][]string{{}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			params := *tt.params
+			for i, want := range tt.want {
+				page, err := listMemory(accountList, &params, accs)
+				if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+					t.Fatalf("listMemory() mismatch (-want +got):\n%s", diff)
+				}
+				got := make([]string, 0)
+				for _, acc := range pageItems(page) {
+					got = append(got, strings.TrimPrefix(acc.Id, idPrefix))
+				}
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Fatalf("listMemory() page %d mismatch (-want +got):\n%s", i, diff)
+				}
+				if err != nil {
+					return
+				}
+				if (page.NextCursor != "") != (i < len(tt.want)-1) {
+					t.Fatalf("listMemory() page %d next cursor = %q", i, page.NextCursor)
+				}
+				params.Cursor = page.NextCursor
+			}
+		})
+	}
+}
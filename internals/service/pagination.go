@@ -0,0 +1,386 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+	// DefaultSort is used when ListParams.Sort is empty.
+	DefaultSort = "created_at"
+)
+
+// ListParams are the pagination, sorting and filtering parameters
+// accepted by every list method.
+//
+// Sort is a comma separated list of fields, a field prefixed with "-"
+// is sorted in descending order. Dates are in RFC 3339 format. Priority
+// is a comma separated list of priorities.
+type ListParams struct {
+	Cursor        string `query:"cursor" json:"cursor,omitempty"`
+	Sort          string `query:"sort" json:"sort,omitempty"`
+	CreatedAfter  string `query:"created_after" json:"created_after,omitempty"`
+	CreatedBefore string `query:"created_before" json:"created_before,omitempty"`
+	UpdatedAfter  string `query:"updated_after" json:"updated_after,omitempty"`
+	UpdatedBefore string `query:"updated_before" json:"updated_before,omitempty"`
+	StatusId      string `query:"status_id" json:"status_id,omitempty"`
+	Priority      string `query:"priority" json:"priority,omitempty"`
+	LabelId       string `query:"label_id" json:"label_id,omitempty"`
+	Limit         int    `query:"limit" json:"limit,omitempty"`
+}
+
+// taskFilter returns the name of a set filter that only tasks support.
+func (p *ListParams) taskFilter() string {
+	switch {
+	case p.StatusId != "":
+		return "status_id"
+	case p.Priority != "":
+		return "priority"
+	case p.LabelId != "":
+		return "label_id"
+	}
+	return ""
+}
+
+// sortKind is the type of the values of a sort field.
+type sortKind int
+
+const (
+	sortText sortKind = iota
+	sortUUID
+	sortInt
+	sortTime
+)
+
+// valid reports whether v, a value of a cursor, is of the kind.
+func (k sortKind) valid(v string) bool {
+	var err error
+	switch k {
+	case sortUUID:
+		_, err = uuid.Parse(v)
+	case sortInt:
+		_, err = strconv.ParseInt(v, 10, 64)
+	case sortTime:
+		_, err = time.Parse(time.RFC3339Nano, v)
+	}
+	return err == nil
+}
+
+// sortField is a field a list can be sorted by.
+type sortField[T any] struct {
+	column string
+	kind   sortKind
+	// value returns the value of the field of an item that is stored in a cursor.
+	value func(*T) string
+}
+
+// listSpec describes how items of a resource are listed.
+type listSpec[T any] struct {
+	// query selects the columns read by scan without any conditions.
+	query string
+	sorts map[string]sortField[T]
+	// defaultSort is used instead of DefaultSort when ListParams.Sort is empty.
+	defaultSort string
+	// filter returns conditions of the filters only the resource supports,
+	// arg adds an argument of a condition and returns its placeholder.
+	filter func(params *ListParams, arg func(any) string) ([]string, error)
+	scan   func(scanner, *T) error
+}
+
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+// cursor is a position in a list after which the next page starts.
+type cursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+// listQuery is the validated form of ListParams.
+type listQuery struct {
+	limit int
+	keys  []sortKey
+	// sort is the normalized sort stored in cursors.
+	sort   string
+	times  []timeFilter
+	cursor *cursor
+}
+
+// timeFilter keeps items with column after (or before) at.
+type timeFilter struct {
+	column string
+	before bool
+	at     time.Time
+}
+
+// parseList validates params against spec.
+//
+// Returned errors: ErrFailedValidation
+func parseList[T any](spec *listSpec[T], params *ListParams) (*listQuery, error) {
+	q := &listQuery{limit: params.Limit}
+	if q.limit == 0 {
+		q.limit = DefaultPageLimit
+	}
+	if q.limit < 0 || q.limit > MaxPageLimit {
+		return nil, invalid("limit", fmt.Sprintf("must be between 1 and %d", MaxPageLimit))
+	}
+
+	sort := params.Sort
+	if sort == "" {
+		sort = spec.defaultSort
+	}
+	keys, err := parseSort(sort, spec.sorts)
+	if err != nil {
+		return nil, err
+	}
+	q.keys = keys
+	q.sort = formatSort(keys)
+
+	filters := []struct {
+		field, value, column string
+		before               bool
+	}{
+		{"created_after", params.CreatedAfter, "created_at", false},
+		{"created_before", params.CreatedBefore, "created_at", true},
+		{"updated_after", params.UpdatedAfter, "updated_at", false},
+		{"updated_before", params.UpdatedBefore, "updated_at", true},
+	}
+	for _, f := range filters {
+		if f.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, f.value)
+		if err != nil {
+			return nil, invalid(f.field, "must be in RFC 3339 format")
+		}
+		q.times = append(q.times, timeFilter{column: f.column, before: f.before, at: t.UTC()})
+	}
+
+	if spec.filter == nil {
+		if f := params.taskFilter(); f != "" {
+			return nil, invalid(f, "is not supported by this list")
+		}
+	}
+
+	if params.Cursor != "" {
+		cur, err := decodeCursor(params.Cursor)
+		if err != nil || len(cur.Values) != len(keys) {
+			return nil, invalid("cursor", "is malformed")
+		}
+		if cur.Sort != q.sort {
+			return nil, invalid("cursor", "was issued for another sort")
+		}
+		for i, k := range keys {
+			if !spec.sorts[k.field].kind.valid(cur.Values[i]) {
+				return nil, invalid("cursor", "is malformed")
+			}
+		}
+		q.cursor = cur
+	}
+
+	return q, nil
+}
+
+// list returns a page of items described by spec that match where conditions,
+// args are the arguments of the conditions.
+//
+// Returned errors: ErrFailedValidation, ErrInternal
+func list[T any](ctx context.Context, db querier, spec *listSpec[T], params *ListParams, where []string, args []any) (*types.Page[T], error) {
+	if params == nil {
+		params = new(ListParams)
+	}
+	q, err := parseList(spec, params)
+	if err != nil {
+		return nil, err
+	}
+
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	for _, f := range q.times {
+		op := " > "
+		if f.before {
+			op = " < "
+		}
+		where = append(where, f.column+op+arg(f.at))
+	}
+
+	if spec.filter != nil {
+		conds, err := spec.filter(params, arg)
+		if err != nil {
+			return nil, err
+		}
+		where = append(where, conds...)
+	}
+
+	if q.cursor != nil {
+		// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ...
+		or := make([]string, 0, len(q.keys))
+		for i, k := range q.keys {
+			and := make([]string, 0, i+1)
+			for j := 0; j < i; j++ {
+				and = append(and, spec.sorts[q.keys[j].field].column+" = "+arg(q.cursor.Values[j]))
+			}
+			op := ">"
+			if k.desc {
+				op = "<"
+			}
+			and = append(and, spec.sorts[k.field].column+" "+op+" "+arg(q.cursor.Values[i]))
+			or = append(or, "("+strings.Join(and, " AND ")+")")
+		}
+		where = append(where, "("+strings.Join(or, " OR ")+")")
+	}
+
+	order := make([]string, 0, len(q.keys))
+	for _, k := range q.keys {
+		dir := "ASC"
+		if k.desc {
+			dir = "DESC"
+		}
+		order = append(order, spec.sorts[k.field].column+" "+dir)
+	}
+
+	query := spec.query
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY " + strings.Join(order, ", ") + " LIMIT " + arg(q.limit+1)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	page := &types.Page[T]{Items: make([]T, 0)}
+	for rows.Next() {
+		var item T
+		if err := spec.scan(rows, &item); err != nil {
+			return nil, dbError(err)
+		}
+		page.Items = append(page.Items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	if err = paginate(q, spec, page); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// paginate cuts page to the limit of q and sets its NextCursor
+// if page has more items than the limit.
+//
+// Returned errors: ErrInternal
+func paginate[T any](q *listQuery, spec *listSpec[T], page *types.Page[T]) error {
+	if len(page.Items) <= q.limit {
+		return nil
+	}
+
+	page.Items = page.Items[:q.limit]
+	last := &page.Items[q.limit-1]
+	cur := cursor{Sort: q.sort, Values: make([]string, 0, len(q.keys))}
+	for _, k := range q.keys {
+		cur.Values = append(cur.Values, spec.sorts[k.field].value(last))
+	}
+
+	var err error
+	page.NextCursor, err = encodeCursor(&cur)
+	if err != nil {
+		return ErrInternal
+	}
+
+	return nil
+}
+
+// parseSort parses a sort like "created_at,-name" into sort keys.
+// The "id" key is appended if missing so the order is always total.
+//
+// Returned errors: ErrFailedValidation
+func parseSort[T any](sort string, sorts map[string]sortField[T]) ([]sortKey, error) {
+	if sort == "" {
+		sort = DefaultSort
+	}
+
+	keys := make([]sortKey, 0)
+	seen := make(map[string]bool)
+	hasId := false
+	for _, f := range strings.Split(sort, ",") {
+		k := sortKey{field: strings.TrimSpace(f)}
+		if strings.HasPrefix(k.field, "-") {
+			k.field = k.field[1:]
+			k.desc = true
+		}
+		if _, ok := sorts[k.field]; !ok {
+			return nil, invalid("sort", fmt.Sprintf("can't sort by %q", k.field))
+		}
+		if seen[k.field] {
+			return nil, invalid("sort", fmt.Sprintf("%q is repeated", k.field))
+		}
+		seen[k.field] = true
+		keys = append(keys, k)
+		if k.field == "id" {
+			hasId = true
+			break
+		}
+	}
+	if !hasId {
+		keys = append(keys, sortKey{field: "id"})
+	}
+
+	return keys, nil
+}
+
+func formatSort(keys []sortKey) string {
+	fields := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if k.desc {
+			fields = append(fields, "-"+k.field)
+		} else {
+			fields = append(fields, k.field)
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+func encodeCursor(c *cursor) (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	c := new(cursor)
+	if err = json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// timeValue formats t to be stored in a cursor.
+func timeValue(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
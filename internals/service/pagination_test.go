@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		want    []sortKey
+		wantErr error
+	}{
+		"default": {
+			input: "",
+			want:  []sortKey{{field: "created_at"}, {field: "id"}},
+		},
+		"mixed directions": {
+			input: "-created_at,name",
+			want:  []sortKey{{field: "created_at", desc: true}, {field: "name"}, {field: "id"}},
+		},
+		"by id": {
+			input: "-id",
+			want:  []sortKey{{field: "id", desc: true}},
+		},
+		"unknown field": {
+			input:   "password_hash",
+			wantErr: ErrFailedValidation,
+		},
+		"repeated field": {
+			input:   "name,-name",
+			wantErr: ErrFailedValidation,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseSort(tt.input, accountList.sorts)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("parseSort() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(sortKey{})); diff != "" {
+				t.Fatalf("parseSort() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	want := &cursor{Sort: "-name,id", Values: []string{"name", uuid.NewString()}}
+	s, err := encodeCursor(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodeCursor(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("decodeCursor() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err = decodeCursor("not a cursor"); err == nil {
+		t.Fatal("decodeCursor() of a malformed cursor didn't fail")
+	}
+}
+
+func TestParseListCursor(t *testing.T) {
+	tests := map[string]struct {
+		cursor  cursor
+		wantErr error
+	}{
+		"valid values": {
+			cursor: cursor{Sort: "created_at,position,id", Values: []string{timeValue(time.Now()), "1024", uuid.NewString()}},
+		},
+		"non-timestamp created_at": {
+			cursor:  cursor{Sort: "created_at,position,id", Values: []string{"yesterday", "1024", uuid.NewString()}},
+			wantErr: ErrFailedValidation,
+		},
+		"non-integer position": {
+			cursor:  cursor{Sort: "created_at,position,id", Values: []string{timeValue(time.Now()), "1e3", uuid.NewString()}},
+			wantErr: ErrFailedValidation,
+		},
+		"non-UUID id": {
+			cursor:  cursor{Sort: "created_at,position,id", Values: []string{timeValue(time.Now()), "1024", "1' OR '1'='1"}},
+			wantErr: ErrFailedValidation,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cur, err := encodeCursor(&tt.cursor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = parseList(taskList, &ListParams{Sort: "created_at,position", Cursor: cur})
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("parseList() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestListPages(t *testing.T) {
+	s, m := setupMemory()
+
+	accs := []types.Account{
+		{Id: uuid.NewString(), Name: "c", Email: "c@test.com"},
+		{Id: uuid.NewString(), Name: "b", Email: "b@test.com"},
+		{Id: uuid.NewString(), Name: "a", Email: "a@test.com"},
+	}
+	for _, acc := range accs {
+		m.PutAccount(acc)
+	}
+
+	ctx := context.Background()
+	params := &ListParams{Sort: "-name", Limit: 2}
+	got := make([]types.Account, 0)
+	for i := 0; i < len(accs); i++ {
+		page, err := s.GetAllAccounts(ctx, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, page.Items...)
+		if page.NextCursor == "" {
+			break
+		}
+		params.Cursor = page.NextCursor
+	}
+	if diff := cmp.Diff(accs, got, cmpopts.IgnoreFields(types.Account{}, "CreatedAt", "UpdatedAt", "Version")); diff != "" {
+		t.Fatalf("GetAllAccounts() mismatch (-want +got):\n%s", diff)
+	}
+
+	_, err := s.GetAllAccounts(ctx, &ListParams{Sort: "name", Cursor: params.Cursor})
+	if diff := cmp.Diff(ErrFailedValidation, err, cmpopts.EquateErrors()); diff != "" {
+		t.Fatalf("GetAllAccounts() mismatch (-want +got):\n%s", diff)
+	}
+}
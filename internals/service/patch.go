@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/danblok/pm/internals/types"
+)
+
+// update builds an UPDATE of the columns a patch sets.
+type update struct {
+	sets []string
+	args []any
+}
+
+// set makes the update set the column to v.
+func (u *update) set(column string, v any) {
+	u.setExpr(column, "%s", v)
+}
+
+// setExpr makes the update set the column to expr,
+// every %s in expr is replaced with the placeholder of v.
+func (u *update) setExpr(column, expr string, v any) {
+	u.args = append(u.args, v)
+	u.sets = append(u.sets, column+"="+strings.ReplaceAll(expr, "%s", fmt.Sprintf("$%d", len(u.args))))
+}
+
+// touch makes the update change updated_at of the row, so a patch that only
+// changes related rows, e.g. labels of a task, still updates it.
+func (u *update) touch() {
+	u.sets = append(u.sets, "updated_at=now()")
+}
+
+// exec updates the row id of the table unless the patch is empty.
+//
+// Returned errors: ErrFailedToUpdate, ErrFailedValidation, ErrConflict, ErrUnprocessable, ErrConcurrentUpdate, ErrInternal
+func (u *update) exec(ctx context.Context, q querier, table, id string) error {
+	if len(u.sets) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id::text=$%d", table, strings.Join(u.sets, ", "), len(u.args)+1)
+	res, err := q.ExecContext(ctx, query, append(u.args, id)...)
+	if err != nil {
+		return dbError(err)
+	}
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if ra < 1 {
+		return ErrFailedToUpdate
+	}
+
+	return nil
+}
+
+// notNull returns ErrFailedValidation if the field of a patch is null.
+func notNull[T any](field string, p types.Patch[T]) error {
+	if p.Null {
+		return invalid(field, "must not be null")
+	}
+	return nil
+}
+
+// notEmpty returns ErrFailedValidation if the field of a patch is null or empty.
+func notEmpty(field string, p types.Patch[string]) error {
+	if p.Set && (p.Null || p.Value == "") {
+		return invalid(field, "must not be empty")
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/danblok/pm/internals/types"
+)
+
+// Permission is an action in a project that requires a certain role.
+type Permission int
+
+const (
+	PermUpdateProject Permission = iota
+	PermDeleteProject
+	PermManageContributors
+	PermManageStatuses
+	PermManageTasks
+	PermManageLabels
+	PermComment
+	PermModerateComments
+)
+
+var rolePermissions = map[types.Role][]Permission{
+	types.RoleOwner:      {PermUpdateProject, PermDeleteProject, PermManageContributors, PermManageStatuses, PermManageTasks, PermManageLabels, PermComment, PermModerateComments},
+	types.RoleMaintainer: {PermUpdateProject, PermManageContributors, PermManageStatuses, PermManageTasks, PermManageLabels, PermComment, PermModerateComments},
+	types.RoleMember:     {PermManageTasks, PermComment},
+	types.RoleViewer:     {PermComment},
+}
+
+// Can reports whether the role grants the permission.
+func Can(role types.Role, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validContributorRole reports whether the role can be assigned to a contributor.
+func validContributorRole(role types.Role) bool {
+	return role == types.RoleMaintainer || role == types.RoleMember || role == types.RoleViewer
+}
+
+// Returned errors: ErrInternal, ErrNotFound
+func (s *Service) roleInProject(ctx context.Context, pId, aId string) (types.Role, error) {
+	return s.projects().Role(ctx, pId, aId)
+}
+
+// authorize makes sure that the caller has the permission in the project.
+//
+// Returned errors: ErrInternal, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) authorize(ctx context.Context, pId string, perm Permission) error {
+	caller, err := callerId(ctx)
+	if err != nil {
+		return err
+	}
+
+	role, err := s.roleInProject(ctx, pId, caller)
+	if err != nil {
+		return err
+	}
+	if !Can(role, perm) {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// Returned errors: ErrInternal, ErrNotFound
+func (s *Service) statusProjectId(ctx context.Context, sId string) (string, error) {
+	return s.statuses().ProjectId(ctx, sId)
+}
+
+// Returned errors: ErrInternal, ErrNotFound
+func (s *Service) taskProjectId(ctx context.Context, tId string) (string, error) {
+	return s.tasks().ProjectId(ctx, tId)
+}
+
+// projectIdOf returns the project_id the query selects by the id.
+//
+// Returned errors: ErrInternal, ErrNotFound
+func projectIdOf(ctx context.Context, db querier, query, id string) (string, error) {
+	var pId string
+	err := db.QueryRowContext(ctx, query, id).Scan(&pId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", dbError(err)
+	}
+
+	return pId, nil
+}
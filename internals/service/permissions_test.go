@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+)
+
+func TestCan(t *testing.T) {
+	tests := map[string]struct {
+		role types.Role
+		perm Permission
+		want bool
+	}{
+		"owner deletes project": {
+			role: types.RoleOwner,
+			perm: PermDeleteProject,
+			want: true,
+		},
+		"maintainer deletes project": {
+			role: types.RoleMaintainer,
+			perm: PermDeleteProject,
+			want: false,
+		},
+		"maintainer manages statuses": {
+			role: types.RoleMaintainer,
+			perm: PermManageStatuses,
+			want: true,
+		},
+		"member manages statuses": {
+			role: types.RoleMember,
+			perm: PermManageStatuses,
+			want: false,
+		},
+		"member manages tasks": {
+			role: types.RoleMember,
+			perm: PermManageTasks,
+			want: true,
+		},
+		"viewer manages tasks": {
+			role: types.RoleViewer,
+			perm: PermManageTasks,
+			want: false,
+		},
+		"maintainer manages labels": {
+			role: types.RoleMaintainer,
+			perm: PermManageLabels,
+			want: true,
+		},
+		"member manages labels": {
+			role: types.RoleMember,
+			perm: PermManageLabels,
+			want: false,
+		},
+		"viewer comments": {
+			role: types.RoleViewer,
+			perm: PermComment,
+			want: true,
+		},
+		"member moderates comments": {
+			role: types.RoleMember,
+			perm: PermModerateComments,
+			want: false,
+		},
+		"stranger manages tasks": {
+			role: "",
+			perm: PermManageTasks,
+			want: false,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := Can(tt.role, tt.perm)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("Can() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	viewer := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "viewer",
+		Email: "viewer@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	tests := map[string]struct {
+		wantErr   error
+		caller    string
+		projectId string
+		perm      Permission
+	}{
+		"owner": {
+			caller:    owner.Id,
+			projectId: project.Id,
+			perm:      PermDeleteProject,
+			wantErr:   nil,
+		},
+		"viewer": {
+			caller:    viewer.Id,
+			projectId: project.Id,
+			perm:      PermManageTasks,
+			wantErr:   ErrForbidden,
+		},
+		"stranger": {
+			caller:    uuid.NewString(),
+			projectId: project.Id,
+			perm:      PermManageTasks,
+			wantErr:   ErrForbidden,
+		},
+		"anonymous": {
+			caller:    "",
+			projectId: project.Id,
+			perm:      PermManageTasks,
+			wantErr:   ErrUnauthorized,
+		},
+		"non-existent project": {
+			caller:    owner.Id,
+			projectId: uuid.NewString(),
+			perm:      PermManageTasks,
+			wantErr:   ErrNotFound,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutAccount(viewer)
+		m.PutProject(project)
+		m.PutContributor(project.Id, viewer.Id, types.RoleViewer)
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), tt.caller)
+			err := s.authorize(ctx, tt.projectId, tt.perm)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("authorize() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
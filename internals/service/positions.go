@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// positionGap is the distance between positions of neighbours after they are
+// added or rebalanced, so items can be moved between them without renumbering.
+const positionGap = 1 << 16
+
+var positionGapSQL = strconv.Itoa(positionGap)
+
+// MoveStatusInput places the status right after the status AfterId,
+// an empty AfterId places it first.
+type MoveStatusInput struct {
+	Id      string `param:"id"`
+	AfterId string `json:"after_id,omitempty"`
+}
+
+// MoveTaskInput places the task into the status StatusId right after the
+// task AfterId, an empty AfterId places it first. An empty StatusId keeps
+// the task in its status.
+type MoveTaskInput struct {
+	Id       string `param:"id"`
+	StatusId string `json:"status_id,omitempty"`
+	AfterId  string `json:"after_id,omitempty"`
+}
+
+// Returned errors: ErrFailedValidation, ErrInternal, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) MoveStatus(ctx context.Context, input *MoveStatusInput) error {
+	if _, err := uuid.Parse(input.Id); err != nil {
+		return invalid("id", "must be a valid UUID")
+	}
+	if input.AfterId != "" {
+		if _, err := uuid.Parse(input.AfterId); err != nil {
+			return invalid("after_id", "must be a valid UUID")
+		}
+	}
+	if input.AfterId == input.Id {
+		return invalid("after_id", "must not be the status itself")
+	}
+	pId, err := s.statusProjectId(ctx, input.Id)
+	if err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, pId, PermManageStatuses); err != nil {
+		return err
+	}
+
+	return s.statuses().Move(ctx, pId, input)
+}
+
+// The status must be a status of the task's project the workflow allows to move to
+// and, in projects with StrictWipLimits, below its WIP limit.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate, ErrUnprocessable, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) MoveTask(ctx context.Context, input *MoveTaskInput) error {
+	if _, err := uuid.Parse(input.Id); err != nil {
+		return invalid("id", "must be a valid UUID")
+	}
+	if input.StatusId != "" {
+		if _, err := uuid.Parse(input.StatusId); err != nil {
+			return invalid("status_id", "must be a valid UUID")
+		}
+	}
+	if input.AfterId != "" {
+		if _, err := uuid.Parse(input.AfterId); err != nil {
+			return invalid("after_id", "must be a valid UUID")
+		}
+	}
+	if input.AfterId == input.Id {
+		return invalid("after_id", "must not be the task itself")
+	}
+	pId, err := s.taskProjectId(ctx, input.Id)
+	if err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, pId, PermManageTasks); err != nil {
+		return err
+	}
+
+	return s.tasks().Move(ctx, pId, input)
+}
+
+func (r *PostgresStatuses) Move(ctx context.Context, pId string, input *MoveStatusInput) error {
+	return transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		if err := lockProject(ctx, tx, pId); err != nil {
+			return err
+		}
+
+		scope := &positionScope{table: "statuses", column: "project_id", value: pId}
+		pos, err := scope.place(ctx, tx, input.Id, input.AfterId)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE statuses SET position=$1, updated_at=now() WHERE id=$2", pos, input.Id)
+		if err != nil {
+			return dbError(err)
+		}
+
+		return nil
+	})
+}
+
+func (r *PostgresTasks) Move(ctx context.Context, pId string, input *MoveTaskInput) error {
+	return transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		if err := lockProject(ctx, tx, pId); err != nil {
+			return err
+		}
+
+		sId := input.StatusId
+		if sId == "" {
+			err := tx.QueryRowContext(ctx, "SELECT status_id FROM tasks WHERE id=$1", input.Id).Scan(&sId)
+			if err != nil {
+				return dbError(err)
+			}
+		} else {
+			if err := checkStatus(ctx, tx, pId, sId); err != nil {
+				return err
+			}
+			if err := checkTransition(ctx, tx, pId, input.Id, sId); err != nil {
+				return err
+			}
+			if err := checkWipLimit(ctx, tx, sId, input.Id); err != nil {
+				return err
+			}
+		}
+
+		scope := &positionScope{table: "tasks", column: "status_id", value: sId}
+		pos, err := scope.place(ctx, tx, input.Id, input.AfterId)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE tasks SET status_id=$1, position=$2, updated_at=now() WHERE id=$3", sId, pos, input.Id)
+		if err != nil {
+			return dbError(err)
+		}
+
+		return nil
+	})
+}
+
+// positionScope is a list of ordered rows of table with column equal to value,
+// e.g. statuses of a project or tasks of a status.
+type positionScope struct {
+	table  string
+	column string
+	value  string
+}
+
+// place returns the position for the row id right after the row afterId,
+// or before all rows if afterId is empty. Rows are rebalanced when there's
+// no room between the neighbours. Callers must lock the scope.
+//
+// Returned errors: ErrFailedValidation, ErrConcurrentUpdate, ErrInternal
+func (ps *positionScope) place(ctx context.Context, tx *sql.Tx, id, afterId string) (int64, error) {
+	for rebalanced := false; ; rebalanced = true {
+		var prev int64
+		if afterId != "" {
+			query := "SELECT position FROM " + ps.table + " WHERE id=$1 AND " + ps.column + "=$2 AND deleted=false"
+			err := tx.QueryRowContext(ctx, query, afterId, ps.value).Scan(&prev)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return 0, invalid("after_id", "must be in the same list")
+				}
+				return 0, dbError(err)
+			}
+		}
+
+		var next sql.NullInt64
+		query := "SELECT MIN(position) FROM " + ps.table + " WHERE " + ps.column + "=$1 AND deleted=false AND id<>$2 AND position>$3"
+		err := tx.QueryRowContext(ctx, query, ps.value, id, prev).Scan(&next)
+		if err != nil {
+			return 0, dbError(err)
+		}
+
+		if pos, ok := between(prev, next); ok || rebalanced {
+			return pos, nil
+		}
+		if err = ps.rebalance(ctx, tx, id); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// between returns the position in the middle of prev and next,
+// ok is false if there's no room between them.
+func between(prev int64, next sql.NullInt64) (pos int64, ok bool) {
+	if !next.Valid {
+		return prev + positionGap, true
+	}
+	pos = prev + (next.Int64-prev)/2
+	return pos, pos > prev && pos < next.Int64
+}
+
+// rebalance renumbers the rows of the scope except the row id with positionGap
+// between them keeping their order.
+//
+// Returned errors: ErrConcurrentUpdate, ErrInternal
+func (ps *positionScope) rebalance(ctx context.Context, tx *sql.Tx, id string) error {
+	query := `UPDATE ` + ps.table + ` r SET position=o.rn * ` + positionGapSQL + `
+		FROM (SELECT id, row_number() OVER (ORDER BY position, id) AS rn
+			FROM ` + ps.table + ` WHERE ` + ps.column + `=$1 AND deleted=false AND id<>$2) o
+		WHERE r.id=o.id`
+	_, err := tx.ExecContext(ctx, query, ps.value, id)
+	if err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
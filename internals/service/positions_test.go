@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+)
+
+func TestBetween(t *testing.T) {
+	tests := map[string]struct {
+		prev   int64
+		next   sql.NullInt64
+		want   int64
+		wantOk bool
+	}{
+		"last": {
+			prev:   positionGap,
+			want:   2 * positionGap,
+			wantOk: true,
+		},
+		"first of empty": {
+			prev:   0,
+			want:   positionGap,
+			wantOk: true,
+		},
+		"middle": {
+			prev:   positionGap,
+			next:   sql.NullInt64{Int64: 2 * positionGap, Valid: true},
+			want:   positionGap + positionGap/2,
+			wantOk: true,
+		},
+		"no room": {
+			prev:   1,
+			next:   sql.NullInt64{Int64: 2, Valid: true},
+			wantOk: false,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := between(tt.prev, tt.next)
+			if ok != tt.wantOk {
+				t.Fatalf("between() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && got != tt.want {
+				t.Fatalf("between() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoveTask(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	other := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "other",
+		OwnerId: owner.Id,
+	}
+	todoId := uuid.NewString()
+	doneId := uuid.NewString()
+	otherStatusId := uuid.NewString()
+	// Positions 1 and 2 leave no room between a and b, so moving c between
+	// them rebalances the status.
+	a := types.Task{Id: uuid.NewString(), Name: "a", ProjectId: project.Id, StatusId: todoId, Position: 1}
+	b := types.Task{Id: uuid.NewString(), Name: "b", ProjectId: project.Id, StatusId: todoId, Position: 2}
+	c := types.Task{Id: uuid.NewString(), Name: "c", ProjectId: project.Id, StatusId: todoId, Position: 3}
+	tests := map[string]struct {
+		wantErr  error
+		input    *MoveTaskInput
+		wantTodo []string
+		wantDone []string
+	}{
+		"invalid id": {
+			input:   &MoveTaskInput{Id: "invalid-id"},
+			wantErr: ErrFailedValidation,
+		},
+		"after itself": {
+			input:   &MoveTaskInput{Id: a.Id, AfterId: a.Id},
+			wantErr: ErrFailedValidation,
+		},
+		"status of another project": {
+			input:   &MoveTaskInput{Id: a.Id, StatusId: otherStatusId},
+			wantErr: ErrFailedValidation,
+		},
+		"after a task of another status": {
+			input:   &MoveTaskInput{Id: a.Id, StatusId: doneId, AfterId: b.Id},
+			wantErr: ErrFailedValidation,
+		},
+		"non-existent": {
+			input:   &MoveTaskInput{Id: uuid.NewString()},
+			wantErr: ErrNotFound,
+		},
+		"succsessfull move to the top": {
+			input:    &MoveTaskInput{Id: c.Id},
+			wantTodo: []string{c.Id, a.Id, b.Id},
+			wantDone: []string{},
+		},
+		"succsessfull move with rebalance": {
+			input:    &MoveTaskInput{Id: c.Id, AfterId: a.Id},
+			wantTodo: []string{a.Id, c.Id, b.Id},
+			wantDone: []string{},
+		},
+		"succsessfull move to another status": {
+			input:    &MoveTaskInput{Id: a.Id, StatusId: doneId},
+			wantTodo: []string{b.Id, c.Id},
+			wantDone: []string{a.Id},
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutProject(other)
+		m.PutStatus(types.Status{Id: todoId, Name: "todo", ProjectId: project.Id})
+		m.PutStatus(types.Status{Id: doneId, Name: "done", ProjectId: project.Id})
+		m.PutStatus(types.Status{Id: otherStatusId, Name: "todo", ProjectId: other.Id})
+		for _, tk := range []types.Task{a, b, c} {
+			m.PutTask(tk)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), owner.Id)
+			err := s.MoveTask(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("MoveTask() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+
+			for sId, want := range map[string][]string{todoId: tt.wantTodo, doneId: tt.wantDone} {
+				got, err := s.GetTasksOfProjectByStatusId(ctx, project.Id, sId, &ListParams{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids := make([]string, 0)
+				for _, tk := range got.Items {
+					ids = append(ids, tk.Id)
+				}
+				if diff := cmp.Diff(want, ids); diff != "" {
+					t.Fatalf("MoveTask() mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
@@ -7,139 +7,252 @@ import (
 
 	"github.com/danblok/pm/internals/types"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// Projects start with statuses of the workflow named Workflow,
+// or of the default workflow if it's empty.
 type AddProjectInput struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	OwnerId     string `json:"owner_id"`
+	Workflow    string `json:"workflow,omitempty"`
 }
 
+// UpdateProjectInput is a JSON merge patch of a project,
+// a null description clears it.
 type UpdateProjectInput struct {
-	Id          string `param:"id"`
-	Name        string `json:"name,omitempty"`
-	Description string `json:"description,omitempty"`
+	Id                 string              `param:"id"`
+	Name               types.Patch[string] `json:"name" swaggertype:"string"`
+	Description        types.Patch[string] `json:"description" swaggertype:"string" extensions:"x-nullable"`
+	StrictDependencies types.Patch[bool]   `json:"strict_dependencies" swaggertype:"boolean"`
+	StrictWipLimits    types.Patch[bool]   `json:"strict_wip_limits" swaggertype:"boolean"`
+}
+
+const projectColumns = "id, name, description, owner_id, strict_dependencies, strict_wip_limits, version, deleted, created_at, updated_at, deleted_at"
+
+func scanProject(row scanner, pj *types.Project) error {
+	return row.Scan(&pj.Id, &pj.Name, &pj.Description, &pj.OwnerId, &pj.StrictDependencies, &pj.StrictWipLimits, &pj.Version, &pj.Deleted, &pj.CreatedAt, &pj.UpdatedAt, &pj.DeletedAt)
 }
 
 // Returned errors: ErrFailedValidation, ErrInternal, ErrNotFound
 func (s *Service) GetProjectById(ctx context.Context, id string) (*types.Project, error) {
 	if _, err := uuid.Parse(id); err != nil {
-		return nil, ErrFailedValidation
+		return nil, invalid("id", "must be a valid UUID")
 	}
 
-	var pj types.Project
-	acc := new(types.Account)
-	pj.Owner = acc
-	query := "SELECT * FROM projects WHERE id=$1 AND deleted=false"
-	row := s.DB.QueryRowContext(ctx, query, id)
-	err := row.Scan(&pj.Id, &pj.Name, &pj.Description, &pj.OwnerId, &pj.Deleted, &pj.CreatedAt, &pj.UpdatedAt)
+	pj, err := s.projects().GetById(ctx, id)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, ErrNotFound
-		}
-		return nil, ErrInternal
+		return nil, err
 	}
+	pj.Owner = new(types.Account)
 
-	return &pj, nil
+	return pj, nil
+}
+
+var projectList = &listSpec[types.Project]{
+	query: "SELECT " + projectColumns + " FROM projects",
+	sorts: map[string]sortField[types.Project]{
+		"id":         {"id", sortUUID, func(p *types.Project) string { return p.Id }},
+		"name":       {"name", sortText, func(p *types.Project) string { return p.Name }},
+		"created_at": {"created_at", sortTime, func(p *types.Project) string { return timeValue(p.CreatedAt) }},
+		"updated_at": {"updated_at", sortTime, func(p *types.Project) string { return timeValue(p.UpdatedAt) }},
+	},
+	scan: scanProject,
 }
 
 // Returned errors: ErrFailedValidation, ErrInternal
-func (s *Service) GetProjectsByOwnerId(ctx context.Context, ownerId string) ([]types.Project, error) {
-	pjs := make([]types.Project, 0)
+func (s *Service) GetProjectsByOwnerId(ctx context.Context, ownerId string, params *ListParams) (*types.Page[types.Project], error) {
 	if _, err := uuid.Parse(ownerId); err != nil {
-		return pjs, ErrFailedValidation
-	}
-	query := "SELECT * FROM projects WHERE owner_id=$1 AND deleted=false"
-	rows, err := s.DB.QueryContext(ctx, query, ownerId)
-	if err != nil {
-		return nil, ErrInternal
+		return nil, invalid("owner_id", "must be a valid UUID")
 	}
 
-	for rows.Next() {
-		var pj types.Project
-
-		err = rows.Scan(&pj.Id, &pj.Name, &pj.Description, &pj.OwnerId, &pj.Deleted, &pj.CreatedAt, &pj.UpdatedAt)
-		if err != nil {
-			return nil, ErrInternal
-		}
-
-		pjs = append(pjs, pj)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, ErrInternal
-	}
-
-	return pjs, nil
+	return s.projects().ListByOwner(ctx, ownerId, params)
 }
 
-// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToInsert
-func (s *Service) AddProject(ctx context.Context, input *AddProjectInput) error {
+// Projects can only be created on behalf of the caller.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrUnprocessable, ErrUnauthorized, ErrForbidden
+func (s *Service) AddProject(ctx context.Context, input *AddProjectInput) (*types.Project, error) {
 	if input.Name == "" {
-		return ErrFailedValidation
+		return nil, invalid("name", "must not be empty")
 	}
 	if _, err := uuid.Parse(input.OwnerId); err != nil {
-		return ErrFailedValidation
+		return nil, invalid("owner_id", "must be a valid UUID")
 	}
-
-	query := "INSERT INTO projects (name, description, owner_id) VALUES ($1, $2, $3)"
-	res, err := s.DB.ExecContext(ctx, query, input.Name, input.Description, input.OwnerId)
+	wf, err := s.GetWorkflow(input.Workflow)
 	if err != nil {
-		return ErrInternal
+		return nil, invalid("workflow", "must be a name of a workflow")
 	}
-
-	ra, err := res.RowsAffected()
-	if err != nil {
-		return ErrInternal
-	}
-	if ra < 1 {
-		return ErrFailedToInsert
+	if err := checkSelf(ctx, input.OwnerId); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return s.projects().Add(ctx, input, wf)
 }
 
-// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate
+// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate, ErrPreconditionFailed, ErrNotFound, ErrUnauthorized, ErrForbidden
 func (s *Service) UpdateProject(ctx context.Context, input *UpdateProjectInput) error {
 	if _, err := uuid.Parse(input.Id); err != nil {
-		return ErrFailedValidation
+		return invalid("id", "must be a valid UUID")
 	}
-
-	query := "UPDATE projects SET name=COALESCE(NULLIF($1, ''), name), description=COALESCE(NULLIF($2, ''), description) WHERE id::text=$3"
-	res, err := s.DB.ExecContext(ctx, query, input.Name, input.Description, input.Id)
-	if err != nil {
-		return ErrInternal
+	if err := notEmpty("name", input.Name); err != nil {
+		return err
 	}
-	ra, err := res.RowsAffected()
-	if err != nil {
-		return ErrInternal
+	if err := notNull("strict_dependencies", input.StrictDependencies); err != nil {
+		return err
 	}
-
-	if ra < 1 {
-		return ErrFailedToUpdate
+	if err := notNull("strict_wip_limits", input.StrictWipLimits); err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, input.Id, PermUpdateProject); err != nil {
+		return err
 	}
 
-	return nil
+	return s.transact(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if err := checkETag(ctx, input.Id, s.GetProjectById); err != nil {
+			return err
+		}
+
+		return s.projects().Update(ctx, input)
+	})
 }
 
-// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate
+// Statuses and tasks of the project are deleted with it.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate, ErrPreconditionFailed, ErrNotFound, ErrUnauthorized, ErrForbidden
 func (s *Service) DeleteProjectById(ctx context.Context, id string) error {
 	if _, err := uuid.Parse(id); err != nil {
-		return ErrFailedValidation
+		return invalid("id", "must be a valid UUID")
 	}
+	if err := s.authorize(ctx, id, PermDeleteProject); err != nil {
+		return err
+	}
+
+	return s.transact(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if err := checkETag(ctx, id, s.GetProjectById); err != nil {
+			return err
+		}
+
+		return s.projects().Delete(ctx, id)
+	})
+}
 
-	query := "UPDATE projects SET deleted=true WHERE id=$1"
-	res, err := s.DB.ExecContext(ctx, query, id)
+// PostgresProjects keeps projects in Postgres.
+type PostgresProjects struct {
+	DB *sql.DB
+}
+
+func (r *PostgresProjects) GetById(ctx context.Context, id string) (*types.Project, error) {
+	var pj types.Project
+	query := "SELECT " + projectColumns + " FROM projects WHERE id=$1 AND deleted=false"
+	row := conn(ctx, r.DB).QueryRowContext(ctx, query, id)
+	err := scanProject(row, &pj)
 	if err != nil {
-		return ErrInternal
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, dbError(err)
 	}
-	ra, err := res.RowsAffected()
+
+	return &pj, nil
+}
+
+func (r *PostgresProjects) ListByOwner(ctx context.Context, ownerId string, params *ListParams) (*types.Page[types.Project], error) {
+	return list(ctx, conn(ctx, r.DB), projectList, params, []string{"owner_id=$1", "deleted=false"}, []any{ownerId})
+}
+
+func (r *PostgresProjects) Role(ctx context.Context, pId, aId string) (types.Role, error) {
+	var role sql.NullString
+	query := `SELECT CASE WHEN p.owner_id=$2 THEN 'owner' ELSE pa.role END
+		FROM projects p LEFT JOIN projects_to_accounts pa ON pa.project_id=p.id AND pa.account_id=$2
+		WHERE p.id=$1 AND p.deleted=false`
+	err := conn(ctx, r.DB).QueryRowContext(ctx, query, pId, aId).Scan(&role)
 	if err != nil {
-		return ErrInternal
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", dbError(err)
+	}
+
+	return types.Role(role.String), nil
+}
+
+func (r *PostgresProjects) Add(ctx context.Context, input *AddProjectInput, wf *types.Workflow) (*types.Project, error) {
+	var pj types.Project
+	err := transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		query := "INSERT INTO projects (name, description, owner_id) VALUES ($1, $2, $3) RETURNING " + projectColumns
+		row := tx.QueryRowContext(ctx, query, input.Name, input.Description, input.OwnerId)
+		if err := scanProject(row, &pj); err != nil {
+			return dbError(err)
+		}
+
+		statuses, err := seedWorkflow(ctx, tx, pj.Id, wf)
+		if err != nil {
+			return err
+		}
+		pj.Statuses = statuses
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pj, nil
+}
+
+func (r *PostgresProjects) Update(ctx context.Context, input *UpdateProjectInput) error {
+	var u update
+	if input.Name.Set {
+		u.set("name", input.Name.Value)
+	}
+	if input.Description.Set {
+		u.set("description", input.Description.Value)
+	}
+	if input.StrictDependencies.Set {
+		u.set("strict_dependencies", input.StrictDependencies.Value)
 	}
+	if input.StrictWipLimits.Set {
+		u.set("strict_wip_limits", input.StrictWipLimits.Value)
+	}
+
+	return u.exec(ctx, conn(ctx, r.DB), "projects", input.Id)
+}
 
-	if ra < 1 {
-		return ErrFailedToUpdate
+func (r *PostgresProjects) Delete(ctx context.Context, id string) error {
+	return transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		query := "UPDATE projects SET deleted=true, deleted_at=now() WHERE id=$1 AND deleted=false"
+		res, err := tx.ExecContext(ctx, query, id)
+		if err != nil {
+			return dbError(err)
+		}
+		ra, err := res.RowsAffected()
+		if err != nil {
+			return dbError(err)
+		}
+
+		if ra < 1 {
+			return ErrFailedToUpdate
+		}
+
+		if err := deleteProjectContents(ctx, tx, []string{id}); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// deleteProjectContents soft deletes statuses and tasks of the projects pIds.
+//
+// Returned errors: ErrConcurrentUpdate, ErrInternal
+func deleteProjectContents(ctx context.Context, tx *sql.Tx, pIds []string) error {
+	for _, table := range []string{"statuses", "tasks"} {
+		query := "UPDATE " + table + " SET deleted=true, deleted_at=now() WHERE project_id=ANY($1) AND deleted=false"
+		if _, err := tx.ExecContext(ctx, query, pq.Array(pIds)); err != nil {
+			return dbError(err)
+		}
 	}
 
 	return nil
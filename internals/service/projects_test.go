@@ -11,8 +11,6 @@ import (
 )
 
 func TestGetProjectById(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	pId := uuid.NewString()
 	owner := types.Account{
 		Id:    uuid.NewString(),
@@ -38,34 +36,29 @@ func TestGetProjectById(t *testing.T) {
 			input:   pId,
 			wantErr: nil,
 			want: &types.Project{
-				Id:      pId,
-				Name:    "Existing project",
-				OwnerId: owner.Id,
+				Id:                 pId,
+				Name:               "Existing project",
+				OwnerId:            owner.Id,
+				StrictDependencies: true,
+				StrictWipLimits:    true,
 			},
 		},
 	}
 
 	for name, tt := range tests {
-		_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
+		s, m := setupMemory()
+		m.PutAccount(owner)
 		if tt.want != nil {
-			_, err = s.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", tt.want.Id, tt.want.Name, tt.want.Description, tt.want.OwnerId)
-			if err != nil {
-				t.Fatal(ErrFailedToPrepareTest, err)
-			}
+			m.PutProject(*tt.want)
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts"))
-
 			ctx := context.Background()
 			got, err := s.GetProjectById(ctx, tt.input)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("GetProjectById() mismatch (-want +got):\n%s", diff)
 			}
-			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(types.Project{}, "CreatedAt", "UpdatedAt", "Owner")); diff != "" {
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(types.Project{}, "CreatedAt", "UpdatedAt", "Version", "Owner")); diff != "" {
 				t.Fatalf("GetProjectById() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -73,8 +66,6 @@ func TestGetProjectById(t *testing.T) {
 }
 
 func TestGetProjectsByOwnerId(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -95,42 +86,37 @@ func TestGetProjectsByOwnerId(t *testing.T) {
 			wantErr: nil,
 			want: []types.Project{
 				{
-					Id:      uuid.NewString(),
-					Name:    "Project 1",
-					OwnerId: owner.Id,
+					Id:                 uuid.NewString(),
+					Name:               "Project 1",
+					OwnerId:            owner.Id,
+					StrictDependencies: true,
+					StrictWipLimits:    true,
 				},
 				{
-					Id:      uuid.NewString(),
-					Name:    "Project 1",
-					OwnerId: owner.Id,
+					Id:                 uuid.NewString(),
+					Name:               "Project 1",
+					OwnerId:            owner.Id,
+					StrictDependencies: true,
+					StrictWipLimits:    true,
 				},
 			},
 		},
 	}
 
 	for name, tt := range tests {
-		_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		if tt.want != nil {
-			for _, p := range tt.want {
-				_, err = s.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", p.Id, p.Name, p.Description, p.OwnerId)
-				if err != nil {
-					t.Fatal(ErrFailedToPrepareTest, err)
-				}
-			}
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		for _, p := range tt.want {
+			m.PutProject(p)
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts"))
-
 			ctx := context.Background()
-			got, err := s.GetProjectsByOwnerId(ctx, tt.input)
+			got, err := s.GetProjectsByOwnerId(ctx, tt.input, nil)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("GetProjectsByOwnerId() mismatch (-want +got):\n%s", diff)
 			}
-			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(types.Project{}, "CreatedAt", "UpdatedAt", "Owner")); diff != "" {
+			if diff := cmp.Diff(tt.want, pageItems(got), cmpopts.IgnoreFields(types.Project{}, "CreatedAt", "UpdatedAt", "Version", "Owner"), cmpopts.SortSlices(func(a, b types.Project) bool { return a.Id < b.Id })); diff != "" {
 				t.Fatalf("GetProjectsByOwnerId() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -138,16 +124,15 @@ func TestGetProjectsByOwnerId(t *testing.T) {
 }
 
 func TestAddProject(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
 		Email: "username@test.com",
 	}
 	tests := map[string]struct {
-		input   *AddProjectInput
-		wantErr error
+		input        *AddProjectInput
+		wantErr      error
+		wantStatuses []string
 	}{
 		"invalid owner id": {
 			input: &AddProjectInput{
@@ -156,6 +141,14 @@ func TestAddProject(t *testing.T) {
 			},
 			wantErr: ErrFailedValidation,
 		},
+		"unknown workflow": {
+			input: &AddProjectInput{
+				Name:     "project",
+				OwnerId:  owner.Id,
+				Workflow: "unknown",
+			},
+			wantErr: ErrFailedValidation,
+		},
 		"invalid name": {
 			input: &AddProjectInput{
 				OwnerId: owner.Id,
@@ -167,38 +160,62 @@ func TestAddProject(t *testing.T) {
 				Name:    "project",
 				OwnerId: uuid.NewString(),
 			},
-			wantErr: ErrInternal,
+			wantErr: ErrUnprocessable,
 		},
 		"sucsessfull add": {
 			input: &AddProjectInput{
 				Name:    "project",
 				OwnerId: owner.Id,
 			},
-			wantErr: nil,
+			wantErr:      nil,
+			wantStatuses: []string{"To Do", "In Progress", "Done"},
+		},
+		"sucsessfull add with workflow": {
+			input: &AddProjectInput{
+				Name:     "project",
+				OwnerId:  owner.Id,
+				Workflow: "kanban",
+			},
+			wantErr:      nil,
+			wantStatuses: []string{"Backlog", "To Do", "In Progress", "Review", "Done"},
 		},
 	}
 
 	for name, tt := range tests {
-		_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
+		s, m := setupMemory()
+		m.PutAccount(owner)
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts"))
-
-			ctx := context.Background()
-			err := s.AddProject(ctx, tt.input)
+			ctx := WithAccountId(context.Background(), tt.input.OwnerId)
+			got, err := s.AddProject(ctx, tt.input)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("AddProject() mismatch (-want +got):\n%s", diff)
 			}
+			if err != nil {
+				return
+			}
+			if _, err := uuid.Parse(got.Id); err != nil {
+				t.Fatalf("AddProject() returned invalid id %q", got.Id)
+			}
+			if diff := cmp.Diff(tt.input.Name, got.Name); diff != "" {
+				t.Fatalf("AddProject() mismatch (-want +got):\n%s", diff)
+			}
+			sts, err := s.GetStatusesByProjectId(ctx, got.Id, &ListParams{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := make([]string, 0)
+			for _, st := range sts.Items {
+				names = append(names, st.Name)
+			}
+			if diff := cmp.Diff(tt.wantStatuses, names); diff != "" {
+				t.Fatalf("AddProject() statuses mismatch (-want +got):\n%s", diff)
+			}
 		})
 	}
 }
 
 func TestUpdateProject(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -216,40 +233,33 @@ func TestUpdateProject(t *testing.T) {
 		"invalid id": {
 			input: &UpdateProjectInput{
 				Id:   "invalid-id",
-				Name: "New project name",
+				Name: types.PatchOf("New project name"),
 			},
 			wantErr: ErrFailedValidation,
 		},
 		"non-existent project id": {
 			input: &UpdateProjectInput{
 				Id:   uuid.NewString(),
-				Name: "New project name",
+				Name: types.PatchOf("New project name"),
 			},
-			wantErr: ErrFailedToUpdate,
+			wantErr: ErrNotFound,
 		},
 		"sucsessfull update": {
 			input: &UpdateProjectInput{
 				Id:   p.Id,
-				Name: "New project name",
+				Name: types.PatchOf("New project name"),
 			},
 			wantErr: nil,
 		},
 	}
 
 	for name, tt := range tests {
-		_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", p.Id, p.Name, p.Description, p.OwnerId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(p)
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts"))
-
-			ctx := context.Background()
+			ctx := WithAccountId(context.Background(), owner.Id)
 			err := s.UpdateProject(ctx, tt.input)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("UpdateProject() mismatch (-want +got):\n%s", diff)
@@ -259,8 +269,6 @@ func TestUpdateProject(t *testing.T) {
 }
 
 func TestDeleteProjectById(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -281,7 +289,7 @@ func TestDeleteProjectById(t *testing.T) {
 		},
 		"non-existent project id": {
 			input:   uuid.NewString(),
-			wantErr: ErrFailedToUpdate,
+			wantErr: ErrNotFound,
 		},
 		"sucsessfull delete": {
 			input:   p.Id,
@@ -290,23 +298,36 @@ func TestDeleteProjectById(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", p.Id, p.Name, p.Description, p.OwnerId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(p)
+		statusId := uuid.NewString()
+		m.PutStatus(types.Status{Id: statusId, Name: "todo", ProjectId: p.Id})
+		m.PutTask(types.Task{Name: "task", ProjectId: p.Id, StatusId: statusId})
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts"))
-
-			ctx := context.Background()
+			ctx := WithAccountId(context.Background(), owner.Id)
 			err := s.DeleteProjectById(ctx, tt.input)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("UpdateProject() mismatch (-want +got):\n%s", diff)
 			}
+			if err != nil {
+				return
+			}
+			var visible int
+			for _, st := range m.statuses {
+				if !st.Deleted {
+					visible++
+				}
+			}
+			for _, t := range m.tasks {
+				if !t.Deleted {
+					visible++
+				}
+			}
+			if visible != 0 {
+				t.Fatalf("DeleteProjectById() left %d statuses and tasks", visible)
+			}
 		})
 	}
 }
@@ -0,0 +1,473 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/danblok/pm/internals/types"
+)
+
+// AccountRepository keeps accounts. Implementations return the service
+// errors, so the service passes them to callers as is.
+//
+// PostgresAccounts is used when Service.Accounts is nil,
+// MemoryAccounts keeps them in a Memory.
+type AccountRepository interface {
+	// Returned errors: ErrInternal, ErrNotFound
+	GetById(ctx context.Context, id string) (*types.Account, error)
+	// Returned errors: ErrFailedValidation, ErrInternal
+	List(ctx context.Context, params *ListParams) (*types.Page[types.Account], error)
+	// Credentials returns the id and the password hash of the account with the email.
+	//
+	// Returned errors: ErrInternal, ErrNotFound
+	Credentials(ctx context.Context, email string) (id, hash string, err error)
+	// WithoutPassword returns accounts that have no password hash,
+	// i.e. accounts created before accounts had passwords.
+	//
+	// Returned errors: ErrInternal
+	WithoutPassword(ctx context.Context) ([]types.Account, error)
+	// SetFirstPassword sets the password hash of the account unless it already has one.
+	//
+	// Returned errors: ErrFailedToUpdate, ErrInternal
+	SetFirstPassword(ctx context.Context, id, hash string) error
+	// Add creates an account and binds invitations held for its email to it.
+	//
+	// Returned errors: ErrConflict, ErrInternal
+	Add(ctx context.Context, input *AddAccountInput, hash string) (*types.Account, error)
+	// Update changes the fields set in the input and the password hash if it's not empty.
+	//
+	// Returned errors: ErrConflict, ErrFailedToUpdate, ErrInternal
+	Update(ctx context.Context, input *UpdateAccountInput, hash string) error
+	// Delete deletes the account with the projects it owns.
+	//
+	// Returned errors: ErrFailedToUpdate, ErrInternal
+	Delete(ctx context.Context, id string) error
+}
+
+// ProjectRepository keeps projects with their contributors.
+//
+// PostgresProjects is used when Service.Projects is nil,
+// MemoryProjects keeps them in a Memory.
+type ProjectRepository interface {
+	// Returned errors: ErrInternal, ErrNotFound
+	GetById(ctx context.Context, id string) (*types.Project, error)
+	// Returned errors: ErrFailedValidation, ErrInternal
+	ListByOwner(ctx context.Context, ownerId string, params *ListParams) (*types.Page[types.Project], error)
+	// Role returns the role of the account aId in the project pId,
+	// it's empty if the account is neither the owner nor a contributor.
+	//
+	// Returned errors: ErrInternal, ErrNotFound
+	Role(ctx context.Context, pId, aId string) (types.Role, error)
+	// Add creates a project with the statuses and transitions of the workflow wf.
+	//
+	// Returned errors: ErrInternal, ErrUnprocessable
+	Add(ctx context.Context, input *AddProjectInput, wf *types.Workflow) (*types.Project, error)
+	// Update changes the fields set in the input.
+	//
+	// Returned errors: ErrFailedToUpdate, ErrInternal
+	Update(ctx context.Context, input *UpdateProjectInput) error
+	// Delete deletes the project with its statuses and tasks.
+	//
+	// Returned errors: ErrFailedToUpdate, ErrInternal
+	Delete(ctx context.Context, id string) error
+	// Contributors returns the contributors of the project pId with their roles.
+	//
+	// Returned errors: ErrInternal
+	Contributors(ctx context.Context, pId string) ([]types.Account, error)
+	// Contributed returns the projects the account aId contributes to.
+	//
+	// Returned errors: ErrInternal
+	Contributed(ctx context.Context, aId string) ([]types.Project, error)
+	// Returned errors: ErrFailedToInsert, ErrInternal, ErrUnprocessable
+	AddContributor(ctx context.Context, input *AddContributorInput) error
+	// Returned errors: ErrFailedToUpdate, ErrInternal
+	UpdateContributor(ctx context.Context, input *UpdateContributorInput) error
+	// DeleteContributor removes the contributor aId from the project pId
+	// and unassigns them from the tasks of the project.
+	//
+	// Returned errors: ErrFailedToUpdate, ErrInternal
+	DeleteContributor(ctx context.Context, pId, aId string) error
+	// Transitions returns the workflow of the project pId.
+	//
+	// Returned errors: ErrInternal
+	Transitions(ctx context.Context, pId string) ([]types.Transition, error)
+	// SetTransitions replaces the workflow of the project, both statuses
+	// of every transition must be statuses of the project.
+	//
+	// Returned errors: ErrFailedValidation, ErrInternal
+	SetTransitions(ctx context.Context, input *SetTransitionsInput) error
+}
+
+// StatusRepository keeps statuses of projects.
+//
+// PostgresStatuses is used when Service.Statuses is nil,
+// MemoryStatuses keeps them in a Memory.
+type StatusRepository interface {
+	// Returned errors: ErrInternal, ErrNotFound
+	GetById(ctx context.Context, id string) (*types.Status, error)
+	// Returned errors: ErrFailedValidation, ErrInternal
+	ListByProject(ctx context.Context, pId string, params *ListParams) (*types.Page[types.Status], error)
+	// ProjectId returns the id of the project of the status id.
+	//
+	// Returned errors: ErrInternal, ErrNotFound
+	ProjectId(ctx context.Context, id string) (string, error)
+	// Add creates a status at the end of the board of its project.
+	//
+	// Returned errors: ErrConflict, ErrInternal, ErrUnprocessable
+	Add(ctx context.Context, input *AddStatusInput) (*types.Status, error)
+	// Update changes the fields set in the input.
+	//
+	// Returned errors: ErrConflict, ErrFailedToUpdate, ErrInternal
+	Update(ctx context.Context, input *UpdateStatusInput) error
+	// Delete deletes the status id with its transitions. Its tasks are moved
+	// to the end of the status targetId of the same project, a status with
+	// tasks can't be deleted if targetId is empty.
+	//
+	// Returned errors: ErrFailedValidation, ErrConflict, ErrFailedToUpdate, ErrInternal, ErrNotFound
+	Delete(ctx context.Context, id, targetId string) error
+	// Move places the status of the project pId right after the status AfterId,
+	// the statuses of the project are rebalanced if there's no room between them.
+	//
+	// Returned errors: ErrFailedValidation, ErrConcurrentUpdate, ErrInternal
+	Move(ctx context.Context, pId string, input *MoveStatusInput) error
+}
+
+// TaskRepository keeps tasks of projects. Tasks are checked against
+// the statuses, the workflow, the WIP limits, the dependencies and
+// the labels of their project.
+//
+// PostgresTasks is used when Service.Tasks is nil,
+// MemoryTasks keeps them in a Memory.
+type TaskRepository interface {
+	// GetById returns the task with its assignees, labels and progress.
+	//
+	// Returned errors: ErrInternal, ErrNotFound
+	GetById(ctx context.Context, id string) (*types.Task, error)
+	// Returned errors: ErrFailedValidation, ErrInternal
+	ListByProject(ctx context.Context, pId string, params *ListParams) (*types.Page[types.Task], error)
+	// ProjectId returns the id of the project of the task id.
+	//
+	// Returned errors: ErrInternal, ErrNotFound
+	ProjectId(ctx context.Context, id string) (string, error)
+	// Add creates a task at the end of its status, start and end are
+	// the parsed Start and End of the input. The task is returned with its labels.
+	//
+	// Returned errors: ErrFailedValidation, ErrInternal, ErrUnprocessable
+	Add(ctx context.Context, input *AddTaskInput, start, end time.Time) (*types.Task, error)
+	// Update changes the fields set in the input, start and end are
+	// the parsed Start and End of the input if they are set.
+	//
+	// Returned errors: ErrFailedValidation, ErrFailedToUpdate, ErrInternal, ErrUnprocessable
+	Update(ctx context.Context, input *UpdateTaskInput, start, end time.Time) error
+	// Delete deletes the task with its subtasks.
+	//
+	// Returned errors: ErrFailedToUpdate, ErrInternal
+	Delete(ctx context.Context, id string) error
+	// Move places the task of the project pId into the status StatusId right
+	// after the task AfterId, the tasks of the status are rebalanced if there's
+	// no room between them.
+	//
+	// Returned errors: ErrFailedValidation, ErrConcurrentUpdate, ErrFailedToUpdate, ErrInternal, ErrUnprocessable
+	Move(ctx context.Context, pId string, input *MoveTaskInput) error
+	// ListChildren returns the direct subtasks of the task id.
+	//
+	// Returned errors: ErrFailedValidation, ErrInternal
+	ListChildren(ctx context.Context, id string, params *ListParams) (*types.Page[types.Task], error)
+	// Tree returns the task id with all of its subtasks nested in Children.
+	//
+	// Returned errors: ErrInternal, ErrNotFound
+	Tree(ctx context.Context, id string) (*types.Task, error)
+}
+
+// InvitationRepository keeps invitations to projects.
+//
+// PostgresInvitations is used when Service.Invitations is nil,
+// MemoryInvitations keeps them in a Memory.
+type InvitationRepository interface {
+	// Returned errors: ErrInternal
+	ListByProject(ctx context.Context, pId string) ([]types.Invitation, error)
+	// Pending returns pending and not expired invitations of the account inviteeId.
+	//
+	// Returned errors: ErrInternal
+	Pending(ctx context.Context, inviteeId string) ([]types.Invitation, error)
+	// Add creates the invitation inv, its invitee is the account with its email.
+	// Without such an account the invitation is held until AccountRepository.Add
+	// creates one.
+	//
+	// Returned errors: ErrConflict, ErrInternal, ErrUnprocessable
+	Add(ctx context.Context, inv *types.Invitation) (*types.Invitation, error)
+	// Accept accepts the pending invitation with the token and makes its invitee
+	// a contributor of the project with the role of the invitation.
+	//
+	// Returned errors: ErrInternal, ErrNotFound
+	Accept(ctx context.Context, token, inviteeId string) error
+	// Returned errors: ErrInternal, ErrNotFound
+	Decline(ctx context.Context, token, inviteeId string) error
+	// Returned errors: ErrFailedToUpdate, ErrInternal
+	Delete(ctx context.Context, pId, id string) error
+}
+
+// LabelRepository keeps labels of projects. Labels of a task are set
+// by TaskRepository.Add and TaskRepository.Update.
+//
+// PostgresLabels is used when Service.Labels is nil,
+// MemoryLabels keeps them in a Memory.
+type LabelRepository interface {
+	// ListByProject returns labels of the project pId ordered by name.
+	//
+	// Returned errors: ErrInternal
+	ListByProject(ctx context.Context, pId string) ([]types.Label, error)
+	// ListByTask returns labels of the task tId ordered by name.
+	//
+	// Returned errors: ErrInternal
+	ListByTask(ctx context.Context, tId string) ([]types.Label, error)
+	// Returned errors: ErrConflict, ErrInternal, ErrUnprocessable
+	Add(ctx context.Context, input *AddLabelInput) (*types.Label, error)
+	// Update changes the name and the color of the label that aren't empty in the input.
+	//
+	// Returned errors: ErrConflict, ErrFailedToUpdate, ErrInternal
+	Update(ctx context.Context, input *UpdateLabelInput) error
+	// Delete deletes the label id of the project pId and detaches it from all tasks.
+	//
+	// Returned errors: ErrFailedToUpdate, ErrInternal
+	Delete(ctx context.Context, pId, id string) error
+}
+
+// AssigneeRepository keeps accounts assigned to tasks.
+//
+// PostgresAssignees is used when Service.Assignees is nil,
+// MemoryAssignees keeps them in a Memory.
+type AssigneeRepository interface {
+	// ListByTask returns accounts assigned to the task tId in order of assignment.
+	//
+	// Returned errors: ErrInternal
+	ListByTask(ctx context.Context, tId string) ([]types.Account, error)
+	// ListTasks returns tasks assigned to the account aId across all projects.
+	//
+	// Returned errors: ErrFailedValidation, ErrInternal
+	ListTasks(ctx context.Context, aId string, params *ListParams) (*types.Page[types.Task], error)
+	// Add assigns the account aId to the task tId.
+	//
+	// Returned errors: ErrFailedToInsert, ErrInternal, ErrUnprocessable
+	Add(ctx context.Context, tId, aId string) error
+	// Returned errors: ErrFailedToUpdate, ErrInternal
+	Delete(ctx context.Context, tId, aId string) error
+}
+
+// DependencyRepository keeps dependencies between tasks.
+//
+// PostgresDependencies is used when Service.Dependencies is nil,
+// MemoryDependencies keeps them in a Memory.
+type DependencyRepository interface {
+	// ListByTask returns the tasks blocking the task tId ordered by their end
+	// and the tasks it blocks ordered by their start.
+	//
+	// Returned errors: ErrInternal
+	ListByTask(ctx context.Context, tId string) (*types.Dependencies, error)
+	// ListByProject returns the tasks of the project pId with the dependencies between them.
+	//
+	// Returned errors: ErrInternal
+	ListByProject(ctx context.Context, pId string) ([]types.Task, []types.Dependency, error)
+	// Add makes the task BlockerId block the task TaskId of the project pId.
+	// The blocker must be a task of the project, the dependency must not create
+	// a cycle and, in projects with StrictDependencies, the blocker must end
+	// before the task starts.
+	//
+	// Returned errors: ErrFailedValidation, ErrConcurrentUpdate, ErrConflict, ErrInternal, ErrUnprocessable
+	Add(ctx context.Context, pId string, input *AddDependencyInput) (*types.Dependency, error)
+	// Returned errors: ErrFailedToUpdate, ErrInternal
+	Delete(ctx context.Context, tId, blockerId string) error
+}
+
+// CommentRepository keeps comments of tasks with their revisions.
+// Deleted comments are kept, but they aren't returned.
+//
+// PostgresComments is used when Service.Comments is nil,
+// MemoryComments keeps them in a Memory.
+type CommentRepository interface {
+	// ListByTask returns a page of top-level comments of the task tId,
+	// each with its replies in order of creation.
+	//
+	// Returned errors: ErrFailedValidation, ErrInternal
+	ListByTask(ctx context.Context, tId string, params *ListParams) (*types.Page[types.Comment], error)
+	// Returned errors: ErrInternal, ErrNotFound
+	GetById(ctx context.Context, tId, id string) (*types.Comment, error)
+	// Add creates a comment of the account authorId, the parent of a reply
+	// must be a top-level comment of the same task.
+	//
+	// Returned errors: ErrFailedValidation, ErrInternal, ErrUnprocessable
+	Add(ctx context.Context, input *AddCommentInput, authorId string) (*types.Comment, error)
+	// Update replaces the body of the comment if it's written by the account
+	// authorId, the previous body is kept in the revisions of the comment.
+	//
+	// Returned errors: ErrConcurrentUpdate, ErrForbidden, ErrInternal, ErrNotFound
+	Update(ctx context.Context, input *UpdateCommentInput, authorId string) error
+	// Delete deletes the comment id with its replies.
+	//
+	// Returned errors: ErrInternal
+	Delete(ctx context.Context, id string) error
+	// Revisions returns the previous bodies of the comment id in order of creation.
+	//
+	// Returned errors: ErrInternal
+	Revisions(ctx context.Context, id string) ([]types.CommentRevision, error)
+}
+
+// AttachmentRepository keeps metadata of attachments, their contents
+// are kept in Service.Storage.
+//
+// PostgresAttachments is used when Service.Attachments is nil,
+// MemoryAttachments keeps them in a Memory.
+type AttachmentRepository interface {
+	// ListByTask returns attachments of the task tId in order of creation.
+	//
+	// Returned errors: ErrInternal
+	ListByTask(ctx context.Context, tId string) ([]types.Attachment, error)
+	// Returned errors: ErrInternal, ErrNotFound
+	GetById(ctx context.Context, tId, id string) (*types.Attachment, error)
+	// Add keeps the attachment a with the id its contents are stored under.
+	//
+	// Returned errors: ErrInternal, ErrUnprocessable
+	Add(ctx context.Context, a *types.Attachment) (*types.Attachment, error)
+	// Returned errors: ErrFailedToUpdate, ErrInternal
+	Delete(ctx context.Context, tId, id string) error
+}
+
+// TrashRepository keeps deleted accounts, projects, statuses and tasks
+// until they are restored or purged. Records deleted together, e.g. a project
+// with its statuses and tasks, are restored together.
+//
+// PostgresTrash is used when Service.Trash is nil,
+// MemoryTrash keeps them in a Memory.
+type TrashRepository interface {
+	// ProjectTrash returns deleted statuses and tasks of the project pId,
+	// the most recently deleted first.
+	//
+	// Returned errors: ErrInternal
+	ProjectTrash(ctx context.Context, pId string) (*types.Trash, error)
+	// AccountTrash returns deleted projects owned by the account aId,
+	// the most recently deleted first.
+	//
+	// Returned errors: ErrInternal
+	AccountTrash(ctx context.Context, aId string) (*types.Trash, error)
+	// DeletedCredentials returns the id and the password hash of the deleted account with the email.
+	//
+	// Returned errors: ErrInternal, ErrNotFound
+	DeletedCredentials(ctx context.Context, email string) (id, hash string, err error)
+	// RestoreAccount restores the deleted account id with the projects it owns
+	// that were deleted with it.
+	//
+	// Returned errors: ErrConcurrentUpdate, ErrInternal, ErrNotFound
+	RestoreAccount(ctx context.Context, id string) error
+	// RestoreProject restores the deleted project id if it's owned by the account ownerId.
+	//
+	// Returned errors: ErrConcurrentUpdate, ErrForbidden, ErrInternal, ErrNotFound
+	RestoreProject(ctx context.Context, id, ownerId string) error
+	// StatusProjectId returns the id of the project of the deleted status id.
+	//
+	// Returned errors: ErrInternal, ErrNotFound
+	StatusProjectId(ctx context.Context, id string) (string, error)
+	// TaskProjectId returns the id of the project of the deleted task id.
+	//
+	// Returned errors: ErrInternal, ErrNotFound
+	TaskProjectId(ctx context.Context, id string) (string, error)
+	// Returned errors: ErrConcurrentUpdate, ErrInternal, ErrNotFound
+	RestoreStatus(ctx context.Context, id string) error
+	// RestoreTask restores the deleted task id of the project pId with its subtasks
+	// that were deleted with it, unless they are in deleted statuses.
+	//
+	// Returned errors: ErrConcurrentUpdate, ErrConflict, ErrInternal, ErrNotFound
+	RestoreTask(ctx context.Context, pId, id string) error
+	// Purge hard deletes what was deleted longer than retention ago, see Service.Purge.
+	// It returns the ids of the purged attachments and the number of purged records.
+	//
+	// Returned errors: ErrConcurrentUpdate, ErrInternal
+	Purge(ctx context.Context, retention time.Duration) (blobs []string, purged int64, err error)
+}
+
+// accounts returns the repository of accounts of the service.
+func (s *Service) accounts() AccountRepository {
+	if s.Accounts != nil {
+		return s.Accounts
+	}
+	return &PostgresAccounts{DB: s.DB}
+}
+
+// projects returns the repository of projects of the service.
+func (s *Service) projects() ProjectRepository {
+	if s.Projects != nil {
+		return s.Projects
+	}
+	return &PostgresProjects{DB: s.DB}
+}
+
+// statuses returns the repository of statuses of the service.
+func (s *Service) statuses() StatusRepository {
+	if s.Statuses != nil {
+		return s.Statuses
+	}
+	return &PostgresStatuses{DB: s.DB}
+}
+
+// tasks returns the repository of tasks of the service.
+func (s *Service) tasks() TaskRepository {
+	if s.Tasks != nil {
+		return s.Tasks
+	}
+	return &PostgresTasks{DB: s.DB}
+}
+
+// invitations returns the repository of invitations of the service.
+func (s *Service) invitations() InvitationRepository {
+	if s.Invitations != nil {
+		return s.Invitations
+	}
+	return &PostgresInvitations{DB: s.DB}
+}
+
+// labels returns the repository of labels of the service.
+func (s *Service) labels() LabelRepository {
+	if s.Labels != nil {
+		return s.Labels
+	}
+	return &PostgresLabels{DB: s.DB}
+}
+
+// assignees returns the repository of assignees of the service.
+func (s *Service) assignees() AssigneeRepository {
+	if s.Assignees != nil {
+		return s.Assignees
+	}
+	return &PostgresAssignees{DB: s.DB}
+}
+
+// dependencies returns the repository of dependencies of the service.
+func (s *Service) dependencies() DependencyRepository {
+	if s.Dependencies != nil {
+		return s.Dependencies
+	}
+	return &PostgresDependencies{DB: s.DB}
+}
+
+// comments returns the repository of comments of the service.
+func (s *Service) comments() CommentRepository {
+	if s.Comments != nil {
+		return s.Comments
+	}
+	return &PostgresComments{DB: s.DB}
+}
+
+// attachments returns the repository of attachments of the service.
+func (s *Service) attachments() AttachmentRepository {
+	if s.Attachments != nil {
+		return s.Attachments
+	}
+	return &PostgresAttachments{DB: s.DB}
+}
+
+// trash returns the repository of deleted records of the service.
+func (s *Service) trash() TrashRepository {
+	if s.Trash != nil {
+		return s.Trash
+	}
+	return &PostgresTrash{DB: s.DB}
+}
@@ -3,6 +3,9 @@ package service
 import (
 	"database/sql"
 	"errors"
+
+	"github.com/danblok/pm/internals/storage"
+	"github.com/danblok/pm/internals/types"
 )
 
 var (
@@ -12,8 +15,47 @@ var (
 	ErrFailedToInsert      = errors.New("failed to insert data")
 	ErrInternal            = errors.New("failed internal")
 	ErrNotFound            = errors.New("not found")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrForbidden           = errors.New("forbidden")
+	ErrConflict            = errors.New("conflict")
+	ErrUnprocessable       = errors.New("unprocessable")
+	ErrTooLarge            = errors.New("too large")
+	ErrConcurrentUpdate    = errors.New("concurrent update")
+	ErrPreconditionFailed  = errors.New("precondition failed")
 )
 
 type Service struct {
 	DB *sql.DB
+	// Accounts keeps accounts, they are kept in DB if it's nil.
+	Accounts AccountRepository
+	// Projects keeps projects, they are kept in DB if it's nil.
+	Projects ProjectRepository
+	// Statuses keeps statuses, they are kept in DB if it's nil.
+	Statuses StatusRepository
+	// Tasks keeps tasks, they are kept in DB if it's nil.
+	Tasks TaskRepository
+	// Invitations keeps invitations, they are kept in DB if it's nil.
+	Invitations InvitationRepository
+	// Labels keeps labels, they are kept in DB if it's nil.
+	Labels LabelRepository
+	// Assignees keeps assignees of tasks, they are kept in DB if it's nil.
+	Assignees AssigneeRepository
+	// Dependencies keeps dependencies between tasks, they are kept in DB if it's nil.
+	Dependencies DependencyRepository
+	// Comments keeps comments of tasks, they are kept in DB if it's nil.
+	Comments CommentRepository
+	// Attachments keeps metadata of attachments, it's kept in DB if it's nil.
+	Attachments AttachmentRepository
+	// Trash keeps deleted records until they are purged, they are kept in DB if it's nil.
+	Trash TrashRepository
+	// Secret is used to sign access and refresh tokens.
+	Secret []byte
+	// Storage keeps contents of task attachments.
+	Storage storage.Storage
+	// Workflows are templates of statuses new projects can start with,
+	// DefaultWorkflows are used if it's nil.
+	Workflows []types.Workflow
+	// DefaultWorkflow is the name of the workflow of new projects that
+	// don't ask for one, DefaultWorkflowName is used if it's empty.
+	DefaultWorkflow string
 }
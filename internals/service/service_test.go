@@ -6,14 +6,26 @@ import (
 	"os"
 	"testing"
 
+	"github.com/danblok/pm/internals/storage"
+	"github.com/danblok/pm/internals/types"
 	_ "github.com/lib/pq"
 )
 
+// setupService returns a service that keeps its data in the Postgres at
+// POSTGRES_URL, the test is skipped if it's not set.
 func setupService(t *testing.T) (*Service, func(...string) func()) {
-	db, err := sql.Open("postgres", os.Getenv("POSTGRES_URL"))
+	url := os.Getenv("POSTGRES_URL")
+	if url == "" {
+		t.Skip("POSTGRES_URL is not set")
+	}
+	db, err := sql.Open("postgres", url)
 	if err != nil {
 		t.Fatalf("connection to db: %s", err)
 	}
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("storage: %s", err)
+	}
 
 	cleanup := func(tables ...string) func() {
 		return func() {
@@ -25,5 +37,13 @@ func setupService(t *testing.T) (*Service, func(...string) func()) {
 			}
 		}
 	}
-	return &Service{DB: db}, cleanup
+	return &Service{DB: db, Secret: []byte("secret"), Storage: store}, cleanup
+}
+
+// pageItems returns items of p or an empty slice if p is nil.
+func pageItems[T any](p *types.Page[T]) []T {
+	if p == nil {
+		return []T{}
+	}
+	return p.Items
 }
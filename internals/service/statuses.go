@@ -4,139 +4,398 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strconv"
 
 	"github.com/danblok/pm/internals/types"
 	"github.com/google/uuid"
 )
 
+// Statuses are added with CategoryTodo unless another category is given.
 type AddStatusInput struct {
-	Name      string `json:"name"`
-	ProjectId string `json:"project_id"`
+	Name      string         `json:"name"`
+	ProjectId string         `json:"project_id"`
+	Category  types.Category `json:"category,omitempty"`
+	WipLimit  *int64         `json:"wip_limit,omitempty"`
 }
 
+// UpdateStatusInput is a JSON merge patch of a status,
+// a null WipLimit or WipLimit of 0 removes the limit.
 type UpdateStatusInput struct {
-	Id   string `param:"id"`
-	Name string `json:"name,omitempty"`
+	Id       string                      `param:"id"`
+	Name     types.Patch[string]         `json:"name" swaggertype:"string"`
+	Category types.Patch[types.Category] `json:"category" swaggertype:"string"`
+	WipLimit types.Patch[int64]          `json:"wip_limit" swaggertype:"integer" extensions:"x-nullable"`
+}
+
+// MergeStatusInput merges the status Id into the status TargetId.
+type MergeStatusInput struct {
+	Id       string `param:"id"`
+	TargetId string `json:"target_id"`
+}
+
+// categories are ordered as tasks usually move through them.
+var categories = []types.Category{
+	types.CategoryBacklog,
+	types.CategoryTodo,
+	types.CategoryInProgress,
+	types.CategoryDone,
+	types.CategoryCancelled,
+}
+
+func validCategory(c types.Category) bool {
+	for _, cat := range categories {
+		if cat == c {
+			return true
+		}
+	}
+	return false
+}
+
+const statusColumns = `id, name, project_id, category, position, wip_limit,
+	(SELECT count(*) FROM tasks WHERE tasks.status_id=statuses.id AND tasks.deleted=false),
+	done, version, deleted, created_at, updated_at, deleted_at`
+
+func scanStatus(row scanner, st *types.Status) error {
+	err := row.Scan(&st.Id, &st.Name, &st.ProjectId, &st.Category, &st.Position, &st.WipLimit, &st.TaskCount, &st.Done, &st.Version, &st.Deleted, &st.CreatedAt, &st.UpdatedAt, &st.DeletedAt)
+	if err != nil {
+		return err
+	}
+	st.OverLimit = st.WipLimit != nil && st.TaskCount > *st.WipLimit
+
+	return nil
 }
 
 // Errors returned: ErrFailedValidation, ErrInternal, ErrNotFound
 func (s *Service) GetStatusById(ctx context.Context, id string) (*types.Status, error) {
 	if _, err := uuid.Parse(id); err != nil {
-		return nil, ErrFailedValidation
+		return nil, invalid("id", "must be a valid UUID")
 	}
 
-	var st types.Status
-	query := "SELECT * FROM statuses WHERE id=$1 AND deleted=false"
-	row := s.DB.QueryRow(query, id)
-	err := row.Scan(&st.Id, &st.Name, &st.ProjectId, &st.Deleted, &st.CreatedAt, &st.UpdatedAt)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, ErrNotFound
-		}
-		return nil, ErrInternal
-	}
+	return s.statuses().GetById(ctx, id)
+}
 
-	return &st, nil
+var statusList = &listSpec[types.Status]{
+	query: "SELECT " + statusColumns + " FROM statuses",
+	sorts: map[string]sortField[types.Status]{
+		"id":         {"id", sortUUID, func(st *types.Status) string { return st.Id }},
+		"name":       {"name", sortText, func(st *types.Status) string { return st.Name }},
+		"position":   {"position", sortInt, func(st *types.Status) string { return strconv.FormatInt(st.Position, 10) }},
+		"created_at": {"created_at", sortTime, func(st *types.Status) string { return timeValue(st.CreatedAt) }},
+		"updated_at": {"updated_at", sortTime, func(st *types.Status) string { return timeValue(st.UpdatedAt) }},
+	},
+	defaultSort: "position",
+	scan:        scanStatus,
 }
 
 // Returned errors: ErrFailedValidation, ErrInternal
-func (s *Service) GetStatusesByProjectId(ctx context.Context, pId string) ([]types.Status, error) {
-	sts := make([]types.Status, 0)
+func (s *Service) GetStatusesByProjectId(ctx context.Context, pId string, params *ListParams) (*types.Page[types.Status], error) {
 	if _, err := uuid.Parse(pId); err != nil {
-		return sts, ErrFailedValidation
+		return nil, invalid("project_id", "must be a valid UUID")
 	}
-	query := "SELECT * FROM statuses WHERE project_id=$1 AND deleted=false"
-	rows, err := s.DB.QueryContext(ctx, query, pId)
-	if err != nil {
-		return nil, ErrInternal
+
+	return s.statuses().ListByProject(ctx, pId, params)
+}
+
+// Returned errors: ErrFailedValidation, ErrInternal, ErrConflict, ErrUnprocessable, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) AddStatus(ctx context.Context, input *AddStatusInput) (*types.Status, error) {
+	if input.Name == "" {
+		return nil, invalid("name", "must not be empty")
+	}
+	if _, err := uuid.Parse(input.ProjectId); err != nil {
+		return nil, invalid("project_id", "must be a valid UUID")
+	}
+	if input.Category == "" {
+		input.Category = types.CategoryTodo
+	}
+	if !validCategory(input.Category) {
+		return nil, invalid("category", "must be one of backlog, todo, in-progress, done, cancelled")
+	}
+	if input.WipLimit != nil && *input.WipLimit < 1 {
+		return nil, invalid("wip_limit", "must be positive")
+	}
+	if err := s.authorize(ctx, input.ProjectId, PermManageStatuses); err != nil {
+		return nil, err
 	}
 
-	for rows.Next() {
-		var st types.Status
+	return s.statuses().Add(ctx, input)
+}
 
-		err = rows.Scan(&st.Id, &st.Name, &st.ProjectId, &st.Deleted, &st.CreatedAt, &st.UpdatedAt)
-		if err != nil {
-			return nil, ErrInternal
+// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate, ErrPreconditionFailed, ErrConflict, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) UpdateStatus(ctx context.Context, input *UpdateStatusInput) error {
+	if _, err := uuid.Parse(input.Id); err != nil {
+		return invalid("id", "must be a valid UUID")
+	}
+	if err := notEmpty("name", input.Name); err != nil {
+		return err
+	}
+	if input.Category.Set && !validCategory(input.Category.Value) {
+		return invalid("category", "must be one of backlog, todo, in-progress, done, cancelled")
+	}
+	if input.WipLimit.Value < 0 {
+		return invalid("wip_limit", "must not be negative")
+	}
+	pId, err := s.statusProjectId(ctx, input.Id)
+	if err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, pId, PermManageStatuses); err != nil {
+		return err
+	}
+
+	return s.transact(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if err := checkETag(ctx, input.Id, s.GetStatusById); err != nil {
+			return err
 		}
 
-		sts = append(sts, st)
-	}
+		return s.statuses().Update(ctx, input)
+	})
+}
 
-	if err = rows.Err(); err != nil {
-		return nil, ErrInternal
+// Tasks of the status are moved to the end of the status targetId of the same
+// project, a status with tasks can't be deleted without a target.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate, ErrPreconditionFailed, ErrConflict, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) DeleteStatusById(ctx context.Context, id, targetId string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return invalid("id", "must be a valid UUID")
+	}
+	if targetId != "" {
+		if _, err := uuid.Parse(targetId); err != nil {
+			return invalid("target_id", "must be a valid UUID")
+		}
 	}
 
-	return sts, nil
+	return s.removeStatus(ctx, id, targetId)
 }
 
-// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToInsert
-func (s *Service) AddStatus(ctx context.Context, input *AddStatusInput) error {
-	if input.Name == "" {
-		return ErrFailedValidation
+// MergeStatus moves all tasks of the status Id to the end of the status TargetId
+// and deletes the status Id.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate, ErrPreconditionFailed, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) MergeStatus(ctx context.Context, input *MergeStatusInput) error {
+	if _, err := uuid.Parse(input.Id); err != nil {
+		return invalid("id", "must be a valid UUID")
 	}
-	if _, err := uuid.Parse(input.ProjectId); err != nil {
-		return ErrFailedValidation
+	if _, err := uuid.Parse(input.TargetId); err != nil {
+		return invalid("target_id", "must be a valid UUID")
 	}
 
-	query := "INSERT INTO statuses (name, project_id) VALUES ($1, $2)"
-	res, err := s.DB.ExecContext(ctx, query, input.Name, input.ProjectId)
-	if err != nil {
-		return ErrInternal
-	}
+	return s.removeStatus(ctx, input.Id, input.TargetId)
+}
 
-	ra, err := res.RowsAffected()
+// removeStatus moves tasks of the status id to the status targetId unless it's
+// empty and soft deletes the status with its transitions. Moved tasks aren't
+// checked against the workflow and the WIP limit of the target.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate, ErrPreconditionFailed, ErrConflict, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) removeStatus(ctx context.Context, id, targetId string) error {
+	if targetId == id {
+		return invalid("target_id", "must not be the status itself")
+	}
+	pId, err := s.statusProjectId(ctx, id)
 	if err != nil {
-		return ErrInternal
+		return err
 	}
-	if ra < 1 {
-		return ErrFailedToInsert
+	if err := s.authorize(ctx, pId, PermManageStatuses); err != nil {
+		return err
 	}
 
-	return nil
+	return s.transact(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if err := checkETag(ctx, id, s.GetStatusById); err != nil {
+			return err
+		}
+
+		return s.statuses().Delete(ctx, id, targetId)
+	})
 }
 
-// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate
-func (s *Service) UpdateStatus(ctx context.Context, input *UpdateStatusInput) error {
-	if _, err := uuid.Parse(input.Id); err != nil {
-		return ErrFailedValidation
-	}
+// PostgresStatuses keeps statuses in Postgres.
+type PostgresStatuses struct {
+	DB *sql.DB
+}
 
-	query := "UPDATE statuses SET name=COALESCE(NULLIF($1, ''), name) WHERE id::text=$2"
-	res, err := s.DB.ExecContext(ctx, query, input.Name, input.Id)
+func (r *PostgresStatuses) GetById(ctx context.Context, id string) (*types.Status, error) {
+	var st types.Status
+	query := "SELECT " + statusColumns + " FROM statuses WHERE id=$1 AND deleted=false"
+	row := conn(ctx, r.DB).QueryRowContext(ctx, query, id)
+	err := scanStatus(row, &st)
 	if err != nil {
-		return ErrInternal
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, dbError(err)
 	}
-	ra, err := res.RowsAffected()
+
+	return &st, nil
+}
+
+func (r *PostgresStatuses) ListByProject(ctx context.Context, pId string, params *ListParams) (*types.Page[types.Status], error) {
+	return list(ctx, conn(ctx, r.DB), statusList, params, []string{"project_id=$1", "deleted=false"}, []any{pId})
+}
+
+func (r *PostgresStatuses) ProjectId(ctx context.Context, id string) (string, error) {
+	return projectIdOf(ctx, conn(ctx, r.DB), "SELECT project_id FROM statuses WHERE id=$1 AND deleted=false", id)
+}
+
+func (r *PostgresStatuses) Add(ctx context.Context, input *AddStatusInput) (*types.Status, error) {
+	var st types.Status
+	query := `INSERT INTO statuses (name, project_id, category, wip_limit, position)
+		VALUES ($1, $2, $3, $4, (SELECT COALESCE(MAX(position), 0) + ` + positionGapSQL + ` FROM statuses WHERE project_id=$2 AND deleted=false))
+		RETURNING ` + statusColumns
+	row := conn(ctx, r.DB).QueryRowContext(ctx, query, input.Name, input.ProjectId, input.Category, input.WipLimit)
+	err := scanStatus(row, &st)
 	if err != nil {
-		return ErrInternal
+		return nil, dbError(err)
 	}
 
-	if ra < 1 {
-		return ErrFailedToUpdate
+	return &st, nil
+}
+
+func (r *PostgresStatuses) Update(ctx context.Context, input *UpdateStatusInput) error {
+	var u update
+	if input.Name.Set {
+		u.set("name", input.Name.Value)
+	}
+	if input.Category.Set {
+		u.set("category", input.Category.Value)
+	}
+	if input.WipLimit.Set {
+		u.setExpr("wip_limit", "NULLIF(%s::bigint, 0)", input.WipLimit.Value)
 	}
 
-	return nil
+	return u.exec(ctx, conn(ctx, r.DB), "statuses", input.Id)
 }
 
-// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate
-func (s *Service) DeleteStatusById(ctx context.Context, id string) error {
-	if _, err := uuid.Parse(id); err != nil {
-		return ErrFailedValidation
+func (r *PostgresStatuses) Delete(ctx context.Context, id, targetId string) error {
+	return transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		pId, err := r.ProjectId(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := lockProject(ctx, tx, pId); err != nil {
+			return err
+		}
+		// Tasks are only added or moved to the status after checkWipLimit locks it,
+		// so none can be added concurrently.
+		_, err = tx.ExecContext(ctx, "SELECT id FROM statuses WHERE id=$1 FOR UPDATE", id)
+		if err != nil {
+			return dbError(err)
+		}
+
+		if targetId == "" {
+			var count int64
+			err = tx.QueryRowContext(ctx, "SELECT count(*) FROM tasks WHERE status_id=$1 AND deleted=false", id).Scan(&count)
+			if err != nil {
+				return dbError(err)
+			}
+			if count > 0 {
+				return &Error{
+					Err:     ErrConflict,
+					Message: fmt.Sprintf("status has %d tasks, a target status for them is required", count),
+				}
+			}
+		} else {
+			var targetProject string
+			err = tx.QueryRowContext(ctx, "SELECT project_id FROM statuses WHERE id=$1 AND deleted=false", targetId).Scan(&targetProject)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return dbError(err)
+			}
+			if targetProject != pId {
+				return invalid("target_id", "must be a status of the same project")
+			}
+
+			// Deleted tasks are moved as well, so they can be restored to a visible status.
+			query := `UPDATE tasks t SET status_id=$2, position=m.max + o.rn * ` + positionGapSQL + `, updated_at=now()
+				FROM (SELECT id, row_number() OVER (ORDER BY position, id) AS rn FROM tasks WHERE status_id=$1) o,
+					(SELECT COALESCE(MAX(position), 0) AS max FROM tasks WHERE status_id=$2 AND deleted=false) m
+				WHERE t.id=o.id`
+			_, err = tx.ExecContext(ctx, query, id, targetId)
+			if err != nil {
+				return dbError(err)
+			}
+		}
+
+		res, err := tx.ExecContext(ctx, "UPDATE statuses SET deleted=true, deleted_at=now(), updated_at=now() WHERE id=$1 AND deleted=false", id)
+		if err != nil {
+			return dbError(err)
+		}
+		ra, err := res.RowsAffected()
+		if err != nil {
+			return dbError(err)
+		}
+
+		if ra < 1 {
+			return ErrFailedToUpdate
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM status_transitions WHERE from_status_id=$1 OR to_status_id=$1", id)
+		if err != nil {
+			return dbError(err)
+		}
+
+		return nil
+	})
+}
+
+// checkStatus checks that sId is a status of the project pId that isn't deleted.
+//
+// Returned errors: ErrFailedValidation, ErrConcurrentUpdate, ErrInternal
+func checkStatus(ctx context.Context, tx *sql.Tx, pId, sId string) error {
+	var statusProject string
+	err := tx.QueryRowContext(ctx, "SELECT project_id FROM statuses WHERE id=$1 AND deleted=false", sId).Scan(&statusProject)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return dbError(err)
+	}
+	if statusProject != pId {
+		return invalid("status_id", "must be a status of the task's project")
 	}
 
-	query := "UPDATE statuses SET deleted=true WHERE id=$1"
-	res, err := s.DB.ExecContext(ctx, query, id)
+	return nil
+}
+
+// checkWipLimit checks that the task tId can be moved to the status sId
+// without exceeding its WipLimit, tId is empty for a new task. Tasks already
+// in the status and statuses of projects without StrictWipLimits always pass.
+// The status is locked until tx ends, so concurrent moves can't exceed the limit.
+//
+// Returned errors: ErrInternal, ErrConcurrentUpdate, ErrUnprocessable
+func checkWipLimit(ctx context.Context, tx *sql.Tx, sId, tId string) error {
+	_, err := tx.ExecContext(ctx, "SELECT id FROM statuses WHERE id=$1 FOR UPDATE", sId)
 	if err != nil {
-		return ErrInternal
+		return dbError(err)
 	}
-	ra, err := res.RowsAffected()
+
+	var (
+		name   string
+		limit  sql.NullInt64
+		strict bool
+		count  int64
+		moved  bool
+	)
+	query := `SELECT s.name, s.wip_limit, p.strict_wip_limits,
+			(SELECT count(*) FROM tasks WHERE status_id=s.id AND deleted=false),
+			NOT EXISTS (SELECT 1 FROM tasks WHERE id::text=$2 AND status_id=s.id)
+		FROM statuses s JOIN projects p ON p.id=s.project_id WHERE s.id=$1`
+	err = tx.QueryRowContext(ctx, query, sId, tId).Scan(&name, &limit, &strict, &count, &moved)
 	if err != nil {
-		return ErrInternal
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return dbError(err)
 	}
-
-	if ra < 1 {
-		return ErrFailedToUpdate
+	if !strict || !limit.Valid || !moved || count < limit.Int64 {
+		return nil
 	}
 
-	return nil
+	return wipLimitReached(name, limit.Int64)
+}
+
+// wipLimitReached returns the error of moving a task to the status name at its WIP limit.
+func wipLimitReached(name string, limit int64) error {
+	return &Error{
+		Err:     ErrUnprocessable,
+		Message: fmt.Sprintf("status %q is at its WIP limit of %d tasks", name, limit),
+		Fields:  []types.FieldError{{Field: "status_id", Message: "must be below its WIP limit"}},
+	}
 }
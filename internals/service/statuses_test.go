@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/danblok/pm/internals/types"
@@ -11,8 +12,6 @@ import (
 )
 
 func TestGetStatusById(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	sId := uuid.NewString()
 	owner := types.Account{
 		Id:    uuid.NewString(),
@@ -47,35 +46,26 @@ func TestGetStatusById(t *testing.T) {
 				Id:        sId,
 				Name:      "Existing project",
 				ProjectId: project.Id,
+				Category:  types.CategoryTodo,
 			},
 		},
 	}
 
 	for name, tt := range tests {
-		_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
 		if tt.want != nil {
-			_, err = s.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", tt.want.Id, tt.want.Name, tt.want.ProjectId)
-			if err != nil {
-				t.Fatal(ErrFailedToPrepareTest, err)
-			}
+			m.PutStatus(*tt.want)
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses"))
-
 			ctx := context.Background()
 			got, err := s.GetStatusById(ctx, tt.input)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("GetStatusById() mismatch (-want +got):\n%s", diff)
 			}
-			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(types.Status{}, "CreatedAt", "UpdatedAt", "Project")); diff != "" {
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(types.Status{}, "CreatedAt", "UpdatedAt", "Version", "Project")); diff != "" {
 				t.Fatalf("GetStatusById() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -83,8 +73,6 @@ func TestGetStatusById(t *testing.T) {
 }
 
 func TestGetStatusesByOwnerId(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -114,41 +102,33 @@ func TestGetStatusesByOwnerId(t *testing.T) {
 					Id:        uuid.NewString(),
 					Name:      "Status 1",
 					ProjectId: project.Id,
+					Category:  types.CategoryTodo,
 				},
 				{
 					Id:        uuid.NewString(),
 					Name:      "Status 2",
 					ProjectId: project.Id,
+					Category:  types.CategoryTodo,
 				},
 			},
 		},
 	}
 
 	for name, tt := range tests {
-		_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
 		for _, st := range tt.want {
-			_, err = s.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", st.Id, st.Name, st.ProjectId)
-			if err != nil {
-				t.Fatal(ErrFailedToPrepareTest, err)
-			}
+			m.PutStatus(st)
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses"))
-
 			ctx := context.Background()
-			got, err := s.GetStatusesByProjectId(ctx, tt.input)
+			got, err := s.GetStatusesByProjectId(ctx, tt.input, nil)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("GetStatusesByProjectId() mismatch (-want +got):\n%s", diff)
 			}
-			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(types.Status{}, "CreatedAt", "UpdatedAt", "Project")); diff != "" {
+			if diff := cmp.Diff(tt.want, pageItems(got), cmpopts.IgnoreFields(types.Status{}, "CreatedAt", "UpdatedAt", "Version", "Project"), cmpopts.SortSlices(func(a, b types.Status) bool { return a.Id < b.Id })); diff != "" {
 				t.Fatalf("GetStatusesByProjectId() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -156,8 +136,6 @@ func TestGetStatusesByOwnerId(t *testing.T) {
 }
 
 func TestAddStatus(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -191,7 +169,15 @@ func TestAddStatus(t *testing.T) {
 				Name:      "status",
 				ProjectId: uuid.NewString(),
 			},
-			wantErr: ErrInternal,
+			wantErr: ErrNotFound,
+		},
+		"invalid category": {
+			input: &AddStatusInput{
+				Name:      "status",
+				ProjectId: project.Id,
+				Category:  "finished",
+			},
+			wantErr: ErrFailedValidation,
 		},
 		"sucsessfull add": {
 			input: &AddStatusInput{
@@ -203,30 +189,30 @@ func TestAddStatus(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses"))
-
-			ctx := context.Background()
-			err := s.AddStatus(ctx, tt.input)
+			ctx := WithAccountId(context.Background(), owner.Id)
+			got, err := s.AddStatus(ctx, tt.input)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("AddStatus() mismatch (-want +got):\n%s", diff)
 			}
+			if err != nil {
+				return
+			}
+			if _, err := uuid.Parse(got.Id); err != nil {
+				t.Fatalf("AddStatus() returned invalid id %q", got.Id)
+			}
+			if diff := cmp.Diff(tt.input.Name, got.Name); diff != "" {
+				t.Fatalf("AddStatus() mismatch (-want +got):\n%s", diff)
+			}
 		})
 	}
 }
 
 func TestUpdateStatus(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -250,44 +236,34 @@ func TestUpdateStatus(t *testing.T) {
 		"invalid id": {
 			input: &UpdateStatusInput{
 				Id:   "invalid-id",
-				Name: "New status name",
+				Name: types.PatchOf("New status name"),
 			},
 			wantErr: ErrFailedValidation,
 		},
 		"non-existent status id": {
 			input: &UpdateStatusInput{
 				Id:   uuid.NewString(),
-				Name: "New status name",
+				Name: types.PatchOf("New status name"),
 			},
-			wantErr: ErrFailedToUpdate,
+			wantErr: ErrNotFound,
 		},
 		"sucsessfull update": {
 			input: &UpdateStatusInput{
 				Id:   status.Id,
-				Name: "New status name",
+				Name: types.PatchOf("New status name"),
 			},
 			wantErr: nil,
 		},
 	}
 
 	for name, tt := range tests {
-		_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", status.Id, status.Name, status.ProjectId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(status)
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses"))
-
-			ctx := context.Background()
+			ctx := WithAccountId(context.Background(), owner.Id)
 			err := s.UpdateStatus(ctx, tt.input)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("UpdateStatus() mismatch (-want +got):\n%s", diff)
@@ -297,8 +273,6 @@ func TestUpdateStatus(t *testing.T) {
 }
 
 func TestDeleteStatusById(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -315,9 +289,16 @@ func TestDeleteStatusById(t *testing.T) {
 		Name:      "in progress",
 		ProjectId: project.Id,
 	}
+	busy := types.Status{
+		Id:        uuid.NewString(),
+		Name:      "review",
+		ProjectId: project.Id,
+	}
+	taskId := uuid.NewString()
 	tests := map[string]struct {
 		wantErr error
 		input   string
+		target  string
 	}{
 		"invalid id": {
 			input:   "invalid-id",
@@ -325,35 +306,215 @@ func TestDeleteStatusById(t *testing.T) {
 		},
 		"non-existent status id": {
 			input:   uuid.NewString(),
-			wantErr: ErrFailedToUpdate,
+			wantErr: ErrNotFound,
+		},
+		"tasks without target": {
+			input:   busy.Id,
+			wantErr: ErrConflict,
+		},
+		"target is itself": {
+			input:   busy.Id,
+			target:  busy.Id,
+			wantErr: ErrFailedValidation,
+		},
+		"non-existent target": {
+			input:   busy.Id,
+			target:  uuid.NewString(),
+			wantErr: ErrFailedValidation,
 		},
 		"sucsessfull delete": {
 			input:   status.Id,
 			wantErr: nil,
 		},
+		"sucsessfull delete with target": {
+			input:   busy.Id,
+			target:  status.Id,
+			wantErr: nil,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(status)
+		m.PutStatus(busy)
+		m.PutTask(types.Task{Id: taskId, Name: "task", ProjectId: project.Id, StatusId: busy.Id})
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), owner.Id)
+			err := s.DeleteStatusById(ctx, tt.input, tt.target)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("DeleteStatusById() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil || tt.target == "" {
+				return
+			}
+			got, err := s.GetTaskById(ctx, taskId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.target, got.StatusId); diff != "" {
+				t.Fatalf("DeleteStatusById() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMergeStatus(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "username",
+		Email: "username@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	other := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "other",
+		OwnerId: owner.Id,
+	}
+	sourceId := uuid.NewString()
+	targetId := uuid.NewString()
+	foreignId := uuid.NewString()
+	tests := map[string]struct {
+		wantErr error
+		input   *MergeStatusInput
+	}{
+		"no target": {
+			input:   &MergeStatusInput{Id: sourceId},
+			wantErr: ErrFailedValidation,
+		},
+		"target of another project": {
+			input:   &MergeStatusInput{Id: sourceId, TargetId: foreignId},
+			wantErr: ErrFailedValidation,
+		},
+		"sucsessfull merge": {
+			input:   &MergeStatusInput{Id: sourceId, TargetId: targetId},
+			wantErr: nil,
+		},
 	}
 
 	for name, tt := range tests {
-		_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutProject(other)
+		m.PutStatus(types.Status{Id: sourceId, Name: "source", ProjectId: project.Id})
+		m.PutStatus(types.Status{Id: targetId, Name: "target", ProjectId: project.Id})
+		m.PutStatus(types.Status{Id: foreignId, Name: "foreign", ProjectId: other.Id})
+		for i := int64(1); i <= 2; i++ {
+			m.PutTask(types.Task{Name: "task", ProjectId: project.Id, StatusId: sourceId, Position: i})
+			m.PutTask(types.Task{Name: "task", ProjectId: project.Id, StatusId: targetId, Position: i})
 		}
-		_, err = s.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), owner.Id)
+			err := s.MergeStatus(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("MergeStatus() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if _, err := s.GetStatusById(ctx, sourceId); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("MergeStatus() didn't delete the source status: %v", err)
+			}
+			got, err := s.GetStatusById(ctx, targetId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(int64(4), got.TaskCount); diff != "" {
+				t.Fatalf("MergeStatus() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWipLimit(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	statusId := uuid.NewString()
+	tests := map[string]struct {
+		wantErr       error
+		strict        bool
+		limit         int64
+		wantCount     int64
+		wantOverLimit bool
+	}{
+		"no limit": {
+			strict:    true,
+			wantCount: 3,
+		},
+		"below limit": {
+			strict:    true,
+			limit:     3,
+			wantCount: 3,
+		},
+		"rejected at limit": {
+			strict:    true,
+			limit:     2,
+			wantErr:   ErrUnprocessable,
+			wantCount: 2,
+		},
+		"flagged over limit": {
+			strict:        false,
+			limit:         2,
+			wantCount:     3,
+			wantOverLimit: true,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		pj := project
+		pj.StrictWipLimits = tt.strict
+		m.PutProject(pj)
+		st := types.Status{Id: statusId, Name: "doing", ProjectId: project.Id}
+		if tt.limit != 0 {
+			st.WipLimit = &tt.limit
 		}
-		_, err = s.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", status.Id, status.Name, status.ProjectId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
+		m.PutStatus(st)
+		for i := 0; i < 2; i++ {
+			m.PutTask(types.Task{Name: "task", ProjectId: project.Id, StatusId: statusId})
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses"))
-
-			ctx := context.Background()
-			err := s.DeleteStatusById(ctx, tt.input)
+			ctx := WithAccountId(context.Background(), owner.Id)
+			input := &AddTaskInput{
+				Name:      "task",
+				ProjectId: project.Id,
+				StatusId:  statusId,
+				Start:     "2024-01-01 00:00:00",
+				End:       "2024-01-02 00:00:00",
+			}
+			_, err := s.AddTask(ctx, input)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
-				t.Fatalf("UpdateStatus() mismatch (-want +got):\n%s", diff)
+				t.Fatalf("AddTask() mismatch (-want +got):\n%s", diff)
+			}
+
+			got, err := s.GetStatusesByProjectId(ctx, project.Id, &ListParams{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Items) != 1 {
+				t.Fatalf("GetStatusesByProjectId() returned %d statuses, want 1", len(got.Items))
+			}
+			st := got.Items[0]
+			if st.TaskCount != tt.wantCount || st.OverLimit != tt.wantOverLimit {
+				t.Fatalf("GetStatusesByProjectId() task_count=%d over_limit=%v, want %d %v", st.TaskCount, st.OverLimit, tt.wantCount, tt.wantOverLimit)
 			}
 		})
 	}
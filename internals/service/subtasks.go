@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/uuid"
+)
+
+// subtreeIds selects ids of the non-deleted task $1 and all of its
+// non-deleted subtasks at any depth.
+const subtreeIds = `WITH RECURSIVE subtree(id) AS (
+		SELECT id FROM tasks WHERE id=$1 AND deleted=false
+		UNION
+		SELECT t.id FROM tasks t JOIN subtree ON t.parent_id=subtree.id WHERE t.deleted=false
+	) SELECT id FROM subtree`
+
+// Returned errors: ErrFailedValidation, ErrInternal
+func (s *Service) GetTaskChildren(ctx context.Context, id string, params *ListParams) (*types.Page[types.Task], error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, invalid("id", "must be a valid UUID")
+	}
+
+	return s.tasks().ListChildren(ctx, id, params)
+}
+
+// GetTaskTree returns the task with all of its subtasks nested in Children,
+// every task of the tree has its Progress.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrNotFound
+func (s *Service) GetTaskTree(ctx context.Context, id string) (*types.Task, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, invalid("id", "must be a valid UUID")
+	}
+
+	return s.tasks().Tree(ctx, id)
+}
+
+// buildTree nests tasks into the tree of the task id and rolls up
+// the completion of subtasks given by done into Progress.
+func buildTree(id string, tasks []types.Task, done map[string]bool) (*types.Task, bool) {
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].CreatedAt.Equal(tasks[j].CreatedAt) {
+			return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+		}
+		return tasks[i].Id < tasks[j].Id
+	})
+
+	var root *types.Task
+	children := make(map[string][]*types.Task)
+	for i := range tasks {
+		t := &tasks[i]
+		if t.Id == id {
+			root = t
+			continue
+		}
+		children[t.ParentId] = append(children[t.ParentId], t)
+	}
+	if root == nil {
+		return nil, false
+	}
+
+	var build func(t *types.Task) types.Task
+	build = func(t *types.Task) types.Task {
+		node := *t
+		node.Progress = &types.Progress{}
+		for _, c := range children[t.Id] {
+			child := build(c)
+			node.Children = append(node.Children, child)
+			node.Progress.Total += child.Progress.Total + 1
+			node.Progress.Done += child.Progress.Done
+			if done[c.Id] {
+				node.Progress.Done++
+			}
+		}
+		return node
+	}
+
+	tree := build(root)
+	return &tree, true
+}
+
+func (r *PostgresTasks) ListChildren(ctx context.Context, id string, params *ListParams) (*types.Page[types.Task], error) {
+	return list(ctx, conn(ctx, r.DB), taskList, params, []string{"parent_id=$1", "deleted=false"}, []any{id})
+}
+
+func (r *PostgresTasks) Tree(ctx context.Context, id string) (*types.Task, error) {
+	query := "SELECT " + taskColumns + ", (SELECT done FROM statuses WHERE statuses.id=tasks.status_id) FROM tasks WHERE id IN (" + subtreeIds + ")"
+	rows, err := conn(ctx, r.DB).QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	tasks := make([]types.Task, 0)
+	done := make(map[string]bool)
+	for rows.Next() {
+		var (
+			t types.Task
+			d sql.NullBool
+		)
+		err = rows.Scan(append(taskFields(&t), &d)...)
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		tasks = append(tasks, t)
+		done[t.Id] = d.Bool
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	root, ok := buildTree(id, tasks, done)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return root, nil
+}
+
+// taskProgress returns the completion of all subtasks of the task id.
+//
+// Returned errors: ErrInternal
+func taskProgress(ctx context.Context, db querier, id string) (*types.Progress, error) {
+	var p types.Progress
+	query := `SELECT count(*), count(*) FILTER (WHERE s.done)
+		FROM tasks t JOIN statuses s ON s.id=t.status_id
+		WHERE t.id IN (` + subtreeIds + `) AND t.id<>$1`
+	err := db.QueryRowContext(ctx, query, id).Scan(&p.Total, &p.Done)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	return &p, nil
+}
+
+// checkParent checks that the task parentId can be the parent of the task tId
+// of the project pId. tId is empty for a new task.
+//
+// Returned errors: ErrFailedValidation, ErrConcurrentUpdate, ErrInternal
+func checkParent(ctx context.Context, tx *sql.Tx, pId, tId, parentId string) error {
+	if err := lockProject(ctx, tx, pId); err != nil {
+		return err
+	}
+
+	var parentProject string
+	err := tx.QueryRowContext(ctx, "SELECT project_id FROM tasks WHERE id=$1 AND deleted=false", parentId).Scan(&parentProject)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return dbError(err)
+	}
+	if parentProject != pId {
+		return invalid("parent_id", "must be a task of the same project")
+	}
+	if tId == "" {
+		return nil
+	}
+
+	// The task can't become a subtask of itself or of any of its subtasks.
+	var cycle bool
+	query := `WITH RECURSIVE ancestors(id, parent_id) AS (
+			SELECT id, parent_id FROM tasks WHERE id=$1
+			UNION
+			SELECT t.id, t.parent_id FROM tasks t JOIN ancestors a ON t.id=a.parent_id
+		) SELECT EXISTS (SELECT 1 FROM ancestors WHERE id=$2)`
+	err = tx.QueryRowContext(ctx, query, parentId, tId).Scan(&cycle)
+	if err != nil {
+		return dbError(err)
+	}
+	if cycle {
+		return invalid("parent_id", "must not be the task itself or one of its subtasks")
+	}
+
+	return nil
+}
+
+// lockProject serializes changes of the task graph of the project pId
+// until tx ends, so concurrent changes can't create a cycle.
+//
+// Returned errors: ErrInternal, ErrConcurrentUpdate
+func lockProject(ctx context.Context, tx *sql.Tx, pId string) error {
+	_, err := tx.ExecContext(ctx, "SELECT id FROM projects WHERE id=$1 FOR NO KEY UPDATE", pId)
+	if err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+)
+
+func TestBuildTree(t *testing.T) {
+	now := time.Now()
+	tasks := []types.Task{
+		{Id: "leaf", ParentId: "child-1", CreatedAt: now.Add(3 * time.Second)},
+		{Id: "child-2", ParentId: "root", CreatedAt: now.Add(2 * time.Second)},
+		{Id: "child-1", ParentId: "root", CreatedAt: now.Add(time.Second)},
+		{Id: "root", ParentId: "epic", CreatedAt: now},
+	}
+	done := map[string]bool{"leaf": true, "child-2": true}
+
+	want := &types.Task{
+		Id:       "root",
+		ParentId: "epic",
+		Progress: &types.Progress{Total: 3, Done: 2},
+		Children: []types.Task{
+			{
+				Id:       "child-1",
+				ParentId: "root",
+				Progress: &types.Progress{Total: 1, Done: 1},
+				Children: []types.Task{
+					{Id: "leaf", ParentId: "child-1", Progress: &types.Progress{}},
+				},
+			},
+			{Id: "child-2", ParentId: "root", Progress: &types.Progress{}},
+		},
+	}
+
+	got, ok := buildTree("root", tasks, done)
+	if !ok {
+		t.Fatal("buildTree() didn't find the root")
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(types.Task{}, "CreatedAt")); diff != "" {
+		t.Fatalf("buildTree() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, ok := buildTree("missing", tasks, done); ok {
+		t.Fatal("buildTree() found a missing root")
+	}
+}
+
+func TestUpdateTaskParent(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	other := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "other",
+		OwnerId: owner.Id,
+	}
+	statusId := uuid.NewString()
+	otherStatusId := uuid.NewString()
+	// epic <- story <- subtask
+	epic := types.Task{Id: uuid.NewString(), Name: "epic", ProjectId: project.Id, StatusId: statusId}
+	story := types.Task{Id: uuid.NewString(), Name: "story", ProjectId: project.Id, StatusId: statusId, ParentId: epic.Id}
+	subtask := types.Task{Id: uuid.NewString(), Name: "subtask", ProjectId: project.Id, StatusId: statusId, ParentId: story.Id}
+	foreign := types.Task{Id: uuid.NewString(), Name: "foreign", ProjectId: other.Id, StatusId: otherStatusId}
+	tests := map[string]struct {
+		wantErr error
+		id      string
+		parent  types.Patch[string]
+	}{
+		"parent is itself": {
+			id:      epic.Id,
+			parent:  types.PatchOf(epic.Id),
+			wantErr: ErrFailedValidation,
+		},
+		"parent is a subtask": {
+			id:      epic.Id,
+			parent:  types.PatchOf(subtask.Id),
+			wantErr: ErrFailedValidation,
+		},
+		"parent of another project": {
+			id:      subtask.Id,
+			parent:  types.PatchOf(foreign.Id),
+			wantErr: ErrFailedValidation,
+		},
+		"succsessfull reparent": {
+			id:      subtask.Id,
+			parent:  types.PatchOf(epic.Id),
+			wantErr: nil,
+		},
+		"succsessfull detach": {
+			id:      story.Id,
+			parent:  types.PatchNull[string](),
+			wantErr: nil,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutProject(other)
+		m.PutStatus(types.Status{Id: statusId, Name: "todo", ProjectId: project.Id})
+		m.PutStatus(types.Status{Id: otherStatusId, Name: "todo", ProjectId: other.Id})
+		for _, tk := range []types.Task{epic, story, subtask, foreign} {
+			m.PutTask(tk)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), owner.Id)
+			input := &UpdateTaskInput{
+				Id:       tt.id,
+				StatusId: types.PatchOf(statusId),
+				Start:    types.PatchOf("2024-01-01 00:00:00"),
+				End:      types.PatchOf("2024-01-02 00:00:00"),
+				ParentId: tt.parent,
+			}
+			err := s.UpdateTask(ctx, input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("UpdateTask() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			got, err := s.GetTaskById(ctx, tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.parent.Value, got.ParentId); diff != "" {
+				t.Fatalf("UpdateTask() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetTaskTree(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	todoId := uuid.NewString()
+	doneId := uuid.NewString()
+	epic := types.Task{Id: uuid.NewString(), Name: "epic", ProjectId: project.Id, StatusId: todoId}
+	story := types.Task{Id: uuid.NewString(), Name: "story", ProjectId: project.Id, StatusId: todoId, ParentId: epic.Id}
+	subtask := types.Task{Id: uuid.NewString(), Name: "subtask", ProjectId: project.Id, StatusId: doneId, ParentId: story.Id}
+	tests := map[string]struct {
+		wantErr      error
+		input        string
+		wantProgress *types.Progress
+	}{
+		"invalid id": {
+			input:   "invalid-id",
+			wantErr: ErrFailedValidation,
+		},
+		"non-existent": {
+			input:   uuid.NewString(),
+			wantErr: ErrNotFound,
+		},
+		"epic": {
+			input:        epic.Id,
+			wantProgress: &types.Progress{Total: 2, Done: 1},
+		},
+		"story": {
+			input:        story.Id,
+			wantProgress: &types.Progress{Total: 1, Done: 1},
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: todoId, Name: "todo", ProjectId: project.Id, Category: types.CategoryTodo})
+		m.PutStatus(types.Status{Id: doneId, Name: "done", ProjectId: project.Id, Category: types.CategoryDone})
+		for _, tk := range []types.Task{epic, story, subtask} {
+			m.PutTask(tk)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			got, err := s.GetTaskTree(context.Background(), tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("GetTaskTree() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.wantProgress, got.Progress); diff != "" {
+				t.Fatalf("GetTaskTree() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -4,199 +4,465 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/danblok/pm/internals/types"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type AddTaskInput struct {
-	Start     string `json:"start"`
-	End       string `json:"end"`
-	Name      string `json:"name"`
-	ProjectId string `json:"project_id"`
-	StatusId  string `json:"status_id"`
+	Start       string         `json:"start"`
+	End         string         `json:"end"`
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Priority    types.Priority `json:"priority,omitempty"`
+	ProjectId   string         `json:"project_id"`
+	StatusId    string         `json:"status_id"`
+	ParentId    string         `json:"parent_id,omitempty"`
+	LabelIds    []string       `json:"label_ids,omitempty"`
 }
 
+// UpdateTaskInput is a JSON merge patch of a task. A null description is
+// cleared, a null priority becomes none, a null ParentId makes the task
+// top-level and null LabelIds remove all labels of the task.
 type UpdateTaskInput struct {
-	Start    string `json:"start,omitempty"`
-	End      string `json:"end,omitempty"`
-	Id       string `param:"id"`
-	Name     string `json:"name,omitempty"`
-	StatusId string `json:"status_id"`
+	Start       types.Patch[string]         `json:"start" swaggertype:"string"`
+	End         types.Patch[string]         `json:"end" swaggertype:"string"`
+	Id          string                      `param:"id"`
+	Name        types.Patch[string]         `json:"name" swaggertype:"string"`
+	Description types.Patch[string]         `json:"description" swaggertype:"string" extensions:"x-nullable"`
+	Priority    types.Patch[types.Priority] `json:"priority" swaggertype:"string" extensions:"x-nullable"`
+	StatusId    types.Patch[string]         `json:"status_id" swaggertype:"string"`
+	ParentId    types.Patch[string]         `json:"parent_id" swaggertype:"string" extensions:"x-nullable"`
+	LabelIds    types.Patch[[]string]       `json:"label_ids" swaggertype:"array,string" extensions:"x-nullable"`
 }
 
-// Errors returned: ErrFailedValidation, ErrInternal, ErrNotFound
-func (s *Service) GetTaskById(ctx context.Context, id string) (*types.Task, error) {
-	if _, err := uuid.Parse(id); err != nil {
-		return nil, ErrFailedValidation
-	}
+// priorities are ordered from the lowest to the highest.
+var priorities = []types.Priority{
+	types.PriorityNone,
+	types.PriorityLow,
+	types.PriorityMedium,
+	types.PriorityHigh,
+	types.PriorityUrgent,
+}
 
-	var t types.Task
-	query := "SELECT * FROM tasks WHERE id=$1 AND deleted=false"
-	row := s.DB.QueryRow(query, id)
-	err := row.Scan(&t.Id, &t.Name, &t.Start, &t.End, &t.StatusId, &t.ProjectId, &t.Deleted, &t.CreatedAt, &t.UpdatedAt)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, ErrNotFound
+// priorityRank returns the position of p in priorities starting from 1
+// like array_position in the priority sort column, or 0 if p is unknown.
+func priorityRank(p types.Priority) int {
+	for i, pr := range priorities {
+		if pr == p {
+			return i + 1
 		}
-		return nil, ErrInternal
 	}
+	return 0
+}
 
-	return &t, nil
+const taskColumns = "id, name, description, priority, \"start\", \"end\", status_id, project_id, COALESCE(parent_id::text, ''), position, version, deleted, created_at, updated_at, deleted_at"
+
+// taskFields returns pointers to the fields of t in order of taskColumns.
+func taskFields(t *types.Task) []any {
+	return []any{&t.Id, &t.Name, &t.Description, &t.Priority, &t.Start, &t.End, &t.StatusId, &t.ProjectId, &t.ParentId, &t.Position, &t.Version, &t.Deleted, &t.CreatedAt, &t.UpdatedAt, &t.DeletedAt}
 }
 
-// Returned errors: ErrFailedValidation, ErrInternal
-func (s *Service) GetTasksByProjectId(ctx context.Context, pId string) ([]types.Task, error) {
-	ts := make([]types.Task, 0)
-	if _, err := uuid.Parse(pId); err != nil {
-		return ts, ErrFailedValidation
-	}
-	query := "SELECT * FROM tasks WHERE project_id=$1 AND deleted=false"
-	rows, err := s.DB.QueryContext(ctx, query, pId)
-	if err != nil {
-		return nil, ErrInternal
+func scanTask(row scanner, t *types.Task) error {
+	return row.Scan(taskFields(t)...)
+}
+
+// Errors returned: ErrFailedValidation, ErrInternal, ErrNotFound
+func (s *Service) GetTaskById(ctx context.Context, id string) (*types.Task, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, invalid("id", "must be a valid UUID")
 	}
 
-	for rows.Next() {
-		var st types.Task
+	return s.tasks().GetById(ctx, id)
+}
 
-		err = rows.Scan(&st.Id, &st.Name, &st.Start, &st.End, &st.StatusId, &st.ProjectId, &st.Deleted, &st.CreatedAt, &st.UpdatedAt)
-		if err != nil {
-			return nil, ErrInternal
-		}
+var taskList = &listSpec[types.Task]{
+	query: "SELECT " + taskColumns + " FROM tasks",
+	sorts: map[string]sortField[types.Task]{
+		"id":         {"id", sortUUID, func(t *types.Task) string { return t.Id }},
+		"name":       {"name", sortText, func(t *types.Task) string { return t.Name }},
+		"position":   {"position", sortInt, func(t *types.Task) string { return strconv.FormatInt(t.Position, 10) }},
+		"priority":   {"array_position(ARRAY['none', 'low', 'medium', 'high', 'urgent'], priority)", sortInt, func(t *types.Task) string { return strconv.Itoa(priorityRank(t.Priority)) }},
+		"start":      {"\"start\"", sortTime, func(t *types.Task) string { return timeValue(t.Start) }},
+		"end":        {"\"end\"", sortTime, func(t *types.Task) string { return timeValue(t.End) }},
+		"created_at": {"created_at", sortTime, func(t *types.Task) string { return timeValue(t.CreatedAt) }},
+		"updated_at": {"updated_at", sortTime, func(t *types.Task) string { return timeValue(t.UpdatedAt) }},
+	},
+	filter: filterTasks,
+	scan:   scanTask,
+}
 
-		ts = append(ts, st)
+// Returned errors: ErrFailedValidation
+func filterTasks(params *ListParams, arg func(any) string) ([]string, error) {
+	conds := make([]string, 0)
+	if params.StatusId != "" {
+		if _, err := uuid.Parse(params.StatusId); err != nil {
+			return nil, invalid("status_id", "must be a valid UUID")
+		}
+		conds = append(conds, "status_id="+arg(params.StatusId))
 	}
-
-	if err = rows.Err(); err != nil {
-		return nil, ErrInternal
+	if params.Priority != "" {
+		ps := strings.Split(params.Priority, ",")
+		for _, p := range ps {
+			if priorityRank(types.Priority(p)) == 0 {
+				return nil, invalid("priority", "must be a list of none, low, medium, high, urgent")
+			}
+		}
+		conds = append(conds, "priority = ANY("+arg(pq.Array(ps))+")")
+	}
+	if params.LabelId != "" {
+		if _, err := uuid.Parse(params.LabelId); err != nil {
+			return nil, invalid("label_id", "must be a valid UUID")
+		}
+		conds = append(conds, "id IN (SELECT task_id FROM tasks_to_labels WHERE label_id="+arg(params.LabelId)+")")
 	}
 
-	return ts, nil
+	return conds, nil
 }
 
 // Returned errors: ErrFailedValidation, ErrInternal
-func (s *Service) GetTasksOfProjectByStatusId(ctx context.Context, pId, sId string) ([]types.Task, error) {
-	ts := make([]types.Task, 0)
+func (s *Service) GetTasksByProjectId(ctx context.Context, pId string, params *ListParams) (*types.Page[types.Task], error) {
 	if _, err := uuid.Parse(pId); err != nil {
-		return ts, ErrFailedValidation
-	}
-	if _, err := uuid.Parse(sId); err != nil {
-		return ts, ErrFailedValidation
-	}
-	query := "SELECT * FROM tasks WHERE project_id=$1 AND status_id=$2 AND deleted=false"
-	rows, err := s.DB.QueryContext(ctx, query, pId, sId)
-	if err != nil {
-		return nil, ErrInternal
+		return nil, invalid("project_id", "must be a valid UUID")
 	}
 
-	for rows.Next() {
-		var st types.Task
-
-		err = rows.Scan(&st.Id, &st.Name, &st.Start, &st.End, &st.StatusId, &st.ProjectId, &st.Deleted, &st.CreatedAt, &st.UpdatedAt)
-		if err != nil {
-			return nil, ErrInternal
-		}
+	return s.tasks().ListByProject(ctx, pId, params)
+}
 
-		ts = append(ts, st)
+// GetTasksOfProjectByStatusId is GetTasksByProjectId filtered by the status sId,
+// tasks are sorted by their position in the status by default.
+//
+// Returned errors: ErrFailedValidation, ErrInternal
+func (s *Service) GetTasksOfProjectByStatusId(ctx context.Context, pId, sId string, params *ListParams) (*types.Page[types.Task], error) {
+	if _, err := uuid.Parse(sId); err != nil {
+		return nil, invalid("status_id", "must be a valid UUID")
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, ErrInternal
+	p := ListParams{}
+	if params != nil {
+		p = *params
 	}
-
-	return ts, nil
+	p.StatusId = sId
+	if p.Sort == "" {
+		p.Sort = "position"
+	}
+	return s.GetTasksByProjectId(ctx, pId, &p)
 }
 
-// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToInsert
-func (s *Service) AddTask(ctx context.Context, input *AddTaskInput) error {
+// Tasks are added with PriorityNone unless another priority is given.
+// The status must be a status of the project and a subtask must be in
+// the same project as its parent. In projects with StrictWipLimits
+// the status must be below its WIP limit.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrUnprocessable, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) AddTask(ctx context.Context, input *AddTaskInput) (*types.Task, error) {
 	if input.Name == "" {
-		return ErrFailedValidation
+		return nil, invalid("name", "must not be empty")
 	}
 	if _, err := uuid.Parse(input.ProjectId); err != nil {
-		return ErrFailedValidation
+		return nil, invalid("project_id", "must be a valid UUID")
 	}
 	if _, err := uuid.Parse(input.StatusId); err != nil {
-		return ErrFailedValidation
+		return nil, invalid("status_id", "must be a valid UUID")
+	}
+	if input.ParentId != "" {
+		if _, err := uuid.Parse(input.ParentId); err != nil {
+			return nil, invalid("parent_id", "must be a valid UUID")
+		}
 	}
 	start, err := time.Parse(time.DateTime, input.Start)
 	if err != nil {
-		return ErrFailedValidation
+		return nil, invalid("start", "must be in format \"2006-01-02 15:04:05\"")
 	}
 	end, err := time.Parse(time.DateTime, input.End)
 	if err != nil {
-		return ErrFailedValidation
+		return nil, invalid("end", "must be in format \"2006-01-02 15:04:05\"")
 	}
 	if end.Before(start) {
-		return ErrFailedValidation
+		return nil, invalid("end", "must not be before start")
 	}
-
-	query := "INSERT INTO tasks (name, \"start\", \"end\", project_id, status_id) VALUES ($1, $2, $3, $4, $5)"
-	res, err := s.DB.ExecContext(ctx, query, input.Name, start.UTC(), end.UTC(), input.ProjectId, input.StatusId)
-	if err != nil {
-		return ErrInternal
+	if input.Priority == "" {
+		input.Priority = types.PriorityNone
 	}
-
-	ra, err := res.RowsAffected()
-	if err != nil {
-		return ErrInternal
+	if priorityRank(input.Priority) == 0 {
+		return nil, invalid("priority", "must be one of none, low, medium, high, urgent")
 	}
-	if ra < 1 {
-		return ErrFailedToInsert
+	if err := validLabelIds(input.LabelIds); err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, input.ProjectId, PermManageTasks); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return s.tasks().Add(ctx, input, start, end)
 }
 
-// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate
+// In projects with StrictDependencies the task can't overlap with the tasks it depends on.
+// The task can only change to a status of its project the workflow allows
+// and, in projects with StrictWipLimits, if the status is below its WIP limit.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate, ErrPreconditionFailed, ErrUnprocessable, ErrNotFound, ErrUnauthorized, ErrForbidden
 func (s *Service) UpdateTask(ctx context.Context, input *UpdateTaskInput) error {
 	if _, err := uuid.Parse(input.Id); err != nil {
-		return ErrFailedValidation
+		return invalid("id", "must be a valid UUID")
 	}
-	if _, err := uuid.Parse(input.StatusId); err != nil {
-		return ErrFailedValidation
+	if err := notEmpty("name", input.Name); err != nil {
+		return err
 	}
-	start, err := time.Parse(time.DateTime, input.Start)
+	if input.StatusId.Set {
+		if _, err := uuid.Parse(input.StatusId.Value); err != nil {
+			return invalid("status_id", "must be a valid UUID")
+		}
+	}
+	var start, end time.Time
+	if input.Start.Set {
+		var err error
+		if start, err = time.Parse(time.DateTime, input.Start.Value); err != nil {
+			return invalid("start", "must be in format \"2006-01-02 15:04:05\"")
+		}
+	}
+	if input.End.Set {
+		var err error
+		if end, err = time.Parse(time.DateTime, input.End.Value); err != nil {
+			return invalid("end", "must be in format \"2006-01-02 15:04:05\"")
+		}
+	}
+	if input.Priority.Null {
+		input.Priority.Value = types.PriorityNone
+	}
+	if input.Priority.Set && priorityRank(input.Priority.Value) == 0 {
+		return invalid("priority", "must be one of none, low, medium, high, urgent")
+	}
+	if input.ParentId.Set && !input.ParentId.Null {
+		if _, err := uuid.Parse(input.ParentId.Value); err != nil {
+			return invalid("parent_id", "must be a valid UUID")
+		}
+	}
+	if err := validLabelIds(input.LabelIds.Value); err != nil {
+		return err
+	}
+	pId, err := s.taskProjectId(ctx, input.Id)
 	if err != nil {
-		return ErrFailedValidation
+		return err
 	}
-	end, err := time.Parse(time.DateTime, input.End)
-	if err != nil || end.Before(start) {
-		return ErrFailedValidation
+	if err := s.authorize(ctx, pId, PermManageTasks); err != nil {
+		return err
 	}
 
-	query := "UPDATE tasks SET name=COALESCE(NULLIF($1, ''), name), \"start\"=$2, \"end\"=$3, status_id=$4 WHERE id::text=$5"
-	res, err := s.DB.ExecContext(ctx, query, input.Name, start, end, input.StatusId, input.Id)
+	return s.transact(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if err := checkETag(ctx, input.Id, s.GetTaskById); err != nil {
+			return err
+		}
+
+		return s.tasks().Update(ctx, input, start, end)
+	})
+}
+
+// Subtasks are deleted with their parent.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate, ErrPreconditionFailed, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) DeleteTaskById(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return invalid("id", "must be a valid UUID")
+	}
+	pId, err := s.taskProjectId(ctx, id)
 	if err != nil {
-		return ErrInternal
+		return err
 	}
-	ra, err := res.RowsAffected()
+	if err := s.authorize(ctx, pId, PermManageTasks); err != nil {
+		return err
+	}
+
+	return s.transact(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if err := checkETag(ctx, id, s.GetTaskById); err != nil {
+			return err
+		}
+
+		return s.tasks().Delete(ctx, id)
+	})
+}
+
+// PostgresTasks keeps tasks in Postgres.
+type PostgresTasks struct {
+	DB *sql.DB
+}
+
+func (r *PostgresTasks) GetById(ctx context.Context, id string) (*types.Task, error) {
+	db := conn(ctx, r.DB)
+	var t types.Task
+	query := "SELECT " + taskColumns + " FROM tasks WHERE id=$1 AND deleted=false"
+	err := scanTask(db.QueryRowContext(ctx, query, id), &t)
 	if err != nil {
-		return ErrInternal
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, dbError(err)
 	}
 
-	if ra < 1 {
-		return ErrFailedToUpdate
+	t.Assignees, err = taskAssignees(ctx, db, t.Id)
+	if err != nil {
+		return nil, err
+	}
+	t.Labels, err = taskLabels(ctx, db, t.Id)
+	if err != nil {
+		return nil, err
+	}
+	t.Progress, err = taskProgress(ctx, db, t.Id)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &t, nil
 }
 
-// Returned errors: ErrFailedValidation, ErrInternal, ErrFailedToUpdate
-func (s *Service) DeleteTaskById(ctx context.Context, id string) error {
-	if _, err := uuid.Parse(id); err != nil {
-		return ErrFailedValidation
+func (r *PostgresTasks) ListByProject(ctx context.Context, pId string, params *ListParams) (*types.Page[types.Task], error) {
+	return list(ctx, conn(ctx, r.DB), taskList, params, []string{"project_id=$1", "deleted=false"}, []any{pId})
+}
+
+func (r *PostgresTasks) ProjectId(ctx context.Context, id string) (string, error) {
+	return projectIdOf(ctx, conn(ctx, r.DB), "SELECT project_id FROM tasks WHERE id=$1 AND deleted=false", id)
+}
+
+func (r *PostgresTasks) Add(ctx context.Context, input *AddTaskInput, start, end time.Time) (*types.Task, error) {
+	var t types.Task
+	err := transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		if input.ParentId != "" {
+			if err := checkParent(ctx, tx, input.ProjectId, "", input.ParentId); err != nil {
+				return err
+			}
+		}
+		if err := checkStatus(ctx, tx, input.ProjectId, input.StatusId); err != nil {
+			return err
+		}
+		if err := checkWipLimit(ctx, tx, input.StatusId, ""); err != nil {
+			return err
+		}
+
+		query := `INSERT INTO tasks (name, description, priority, "start", "end", project_id, status_id, parent_id, position)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, '')::uuid,
+				(SELECT COALESCE(MAX(position), 0) + ` + positionGapSQL + ` FROM tasks WHERE status_id=$7 AND deleted=false))
+			RETURNING ` + taskColumns
+		row := tx.QueryRowContext(ctx, query, input.Name, input.Description, input.Priority, start.UTC(), end.UTC(), input.ProjectId, input.StatusId, input.ParentId)
+		if err := scanTask(row, &t); err != nil {
+			return dbError(err)
+		}
+
+		if input.LabelIds != nil {
+			return setTaskLabels(ctx, tx, t.Id, t.ProjectId, input.LabelIds)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	t.Labels, err = taskLabels(ctx, conn(ctx, r.DB), t.Id)
+	if err != nil {
+		return nil, err
 	}
 
-	query := "UPDATE tasks SET deleted=true WHERE id=$1"
-	res, err := s.DB.ExecContext(ctx, query, id)
+	return &t, nil
+}
+
+func (r *PostgresTasks) Update(ctx context.Context, input *UpdateTaskInput, start, end time.Time) error {
+	return transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		pId, err := r.ProjectId(ctx, input.Id)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return ErrFailedToUpdate
+			}
+			return err
+		}
+
+		var u update
+		if input.Name.Set {
+			u.set("name", input.Name.Value)
+		}
+		if input.Description.Set {
+			u.set("description", input.Description.Value)
+		}
+		if input.Priority.Set {
+			u.set("priority", input.Priority.Value)
+		}
+
+		if input.Start.Set || input.End.Set {
+			query := `SELECT "start", "end" FROM tasks WHERE id=$1 AND deleted=false`
+			var curStart, curEnd time.Time
+			err := tx.QueryRowContext(ctx, query, input.Id).Scan(&curStart, &curEnd)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return ErrFailedToUpdate
+				}
+				return dbError(err)
+			}
+			if !input.Start.Set {
+				start = curStart
+			}
+			if !input.End.Set {
+				end = curEnd
+			}
+			if end.Before(start) {
+				return invalid("end", "must not be before start")
+			}
+			if err := checkDependencyDates(ctx, tx, pId, input.Id, start, end); err != nil {
+				return err
+			}
+			u.set(`"start"`, start)
+			u.set(`"end"`, end)
+		}
+
+		if input.StatusId.Set {
+			if err := checkStatus(ctx, tx, pId, input.StatusId.Value); err != nil {
+				return err
+			}
+			if err := checkTransition(ctx, tx, pId, input.Id, input.StatusId.Value); err != nil {
+				return err
+			}
+			if err := checkWipLimit(ctx, tx, input.StatusId.Value, input.Id); err != nil {
+				return err
+			}
+			// A task moved to another status goes to the end of it.
+			u.setExpr("position", `CASE WHEN status_id=%s THEN position
+				ELSE (SELECT COALESCE(MAX(position), 0) + `+positionGapSQL+` FROM tasks WHERE status_id=%s AND deleted=false) END`, input.StatusId.Value)
+			u.set("status_id", input.StatusId.Value)
+		}
+
+		if input.ParentId.Set {
+			if input.ParentId.Null {
+				u.set("parent_id", nil)
+			} else {
+				if err := checkParent(ctx, tx, pId, input.Id, input.ParentId.Value); err != nil {
+					return err
+				}
+				u.set("parent_id", input.ParentId.Value)
+			}
+		}
+
+		if input.LabelIds.Set {
+			if err := setTaskLabels(ctx, tx, input.Id, pId, input.LabelIds.Value); err != nil {
+				return err
+			}
+			u.touch()
+		}
+
+		return u.exec(ctx, tx, "tasks", input.Id)
+	})
+}
+
+func (r *PostgresTasks) Delete(ctx context.Context, id string) error {
+	query := "UPDATE tasks SET deleted=true, deleted_at=now() WHERE id IN (" + subtreeIds + ")"
+	res, err := conn(ctx, r.DB).ExecContext(ctx, query, id)
 	if err != nil {
-		return ErrInternal
+		return dbError(err)
 	}
 	ra, err := res.RowsAffected()
 	if err != nil {
-		return ErrInternal
+		return dbError(err)
 	}
 
 	if ra < 1 {
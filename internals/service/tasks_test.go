@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -12,8 +13,6 @@ import (
 )
 
 func TestGetTaskById(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	tId := uuid.NewString()
 	owner := types.Account{
 		Id:    uuid.NewString(),
@@ -52,43 +51,32 @@ func TestGetTaskById(t *testing.T) {
 			want: &types.Task{
 				Id:        tId,
 				Name:      "Existing project",
+				Priority:  types.PriorityNone,
 				ProjectId: project.Id,
 				StatusId:  status.Id,
 				Start:     time.Now().UTC(),
 				End:       time.Now().UTC().AddDate(0, 0, 1),
+				Progress:  &types.Progress{},
 			},
 		},
 	}
 
 	for name, tt := range tests {
-		_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", status.Id, status.Name, status.ProjectId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: status.Id, Name: status.Name, ProjectId: status.ProjectId})
 		if tt.want != nil {
-			_, err = s.DB.Exec("INSERT INTO tasks (id, name, project_id, status_id, \"start\", \"end\") VALUES ($1, $2, $3, $4, $5, $6)", tt.want.Id, tt.want.Name, tt.want.ProjectId, tt.want.StatusId, tt.want.Start, tt.want.End)
-			if err != nil {
-				t.Fatal(ErrFailedToPrepareTest, err)
-			}
+			m.PutTask(types.Task{Id: tt.want.Id, Name: tt.want.Name, ProjectId: tt.want.ProjectId, StatusId: tt.want.StatusId, Start: tt.want.Start, End: tt.want.End})
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses", "tasks"))
-
 			ctx := context.Background()
 			got, err := s.GetTaskById(ctx, tt.input)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("GetTaskById() mismatch (-want +got):\n%s", diff)
 			}
-			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(types.Task{}, "CreatedAt", "UpdatedAt", "Project", "Status"), cmpopts.EquateApproxTime(time.Millisecond)); diff != "" {
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(types.Task{}, "CreatedAt", "UpdatedAt", "Version", "Project", "Status"), cmpopts.EquateApproxTime(time.Millisecond), cmpopts.EquateEmpty()); diff != "" {
 				t.Fatalf("GetTaskById() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -96,8 +84,6 @@ func TestGetTaskById(t *testing.T) {
 }
 
 func TestGetTasksByProjectId(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -132,6 +118,7 @@ func TestGetTasksByProjectId(t *testing.T) {
 					Id:        uuid.NewString(),
 					Name:      "Task 1",
 					ProjectId: project.Id,
+					Priority:  types.PriorityNone,
 					StatusId:  status.Id,
 					Start:     time.Now().UTC(),
 					End:       time.Now().UTC(),
@@ -140,6 +127,7 @@ func TestGetTasksByProjectId(t *testing.T) {
 					Id:        uuid.NewString(),
 					Name:      "Task 2",
 					ProjectId: project.Id,
+					Priority:  types.PriorityNone,
 					StatusId:  status.Id,
 					Start:     time.Now().UTC(),
 					End:       time.Now().UTC(),
@@ -149,34 +137,21 @@ func TestGetTasksByProjectId(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", status.Id, status.Name, status.ProjectId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: status.Id, Name: status.Name, ProjectId: status.ProjectId})
 		for _, ts := range tt.want {
-			_, err = s.DB.Exec("INSERT INTO tasks (id, name, project_id, status_id, \"start\", \"end\") VALUES ($1, $2, $3, $4, $5, $6)", ts.Id, ts.Name, ts.ProjectId, ts.StatusId, ts.Start, ts.End)
-			if err != nil {
-				t.Fatal(ErrFailedToPrepareTest, err)
-			}
+			m.PutTask(ts)
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses", "tasks"))
-
 			ctx := context.Background()
-			got, err := s.GetTasksByProjectId(ctx, tt.input)
+			got, err := s.GetTasksByProjectId(ctx, tt.input, nil)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("GetTasksByProjectId() mismatch (-want +got):\n%s", diff)
 			}
-			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(types.Task{}, "CreatedAt", "UpdatedAt", "Project"), cmpopts.EquateApproxTime(time.Millisecond)); diff != "" {
+			if diff := cmp.Diff(tt.want, pageItems(got), cmpopts.IgnoreFields(types.Task{}, "CreatedAt", "UpdatedAt", "Version", "Project"), cmpopts.EquateApproxTime(time.Millisecond), cmpopts.SortSlices(func(a, b types.Task) bool { return a.Id < b.Id })); diff != "" {
 				t.Fatalf("GetTasksByProjectId() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -184,8 +159,6 @@ func TestGetTasksByProjectId(t *testing.T) {
 }
 
 func TestGetTasksOfProjectByStatusId(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -234,6 +207,7 @@ func TestGetTasksOfProjectByStatusId(t *testing.T) {
 					Id:        uuid.NewString(),
 					Name:      "Task 1",
 					ProjectId: project.Id,
+					Priority:  types.PriorityNone,
 					StatusId:  status.Id,
 					Start:     time.Now().UTC(),
 					End:       time.Now().UTC(),
@@ -242,6 +216,7 @@ func TestGetTasksOfProjectByStatusId(t *testing.T) {
 					Id:        uuid.NewString(),
 					Name:      "Task 2",
 					ProjectId: project.Id,
+					Priority:  types.PriorityNone,
 					StatusId:  status.Id,
 					Start:     time.Now().UTC(),
 					End:       time.Now().UTC(),
@@ -251,34 +226,21 @@ func TestGetTasksOfProjectByStatusId(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", status.Id, status.Name, status.ProjectId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: status.Id, Name: status.Name, ProjectId: status.ProjectId})
 		for _, ts := range tt.want {
-			_, err = s.DB.Exec("INSERT INTO tasks (id, name, project_id, status_id, \"start\", \"end\") VALUES ($1, $2, $3, $4, $5, $6)", ts.Id, ts.Name, ts.ProjectId, ts.StatusId, ts.Start, ts.End)
-			if err != nil {
-				t.Fatal(ErrFailedToPrepareTest, err)
-			}
+			m.PutTask(ts)
 		}
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses", "tasks"))
-
 			ctx := context.Background()
-			got, err := s.GetTasksOfProjectByStatusId(ctx, tt.input.projectId, tt.input.statusId)
+			got, err := s.GetTasksOfProjectByStatusId(ctx, tt.input.projectId, tt.input.statusId, nil)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("GetTasksByStatusId() mismatch (-want +got):\n%s", diff)
 			}
-			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(types.Task{}, "CreatedAt", "UpdatedAt", "Project"), cmpopts.EquateApproxTime(time.Millisecond)); diff != "" {
+			if diff := cmp.Diff(tt.want, pageItems(got), cmpopts.IgnoreFields(types.Task{}, "CreatedAt", "UpdatedAt", "Version", "Project"), cmpopts.EquateApproxTime(time.Millisecond), cmpopts.SortSlices(func(a, b types.Task) bool { return a.Id < b.Id })); diff != "" {
 				t.Fatalf("GetTasksByStatusId() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -286,8 +248,6 @@ func TestGetTasksOfProjectByStatusId(t *testing.T) {
 }
 
 func TestAddTask(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -304,6 +264,16 @@ func TestAddTask(t *testing.T) {
 		Name:      "in progress",
 		ProjectId: project.Id,
 	}
+	otherStatus := types.Status{
+		Id:        uuid.NewString(),
+		Name:      "in progress",
+		ProjectId: uuid.NewString(),
+	}
+	deletedStatus := types.Status{
+		Id:        uuid.NewString(),
+		Name:      "archived",
+		ProjectId: project.Id,
+	}
 	tests := map[string]struct {
 		input   *AddTaskInput
 		wantErr error
@@ -375,7 +345,7 @@ func TestAddTask(t *testing.T) {
 				Start:     time.Now().Format(time.DateTime),
 				End:       time.Now().AddDate(0, 0, 1).Format(time.DateTime),
 			},
-			wantErr: ErrInternal,
+			wantErr: ErrNotFound,
 		},
 		"non-existent status id": {
 			input: &AddTaskInput{
@@ -385,7 +355,61 @@ func TestAddTask(t *testing.T) {
 				Start:     time.Now().Format(time.DateTime),
 				End:       time.Now().AddDate(0, 0, 1).Format(time.DateTime),
 			},
-			wantErr: ErrInternal,
+			wantErr: ErrFailedValidation,
+		},
+		"status of another project": {
+			input: &AddTaskInput{
+				Name:      "task",
+				ProjectId: project.Id,
+				StatusId:  otherStatus.Id,
+				Start:     time.Now().Format(time.DateTime),
+				End:       time.Now().AddDate(0, 0, 1).Format(time.DateTime),
+			},
+			wantErr: ErrFailedValidation,
+		},
+		"deleted status": {
+			input: &AddTaskInput{
+				Name:      "task",
+				ProjectId: project.Id,
+				StatusId:  deletedStatus.Id,
+				Start:     time.Now().Format(time.DateTime),
+				End:       time.Now().AddDate(0, 0, 1).Format(time.DateTime),
+			},
+			wantErr: ErrFailedValidation,
+		},
+		"invalid priority": {
+			input: &AddTaskInput{
+				Name:      "task",
+				Priority:  "whenever",
+				ProjectId: project.Id,
+				StatusId:  status.Id,
+				Start:     time.Now().Format(time.DateTime),
+				End:       time.Now().AddDate(0, 0, 1).Format(time.DateTime),
+			},
+			wantErr: ErrFailedValidation,
+		},
+		"label of another project": {
+			input: &AddTaskInput{
+				Name:      "task",
+				ProjectId: project.Id,
+				StatusId:  status.Id,
+				LabelIds:  []string{uuid.NewString()},
+				Start:     time.Now().Format(time.DateTime),
+				End:       time.Now().AddDate(0, 0, 1).Format(time.DateTime),
+			},
+			wantErr: ErrFailedValidation,
+		},
+		"sucsessfull add with details": {
+			input: &AddTaskInput{
+				Name:        "task",
+				Description: "# Steps\n1. Do it",
+				Priority:    types.PriorityHigh,
+				ProjectId:   project.Id,
+				StatusId:    status.Id,
+				Start:       time.Now().Format(time.DateTime),
+				End:         time.Now().AddDate(0, 0, 1).Format(time.DateTime),
+			},
+			wantErr: nil,
 		},
 		"sucsessfull add": {
 			input: &AddTaskInput{
@@ -400,34 +424,37 @@ func TestAddTask(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", status.Id, status.Name, status.ProjectId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: status.Id, Name: status.Name, ProjectId: status.ProjectId})
+		m.PutProject(types.Project{Id: otherStatus.ProjectId, Name: "other project", OwnerId: owner.Id})
+		m.PutStatus(otherStatus)
+		m.PutStatus(types.Status{Id: deletedStatus.Id, Name: deletedStatus.Name, ProjectId: deletedStatus.ProjectId, Deleted: true})
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses", "tasks"))
-
-			ctx := context.Background()
-			err := s.AddTask(ctx, tt.input)
+			ctx := WithAccountId(context.Background(), owner.Id)
+			got, err := s.AddTask(ctx, tt.input)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("AddTask() mismatch (-want +got):\n%s", diff)
 			}
+			if err != nil {
+				return
+			}
+			if _, err := uuid.Parse(got.Id); err != nil {
+				t.Fatalf("AddTask() returned invalid id %q", got.Id)
+			}
+			if diff := cmp.Diff(tt.input.Name, got.Name); diff != "" {
+				t.Fatalf("AddTask() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(len(tt.input.LabelIds), len(got.Labels)); diff != "" {
+				t.Fatalf("AddTask() mismatch (-want +got):\n%s", diff)
+			}
 		})
 	}
 }
 
 func TestUpdateTask(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -444,6 +471,16 @@ func TestUpdateTask(t *testing.T) {
 		Name:      "in progress",
 		ProjectId: project.Id,
 	}
+	otherStatus := types.Status{
+		Id:        uuid.NewString(),
+		Name:      "in progress",
+		ProjectId: uuid.NewString(),
+	}
+	deletedStatus := types.Status{
+		Id:        uuid.NewString(),
+		Name:      "archived",
+		ProjectId: project.Id,
+	}
 	task := types.Task{
 		Id:        uuid.NewString(),
 		Name:      "task",
@@ -457,118 +494,190 @@ func TestUpdateTask(t *testing.T) {
 		"invalid id": {
 			input: &UpdateTaskInput{
 				Id:       "invalid-id",
-				Name:     "New task name",
-				StatusId: status.Id,
-				Start:    time.Now().Format(time.DateTime),
-				End:      time.Now().AddDate(0, 0, 1).Format(time.DateTime),
+				Name:     types.PatchOf("New task name"),
+				StatusId: types.PatchOf(status.Id),
+				Start:    types.PatchOf(time.Now().Format(time.DateTime)),
+				End:      types.PatchOf(time.Now().AddDate(0, 0, 1).Format(time.DateTime)),
 			},
 			wantErr: ErrFailedValidation,
 		},
 		"invalid status id": {
 			input: &UpdateTaskInput{
 				Id:       task.Id,
-				Name:     "New task name",
-				StatusId: "invalid-id",
-				Start:    time.Now().Format(time.DateTime),
-				End:      time.Now().AddDate(0, 0, 1).Format(time.DateTime),
+				Name:     types.PatchOf("New task name"),
+				StatusId: types.PatchOf("invalid-id"),
+				Start:    types.PatchOf(time.Now().Format(time.DateTime)),
+				End:      types.PatchOf(time.Now().AddDate(0, 0, 1).Format(time.DateTime)),
 			},
 			wantErr: ErrFailedValidation,
 		},
 		"invalid start": {
 			input: &UpdateTaskInput{
 				Id:       task.Id,
-				Name:     "New task name",
-				StatusId: status.Id,
-				Start:    "invalid start",
-				End:      time.Now().AddDate(0, 0, 1).Format(time.DateTime),
+				Name:     types.PatchOf("New task name"),
+				StatusId: types.PatchOf(status.Id),
+				Start:    types.PatchOf("invalid start"),
+				End:      types.PatchOf(time.Now().AddDate(0, 0, 1).Format(time.DateTime)),
 			},
 			wantErr: ErrFailedValidation,
 		},
 		"invalid end": {
 			input: &UpdateTaskInput{
 				Id:       task.Id,
-				Name:     "New task name",
-				StatusId: status.Id,
-				Start:    time.Now().Format(time.DateTime),
-				End:      "invalid end",
+				Name:     types.PatchOf("New task name"),
+				StatusId: types.PatchOf(status.Id),
+				Start:    types.PatchOf(time.Now().Format(time.DateTime)),
+				End:      types.PatchOf("invalid end"),
 			},
 			wantErr: ErrFailedValidation,
 		},
 		"end is less than start": {
 			input: &UpdateTaskInput{
 				Id:       task.Id,
-				Name:     "New task name",
-				StatusId: status.Id,
-				Start:    time.Now().AddDate(0, 0, 1).Format(time.DateTime),
-				End:      time.Now().Format(time.DateTime),
+				Name:     types.PatchOf("New task name"),
+				StatusId: types.PatchOf(status.Id),
+				Start:    types.PatchOf(time.Now().AddDate(0, 0, 1).Format(time.DateTime)),
+				End:      types.PatchOf(time.Now().Format(time.DateTime)),
 			},
 			wantErr: ErrFailedValidation,
 		},
 		"non-existent task id": {
 			input: &UpdateTaskInput{
 				Id:       uuid.NewString(),
-				Name:     "New task name",
-				StatusId: status.Id,
-				Start:    time.Now().Format(time.DateTime),
-				End:      time.Now().AddDate(0, 0, 1).Format(time.DateTime),
+				Name:     types.PatchOf("New task name"),
+				StatusId: types.PatchOf(status.Id),
+				Start:    types.PatchOf(time.Now().Format(time.DateTime)),
+				End:      types.PatchOf(time.Now().AddDate(0, 0, 1).Format(time.DateTime)),
 			},
-			wantErr: ErrFailedToUpdate,
+			wantErr: ErrNotFound,
 		},
 		"non-existent status id": {
 			input: &UpdateTaskInput{
 				Id:       task.Id,
-				Name:     "New task name",
-				StatusId: uuid.NewString(),
-				Start:    time.Now().Format(time.DateTime),
-				End:      time.Now().AddDate(0, 0, 1).Format(time.DateTime),
+				Name:     types.PatchOf("New task name"),
+				StatusId: types.PatchOf(uuid.NewString()),
+				Start:    types.PatchOf(time.Now().Format(time.DateTime)),
+				End:      types.PatchOf(time.Now().AddDate(0, 0, 1).Format(time.DateTime)),
+			},
+			wantErr: ErrFailedValidation,
+		},
+		"status of another project": {
+			input: &UpdateTaskInput{
+				Id:       task.Id,
+				StatusId: types.PatchOf(otherStatus.Id),
+			},
+			wantErr: ErrFailedValidation,
+		},
+		"deleted status": {
+			input: &UpdateTaskInput{
+				Id:       task.Id,
+				StatusId: types.PatchOf(deletedStatus.Id),
 			},
-			wantErr: ErrInternal,
+			wantErr: ErrFailedValidation,
 		},
 		"sucsessfull update": {
 			input: &UpdateTaskInput{
 				Id:       task.Id,
-				Name:     "New task name",
-				StatusId: status.Id,
-				Start:    time.Now().Format(time.DateTime),
-				End:      time.Now().AddDate(0, 0, 1).Format(time.DateTime),
+				Name:     types.PatchOf("New task name"),
+				StatusId: types.PatchOf(status.Id),
+				Start:    types.PatchOf(time.Now().Format(time.DateTime)),
+				End:      types.PatchOf(time.Now().AddDate(0, 0, 1).Format(time.DateTime)),
 			},
 			wantErr: nil,
 		},
 	}
 
 	for name, tt := range tests {
-		_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", status.Id, status.Name, status.ProjectId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO tasks (id, name, project_id, status_id, \"start\", \"end\") VALUES ($1, $2, $3, $4, $5, $6)", task.Id, task.Name, task.ProjectId, task.StatusId, task.Start, task.End)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: status.Id, Name: status.Name, ProjectId: status.ProjectId})
+		m.PutProject(types.Project{Id: otherStatus.ProjectId, Name: "other project", OwnerId: owner.Id})
+		m.PutStatus(otherStatus)
+		m.PutStatus(types.Status{Id: deletedStatus.Id, Name: deletedStatus.Name, ProjectId: deletedStatus.ProjectId, Deleted: true})
+		m.PutTask(task)
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses", "tasks"))
+			ctx := WithAccountId(context.Background(), owner.Id)
+			err := s.UpdateTask(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("UpdateTask() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
 
-			ctx := context.Background()
+func TestPatchTask(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "username",
+		Email: "username@test.com",
+	}
+	pId := uuid.NewString()
+	statusId := uuid.NewString()
+	task := types.Task{
+		Id:          uuid.NewString(),
+		Name:        "task",
+		Description: "details",
+		Priority:    types.PriorityHigh,
+		Start:       time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		End:         time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+	}
+	tests := map[string]struct {
+		wantErr error
+		input   *UpdateTaskInput
+		want    types.Task
+	}{
+		"absent fields are kept": {
+			input: &UpdateTaskInput{Id: task.Id, Name: types.PatchOf("New name")},
+			want:  types.Task{Name: "New name", Description: task.Description, Priority: task.Priority, Start: task.Start, End: task.End},
+		},
+		"null fields are cleared": {
+			input: &UpdateTaskInput{Id: task.Id, Description: types.PatchNull[string](), Priority: types.PatchNull[types.Priority]()},
+			want:  types.Task{Name: task.Name, Priority: types.PriorityNone, Start: task.Start, End: task.End},
+		},
+		"end is checked against the current start": {
+			input:   &UpdateTaskInput{Id: task.Id, End: types.PatchOf("2024-01-01 00:00:00")},
+			wantErr: ErrFailedValidation,
+		},
+		"null name": {
+			input:   &UpdateTaskInput{Id: task.Id, Name: types.PatchNull[string]()},
+			wantErr: ErrFailedValidation,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(types.Project{Id: pId, Name: "project", OwnerId: owner.Id})
+		m.PutStatus(types.Status{Id: statusId, Name: "todo", ProjectId: pId})
+		m.PutTask(types.Task{Id: task.Id, Name: task.Name, Description: task.Description, Priority: task.Priority, Start: task.Start, End: task.End, ProjectId: pId, StatusId: statusId, UpdatedAt: time.Now().Add(-time.Hour)})
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), owner.Id)
 			err := s.UpdateTask(ctx, tt.input)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("UpdateTask() mismatch (-want +got):\n%s", diff)
 			}
+			if err != nil {
+				return
+			}
+			got, err := s.GetTaskById(ctx, task.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := cmpopts.IgnoreFields(types.Task{}, "Id", "StatusId", "ProjectId", "Status", "Project", "Position", "Progress", "Version", "CreatedAt", "UpdatedAt")
+			if diff := cmp.Diff(tt.want, *got, opts, cmpopts.EquateApproxTime(time.Millisecond), cmpopts.EquateEmpty()); diff != "" {
+				t.Fatalf("UpdateTask() mismatch (-want +got):\n%s", diff)
+			}
+			if time.Since(got.UpdatedAt) > time.Minute {
+				t.Fatalf("UpdateTask() left updated_at at %v", got.UpdatedAt)
+			}
 		})
 	}
 }
 
 func TestDeleteTaskById(t *testing.T) {
-	s, cleanup := setupService(t)
-
 	owner := types.Account{
 		Id:    uuid.NewString(),
 		Name:  "username",
@@ -603,7 +712,7 @@ func TestDeleteTaskById(t *testing.T) {
 		},
 		"non-existent task id": {
 			input:   uuid.NewString(),
-			wantErr: ErrFailedToUpdate,
+			wantErr: ErrNotFound,
 		},
 		"sucsessfull delete": {
 			input:   task.Id,
@@ -612,27 +721,14 @@ func TestDeleteTaskById(t *testing.T) {
 	}
 
 	for name, tt := range tests {
-		_, err := s.DB.Exec("INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", owner.Id, owner.Email, owner.Name)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO projects (id, name, description, owner_id) VALUES ($1, $2, $3, $4)", project.Id, project.Name, project.Description, project.OwnerId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO statuses (id, name, project_id) VALUES ($1, $2, $3)", status.Id, status.Name, status.ProjectId)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
-		_, err = s.DB.Exec("INSERT INTO tasks (id, name, project_id, status_id, \"start\", \"end\") VALUES ($1, $2, $3, $4, $5, $6)", task.Id, task.Name, task.ProjectId, task.StatusId, task.Start, task.End)
-		if err != nil {
-			t.Fatal(ErrFailedToPrepareTest, err)
-		}
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: status.Id, Name: status.Name, ProjectId: status.ProjectId})
+		m.PutTask(task)
 
 		t.Run(name, func(t *testing.T) {
-			t.Cleanup(cleanup("projects", "accounts", "statuses", "tasks"))
-
-			ctx := context.Background()
+			ctx := WithAccountId(context.Background(), owner.Id)
 			err := s.DeleteTaskById(ctx, tt.input)
 			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
 				t.Fatalf("DeleteTaskById() mismatch (-want +got):\n%s", diff)
@@ -640,3 +736,46 @@ func TestDeleteTaskById(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterTasks(t *testing.T) {
+	labelId := uuid.NewString()
+	tests := map[string]struct {
+		input   *ListParams
+		want    []string
+		wantErr error
+	}{
+		"no filters": {
+			input: &ListParams{},
+			want:  []string{},
+		},
+		"priorities and label": {
+			input: &ListParams{Priority: "high,urgent", LabelId: labelId},
+			want:  []string{"priority = ANY($1)", "id IN (SELECT task_id FROM tasks_to_labels WHERE label_id=$2)"},
+		},
+		"unknown priority": {
+			input:   &ListParams{Priority: "high,whenever"},
+			wantErr: ErrFailedValidation,
+		},
+		"invalid label id": {
+			input:   &ListParams{LabelId: "invalid-id"},
+			wantErr: ErrFailedValidation,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			n := 0
+			arg := func(any) string {
+				n++
+				return fmt.Sprintf("$%d", n)
+			}
+			got, err := filterTasks(tt.input, arg)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("filterTasks() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("filterTasks() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/uuid"
+)
+
+var errDependencyCycle = errors.New("dependencies have a cycle")
+
+// GetProjectTimeline schedules the tasks of the project by their dates and
+// dependencies and finds the critical path.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrNotFound
+func (s *Service) GetProjectTimeline(ctx context.Context, pId string) (*types.Timeline, error) {
+	if _, err := uuid.Parse(pId); err != nil {
+		return nil, invalid("project_id", "must be a valid UUID")
+	}
+	if _, err := s.GetProjectById(ctx, pId); err != nil {
+		return nil, err
+	}
+
+	tks, deps, err := s.dependencies().ListByProject(ctx, pId)
+	if err != nil {
+		return nil, err
+	}
+
+	tl, err := schedule(tks, deps)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	return tl, nil
+}
+
+// schedule computes the timeline of tasks with the critical path method.
+// A task keeps its duration and starts at its Start or when the last of its
+// blockers ends, whichever is later. The latest start of a task is the latest
+// time it can start without delaying the end of the project.
+func schedule(tks []types.Task, deps []types.Dependency) (*types.Timeline, error) {
+	tl := &types.Timeline{
+		Tasks:        make([]types.TimelineTask, 0, len(tks)),
+		Dependencies: make([]types.Dependency, 0, len(deps)),
+		CriticalPath: make([]string, 0),
+	}
+	if len(tks) == 0 {
+		return tl, nil
+	}
+
+	idx := make(map[string]int, len(tks))
+	for i, t := range tks {
+		idx[t.Id] = i
+	}
+	preds := make([][]int, len(tks))
+	succs := make([][]int, len(tks))
+	inDegree := make([]int, len(tks))
+	for _, d := range deps {
+		b, okB := idx[d.BlockerId]
+		t, okT := idx[d.BlockedId]
+		if !okB || !okT {
+			continue
+		}
+		preds[t] = append(preds[t], b)
+		succs[b] = append(succs[b], t)
+		inDegree[t]++
+		tl.Dependencies = append(tl.Dependencies, d)
+	}
+
+	// Kahn's algorithm, tasks are visited in order of idx for determinism.
+	order := make([]int, 0, len(tks))
+	for i := range tks {
+		if inDegree[i] == 0 {
+			order = append(order, i)
+		}
+	}
+	for i := 0; i < len(order); i++ {
+		for _, s := range succs[order[i]] {
+			inDegree[s]--
+			if inDegree[s] == 0 {
+				order = append(order, s)
+			}
+		}
+	}
+	if len(order) != len(tks) {
+		return nil, errDependencyCycle
+	}
+
+	es := make([]time.Time, len(tks))
+	ef := make([]time.Time, len(tks))
+	for _, i := range order {
+		es[i] = tks[i].Start
+		for _, p := range preds[i] {
+			if ef[p].After(es[i]) {
+				es[i] = ef[p]
+			}
+		}
+		ef[i] = es[i].Add(tks[i].End.Sub(tks[i].Start))
+	}
+
+	tl.Start, tl.End = tks[0].Start, ef[0]
+	for i := range tks {
+		if tks[i].Start.Before(tl.Start) {
+			tl.Start = tks[i].Start
+		}
+		if ef[i].After(tl.End) {
+			tl.End = ef[i]
+		}
+	}
+
+	ls := make([]time.Time, len(tks))
+	for k := len(order) - 1; k >= 0; k-- {
+		i := order[k]
+		lf := tl.End
+		for _, s := range succs[i] {
+			if ls[s].Before(lf) {
+				lf = ls[s]
+			}
+		}
+		ls[i] = lf.Add(-tks[i].End.Sub(tks[i].Start))
+	}
+
+	for i, t := range tks {
+		slack := ls[i].Sub(es[i])
+		tl.Tasks = append(tl.Tasks, types.TimelineTask{
+			Start:         t.Start,
+			End:           t.End,
+			EarliestStart: es[i],
+			LatestStart:   ls[i],
+			Id:            t.Id,
+			Name:          t.Name,
+			StatusId:      t.StatusId,
+			ParentId:      t.ParentId,
+			Slack:         int64(slack / time.Second),
+			Critical:      slack == 0,
+		})
+	}
+
+	// The critical path ends with a critical task that finishes the project and
+	// goes back through critical blockers that end exactly when the task starts.
+	before := func(a, b int) bool {
+		if !es[a].Equal(es[b]) {
+			return es[a].Before(es[b])
+		}
+		return tks[a].Id < tks[b].Id
+	}
+	last := -1
+	for i := range tks {
+		if ls[i].Equal(es[i]) && ef[i].Equal(tl.End) && (last < 0 || before(i, last)) {
+			last = i
+		}
+	}
+	for i := last; i >= 0; {
+		tl.CriticalPath = append(tl.CriticalPath, tks[i].Id)
+		next := -1
+		for _, p := range preds[i] {
+			if ls[p].Equal(es[p]) && ef[p].Equal(es[i]) && (next < 0 || before(p, next)) {
+				next = p
+			}
+		}
+		i = next
+	}
+	for l, r := 0, len(tl.CriticalPath)-1; l < r; l, r = l+1, r-1 {
+		tl.CriticalPath[l], tl.CriticalPath[r] = tl.CriticalPath[r], tl.CriticalPath[l]
+	}
+
+	sort.SliceStable(tl.Tasks, func(i, j int) bool {
+		a, b := tl.Tasks[i], tl.Tasks[j]
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		if !a.End.Equal(b.End) {
+			return a.End.Before(b.End)
+		}
+		return a.Id < b.Id
+	})
+
+	return tl, nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestSchedule(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d int) time.Time { return day.AddDate(0, 0, d) }
+	task := func(id string, start, end int) types.Task {
+		return types.Task{Id: id, Name: id, Start: at(start), End: at(end)}
+	}
+	dep := func(blocker, blocked string) types.Dependency {
+		return types.Dependency{BlockerId: blocker, BlockedId: blocked}
+	}
+
+	tests := map[string]struct {
+		tasks        []types.Task
+		deps         []types.Dependency
+		wantErr      error
+		wantPath     []string
+		wantSlack    map[string]int64
+		wantEarliest map[string]time.Time
+	}{
+		"no tasks": {
+			wantPath:     []string{},
+			wantSlack:    map[string]int64{},
+			wantEarliest: map[string]time.Time{},
+		},
+		"diamond": {
+			// a -> b -> d, a -> c -> d
+			tasks:    []types.Task{task("d", 3, 4), task("c", 1, 2), task("b", 1, 3), task("a", 0, 1)},
+			deps:     []types.Dependency{dep("a", "b"), dep("a", "c"), dep("b", "d"), dep("c", "d")},
+			wantPath: []string{"a", "b", "d"},
+			wantSlack: map[string]int64{
+				"a": 0,
+				"b": 0,
+				"c": int64(24 * time.Hour / time.Second),
+				"d": 0,
+			},
+			wantEarliest: map[string]time.Time{"a": at(0), "b": at(1), "c": at(1), "d": at(3)},
+		},
+		"blocker delays a task": {
+			// b is planned to start before a ends.
+			tasks:        []types.Task{task("a", 0, 2), task("b", 1, 2), task("c", 0, 1)},
+			deps:         []types.Dependency{dep("a", "b")},
+			wantPath:     []string{"a", "b"},
+			wantSlack:    map[string]int64{"a": 0, "b": 0, "c": int64(2 * 24 * time.Hour / time.Second)},
+			wantEarliest: map[string]time.Time{"a": at(0), "b": at(2), "c": at(0)},
+		},
+		"dependency on unknown task": {
+			tasks:        []types.Task{task("a", 0, 1)},
+			deps:         []types.Dependency{dep("deleted", "a")},
+			wantPath:     []string{"a"},
+			wantSlack:    map[string]int64{"a": 0},
+			wantEarliest: map[string]time.Time{"a": at(0)},
+		},
+		"cycle": {
+			tasks:   []types.Task{task("a", 0, 1), task("b", 1, 2)},
+			deps:    []types.Dependency{dep("a", "b"), dep("b", "a")},
+			wantErr: errDependencyCycle,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := schedule(tt.tasks, tt.deps)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("schedule() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.wantPath, got.CriticalPath); diff != "" {
+				t.Fatalf("schedule() critical path mismatch (-want +got):\n%s", diff)
+			}
+			slack := make(map[string]int64)
+			earliest := make(map[string]time.Time)
+			for _, tk := range got.Tasks {
+				slack[tk.Id] = tk.Slack
+				earliest[tk.Id] = tk.EarliestStart
+			}
+			if diff := cmp.Diff(tt.wantSlack, slack); diff != "" {
+				t.Fatalf("schedule() slack mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantEarliest, earliest); diff != "" {
+				t.Fatalf("schedule() earliest start mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// SetTransitionsInput replaces the workflow of the project ProjectId.
+// Empty Transitions allow tasks to move between any statuses.
+type SetTransitionsInput struct {
+	ProjectId   string             `param:"id"`
+	Transitions []types.Transition `json:"transitions"`
+}
+
+// GetTransitionsByProjectId returns the workflow of the project,
+// no transitions mean that tasks can move between any statuses.
+//
+// Returned errors: ErrFailedValidation, ErrInternal
+func (s *Service) GetTransitionsByProjectId(ctx context.Context, pId string) ([]types.Transition, error) {
+	if _, err := uuid.Parse(pId); err != nil {
+		return nil, invalid("project_id", "must be a valid UUID")
+	}
+
+	return s.projects().Transitions(ctx, pId)
+}
+
+// Both statuses of every transition must be statuses of the project.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) SetTransitions(ctx context.Context, input *SetTransitionsInput) error {
+	if _, err := uuid.Parse(input.ProjectId); err != nil {
+		return invalid("project_id", "must be a valid UUID")
+	}
+	for _, tr := range input.Transitions {
+		if _, err := uuid.Parse(tr.FromStatusId); err != nil {
+			return invalid("transitions", "from_status_id must be a valid UUID")
+		}
+		if _, err := uuid.Parse(tr.ToStatusId); err != nil {
+			return invalid("transitions", "to_status_id must be a valid UUID")
+		}
+		if tr.FromStatusId == tr.ToStatusId {
+			return invalid("transitions", "must be between different statuses")
+		}
+	}
+	if _, err := s.GetProjectById(ctx, input.ProjectId); err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, input.ProjectId, PermManageStatuses); err != nil {
+		return err
+	}
+
+	return s.projects().SetTransitions(ctx, input)
+}
+
+// checkTransition checks that the workflow of the project pId allows
+// the task tId to move to the status to. The task is locked until tx ends.
+//
+// Returned errors: ErrInternal, ErrConcurrentUpdate, ErrFailedToUpdate, ErrUnprocessable
+func checkTransition(ctx context.Context, tx *sql.Tx, pId, tId, to string) error {
+	var from string
+	err := tx.QueryRowContext(ctx, "SELECT status_id FROM tasks WHERE id=$1 FOR NO KEY UPDATE", tId).Scan(&from)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrFailedToUpdate
+		}
+		return dbError(err)
+	}
+	if from == to {
+		return nil
+	}
+
+	var (
+		restricted, allowed bool
+		fromName, toName    sql.NullString
+	)
+	query := `SELECT
+		EXISTS (SELECT 1 FROM status_transitions WHERE project_id=$1),
+		EXISTS (SELECT 1 FROM status_transitions WHERE from_status_id=$2 AND to_status_id=$3),
+		(SELECT name FROM statuses WHERE id=$2),
+		(SELECT name FROM statuses WHERE id=$3)`
+	err = tx.QueryRowContext(ctx, query, pId, from, to).Scan(&restricted, &allowed, &fromName, &toName)
+	if err != nil {
+		return dbError(err)
+	}
+	if restricted && !allowed {
+		return transitionNotAllowed(fromName.String, toName.String)
+	}
+
+	return nil
+}
+
+func (r *PostgresProjects) Transitions(ctx context.Context, pId string) ([]types.Transition, error) {
+	query := `SELECT t.from_status_id, t.to_status_id FROM status_transitions t
+		JOIN statuses f ON f.id=t.from_status_id
+		JOIN statuses s ON s.id=t.to_status_id
+		WHERE t.project_id=$1 AND f.deleted=false AND s.deleted=false
+		ORDER BY t.created_at, t.from_status_id, t.to_status_id`
+	rows, err := conn(ctx, r.DB).QueryContext(ctx, query, pId)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	trs := make([]types.Transition, 0)
+	for rows.Next() {
+		var tr types.Transition
+		if err = rows.Scan(&tr.FromStatusId, &tr.ToStatusId); err != nil {
+			return nil, dbError(err)
+		}
+		trs = append(trs, tr)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return trs, nil
+}
+
+func (r *PostgresProjects) SetTransitions(ctx context.Context, input *SetTransitionsInput) error {
+	ids := make([]string, 0, 2*len(input.Transitions))
+	for _, tr := range input.Transitions {
+		ids = append(ids, tr.FromStatusId, tr.ToStatusId)
+	}
+
+	return transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		if err := lockProject(ctx, tx, input.ProjectId); err != nil {
+			return err
+		}
+
+		var foreign bool
+		query := `SELECT EXISTS (SELECT 1 FROM unnest($2::uuid[]) AS i(id)
+			WHERE NOT EXISTS (SELECT 1 FROM statuses WHERE id=i.id AND project_id=$1 AND deleted=false))`
+		err := tx.QueryRowContext(ctx, query, input.ProjectId, pq.Array(ids)).Scan(&foreign)
+		if err != nil {
+			return dbError(err)
+		}
+		if foreign {
+			return invalid("transitions", "must be between statuses of the project")
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM status_transitions WHERE project_id=$1", input.ProjectId)
+		if err != nil {
+			return dbError(err)
+		}
+
+		query = `INSERT INTO status_transitions (project_id, from_status_id, to_status_id)
+			VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`
+		for _, tr := range input.Transitions {
+			_, err = tx.ExecContext(ctx, query, input.ProjectId, tr.FromStatusId, tr.ToStatusId)
+			if err != nil {
+				return dbError(err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// transitionNotAllowed returns the error of moving a task from the status from
+// to the status to the workflow doesn't allow.
+func transitionNotAllowed(from, to string) error {
+	return &Error{
+		Err:     ErrUnprocessable,
+		Message: fmt.Sprintf("the workflow doesn't allow moving tasks from %q to %q", from, to),
+		Fields:  []types.FieldError{{Field: "status_id", Message: "must be reachable from the current status"}},
+	}
+}
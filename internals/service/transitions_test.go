@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+)
+
+func TestUpdateTaskTransition(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "owner",
+		Email: "owner@test.com",
+	}
+	project := types.Project{
+		Id:      uuid.NewString(),
+		Name:    "project",
+		OwnerId: owner.Id,
+	}
+	todoId := uuid.NewString()
+	doingId := uuid.NewString()
+	doneId := uuid.NewString()
+	task := types.Task{Id: uuid.NewString(), Name: "task", ProjectId: project.Id, StatusId: todoId}
+	// todo -> doing -> done
+	workflow := []types.Transition{
+		{FromStatusId: todoId, ToStatusId: doingId},
+		{FromStatusId: doingId, ToStatusId: doneId},
+	}
+	tests := map[string]struct {
+		wantErr     error
+		transitions []types.Transition
+		statusId    string
+	}{
+		"any status without workflow": {
+			statusId: doneId,
+			wantErr:  nil,
+		},
+		"same status": {
+			transitions: workflow,
+			statusId:    todoId,
+			wantErr:     nil,
+		},
+		"allowed transition": {
+			transitions: workflow,
+			statusId:    doingId,
+			wantErr:     nil,
+		},
+		"forbidden transition": {
+			transitions: workflow,
+			statusId:    doneId,
+			wantErr:     ErrUnprocessable,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(project)
+		m.PutStatus(types.Status{Id: todoId, Name: "todo", ProjectId: project.Id, Category: types.CategoryTodo})
+		m.PutStatus(types.Status{Id: doingId, Name: "doing", ProjectId: project.Id, Category: types.CategoryInProgress})
+		m.PutStatus(types.Status{Id: doneId, Name: "done", ProjectId: project.Id, Category: types.CategoryDone})
+		m.PutTask(task)
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), owner.Id)
+			err := s.SetTransitions(ctx, &SetTransitionsInput{ProjectId: project.Id, Transitions: tt.transitions})
+			if err != nil {
+				t.Fatal(ErrFailedToPrepareTest, err)
+			}
+
+			input := &UpdateTaskInput{
+				Id:       task.Id,
+				StatusId: types.PatchOf(tt.statusId),
+				Start:    types.PatchOf("2024-01-01 00:00:00"),
+				End:      types.PatchOf("2024-01-02 00:00:00"),
+			}
+			err = s.UpdateTask(ctx, input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("UpdateTask() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
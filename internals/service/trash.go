@@ -0,0 +1,445 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/danblok/pm/internals/storage"
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// GetProjectTrash returns deleted statuses and tasks of the project,
+// the most recently deleted first.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) GetProjectTrash(ctx context.Context, pId string) (*types.Trash, error) {
+	if _, err := uuid.Parse(pId); err != nil {
+		return nil, invalid("project_id", "must be a valid UUID")
+	}
+	if err := s.authorize(ctx, pId, PermManageTasks); err != nil {
+		return nil, err
+	}
+
+	return s.trash().ProjectTrash(ctx, pId)
+}
+
+// GetAccountTrash returns deleted projects owned by the account,
+// the most recently deleted first.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrUnauthorized, ErrForbidden
+func (s *Service) GetAccountTrash(ctx context.Context, aId string) (*types.Trash, error) {
+	if _, err := uuid.Parse(aId); err != nil {
+		return nil, invalid("account_id", "must be a valid UUID")
+	}
+	if err := checkSelf(ctx, aId); err != nil {
+		return nil, err
+	}
+
+	return s.trash().AccountTrash(ctx, aId)
+}
+
+// RestoreAccount restores the deleted account with the email and
+// the password of the input and issues tokens for it, since a deleted account
+// can't log in. Projects owned by the account that were deleted with it
+// are restored as well.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrUnauthorized
+func (s *Service) RestoreAccount(ctx context.Context, input *LoginInput) (*types.Tokens, error) {
+	if input.Email == "" {
+		return nil, invalid("email", "must not be empty")
+	}
+	if input.Password == "" {
+		return nil, invalid("password", "must not be empty")
+	}
+
+	var id string
+	err := s.transact(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		var (
+			hash string
+			err  error
+		)
+		id, hash, err = s.trash().DeletedCredentials(ctx, input.Email)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return ErrUnauthorized
+			}
+			return err
+		}
+		if hash == "" || bcrypt.CompareHashAndPassword([]byte(hash), []byte(input.Password)) != nil {
+			return ErrUnauthorized
+		}
+
+		return s.trash().RestoreAccount(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(id)
+}
+
+// Only the owner can restore the project. Statuses and tasks that were
+// deleted with the project are restored as well.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) RestoreProjectById(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return invalid("id", "must be a valid UUID")
+	}
+	caller, err := callerId(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.trash().RestoreProject(ctx, id, caller)
+}
+
+// The status is restored at its former position.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) RestoreStatusById(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return invalid("id", "must be a valid UUID")
+	}
+	pId, err := s.trash().StatusProjectId(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, pId, PermManageStatuses); err != nil {
+		return err
+	}
+
+	return s.trash().RestoreStatus(ctx, id)
+}
+
+// Subtasks that were deleted with the task are restored as well. The status
+// and the parent of the task must be restored before the task.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrConflict, ErrNotFound, ErrUnauthorized, ErrForbidden
+func (s *Service) RestoreTaskById(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return invalid("id", "must be a valid UUID")
+	}
+	pId, err := s.trash().TaskProjectId(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.authorize(ctx, pId, PermManageTasks); err != nil {
+		return err
+	}
+
+	return s.trash().RestoreTask(ctx, pId, id)
+}
+
+// Purge hard deletes accounts, projects, statuses and tasks that were deleted
+// longer than retention ago with everything that belongs to them, including
+// contents of their attachments. Comments and attachments of a purged account
+// on tasks of other projects are kept without their author. It returns
+// the number of purged records.
+//
+// Returned errors: ErrInternal
+func (s *Service) Purge(ctx context.Context, retention time.Duration) (int64, error) {
+	blobs, purged, err := s.trash().Purge(ctx, retention)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range blobs {
+		err = s.Storage.Delete(ctx, id)
+		if err != nil && !errors.Is(err, storage.ErrNotExist) {
+			return purged, ErrInternal
+		}
+	}
+
+	return purged, nil
+}
+
+// PostgresTrash keeps deleted records in Postgres.
+type PostgresTrash struct {
+	DB *sql.DB
+}
+
+func (r *PostgresTrash) ProjectTrash(ctx context.Context, pId string) (*types.Trash, error) {
+	trash := &types.Trash{Statuses: make([]types.Status, 0)}
+	query := "SELECT " + statusColumns + " FROM statuses WHERE project_id=$1 AND deleted=true ORDER BY deleted_at DESC, id"
+	rows, err := conn(ctx, r.DB).QueryContext(ctx, query, pId)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var st types.Status
+		if err = scanStatus(rows, &st); err != nil {
+			return nil, dbError(err)
+		}
+		trash.Statuses = append(trash.Statuses, st)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	query = "SELECT " + taskColumns + " FROM tasks WHERE project_id=$1 AND deleted=true ORDER BY deleted_at DESC, id"
+	trash.Tasks, err = queryTasks(ctx, conn(ctx, r.DB), query, pId)
+	if err != nil {
+		return nil, err
+	}
+
+	return trash, nil
+}
+
+func (r *PostgresTrash) AccountTrash(ctx context.Context, aId string) (*types.Trash, error) {
+	trash := &types.Trash{Projects: make([]types.Project, 0)}
+	query := "SELECT " + projectColumns + " FROM projects WHERE owner_id=$1 AND deleted=true ORDER BY deleted_at DESC, id"
+	rows, err := conn(ctx, r.DB).QueryContext(ctx, query, aId)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pj types.Project
+		if err = scanProject(rows, &pj); err != nil {
+			return nil, dbError(err)
+		}
+		trash.Projects = append(trash.Projects, pj)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err)
+	}
+
+	return trash, nil
+}
+
+func (r *PostgresTrash) DeletedCredentials(ctx context.Context, email string) (string, string, error) {
+	var id, hash string
+	query := "SELECT id, password_hash FROM accounts WHERE email=$1 AND deleted=true FOR UPDATE"
+	err := conn(ctx, r.DB).QueryRowContext(ctx, query, email).Scan(&id, &hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", ErrNotFound
+		}
+		return "", "", dbError(err)
+	}
+
+	return id, hash, nil
+}
+
+func (r *PostgresTrash) RestoreAccount(ctx context.Context, id string) error {
+	return transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		at, err := deletedAt(ctx, tx, "accounts", id)
+		if err != nil {
+			return err
+		}
+
+		owned := "project_id IN (SELECT id FROM projects WHERE owner_id=$2 AND deleted_at=$1::timestamp)"
+		for _, r := range []struct{ table, cond string }{
+			{"statuses", owned},
+			{"tasks", owned},
+			{"projects", "owner_id=$2"},
+			{"accounts", "id=$2"},
+		} {
+			if err = undelete(ctx, tx, r.table, r.cond, at, id); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *PostgresTrash) RestoreProject(ctx context.Context, id, ownerId string) error {
+	return transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		var owner string
+		err := tx.QueryRowContext(ctx, "SELECT owner_id FROM projects WHERE id=$1 AND deleted=true", id).Scan(&owner)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotFound
+			}
+			return dbError(err)
+		}
+		if owner != ownerId {
+			return ErrForbidden
+		}
+
+		at, err := deletedAt(ctx, tx, "projects", id)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range []struct{ table, cond string }{
+			{"statuses", "project_id=$2"},
+			{"tasks", "project_id=$2"},
+			{"projects", "id=$2"},
+		} {
+			if err = undelete(ctx, tx, r.table, r.cond, at, id); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *PostgresTrash) StatusProjectId(ctx context.Context, id string) (string, error) {
+	return projectIdOf(ctx, conn(ctx, r.DB), "SELECT project_id FROM statuses WHERE id=$1 AND deleted=true", id)
+}
+
+func (r *PostgresTrash) TaskProjectId(ctx context.Context, id string) (string, error) {
+	return projectIdOf(ctx, conn(ctx, r.DB), "SELECT project_id FROM tasks WHERE id=$1 AND deleted=true", id)
+}
+
+func (r *PostgresTrash) RestoreStatus(ctx context.Context, id string) error {
+	return transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		at, err := deletedAt(ctx, tx, "statuses", id)
+		if err != nil {
+			return err
+		}
+		if err := undelete(ctx, tx, "statuses", "id=$2", at, id); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (r *PostgresTrash) RestoreTask(ctx context.Context, pId, id string) error {
+	return transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		if err := lockProject(ctx, tx, pId); err != nil {
+			return err
+		}
+
+		var statusDeleted, parentDeleted bool
+		query := `SELECT s.deleted, COALESCE(p.deleted, false)
+			FROM tasks t JOIN statuses s ON s.id=t.status_id LEFT JOIN tasks p ON p.id=t.parent_id
+			WHERE t.id=$1`
+		err := tx.QueryRowContext(ctx, query, id).Scan(&statusDeleted, &parentDeleted)
+		if err != nil {
+			return dbError(err)
+		}
+		if statusDeleted {
+			return &Error{Err: ErrConflict, Message: "the status of the task is deleted, restore it first"}
+		}
+		if parentDeleted {
+			return &Error{Err: ErrConflict, Message: "the parent of the task is deleted, restore it first"}
+		}
+
+		at, err := deletedAt(ctx, tx, "tasks", id)
+		if err != nil {
+			return err
+		}
+
+		// Subtasks in deleted statuses stay deleted, so they can't end up hidden.
+		subtree := `id IN (WITH RECURSIVE subtree(id) AS (
+				SELECT id FROM tasks WHERE id=$2
+				UNION
+				SELECT t.id FROM tasks t JOIN subtree ON t.parent_id=subtree.id
+				WHERE t.deleted=true AND t.deleted_at=$1::timestamp
+					AND t.status_id IN (SELECT id FROM statuses WHERE deleted=false)
+			) SELECT id FROM subtree)`
+		if err = undelete(ctx, tx, "tasks", subtree, at, id); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (r *PostgresTrash) Purge(ctx context.Context, retention time.Duration) ([]string, int64, error) {
+	var (
+		blobs  []string
+		purged int64
+	)
+	err := transact(ctx, r.DB, func(ctx context.Context, tx *sql.Tx) error {
+		// Attachments are deleted explicitly, so their contents can be removed
+		// from the storage once the transaction commits.
+		query := `WITH expired AS (SELECT now() - make_interval(secs => $1) AS at)
+			DELETE FROM attachments a USING expired e
+			WHERE (a.deleted=true AND a.updated_at < e.at)
+				OR a.task_id IN (SELECT t.id FROM tasks t
+					JOIN statuses s ON s.id=t.status_id
+					JOIN projects p ON p.id=t.project_id
+					JOIN accounts o ON o.id=p.owner_id
+					WHERE (t.deleted=true AND t.deleted_at < e.at)
+						OR (s.deleted=true AND s.deleted_at < e.at)
+						OR (p.deleted=true AND p.deleted_at < e.at)
+						OR (o.deleted=true AND o.deleted_at < e.at))
+			RETURNING a.id`
+		rows, err := tx.QueryContext(ctx, query, retention.Seconds())
+		if err != nil {
+			return dbError(err)
+		}
+		defer rows.Close()
+
+		blobs = make([]string, 0)
+		for rows.Next() {
+			var id string
+			if err = rows.Scan(&id); err != nil {
+				return dbError(err)
+			}
+			blobs = append(blobs, id)
+		}
+
+		if err = rows.Err(); err != nil {
+			return dbError(err)
+		}
+
+		purged = int64(len(blobs))
+		for _, table := range []string{"tasks", "statuses", "projects", "accounts"} {
+			query = "DELETE FROM " + table + " WHERE deleted=true AND deleted_at < now() - make_interval(secs => $1)"
+			res, err := tx.ExecContext(ctx, query, retention.Seconds())
+			if err != nil {
+				return dbError(err)
+			}
+			ra, err := res.RowsAffected()
+			if err != nil {
+				return dbError(err)
+			}
+			purged += ra
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return blobs, purged, nil
+}
+
+// deletedAt returns the time the record id of the table was deleted at
+// in the format of Postgres, so it can be compared without losing precision.
+//
+// Returned errors: ErrInternal, ErrConcurrentUpdate, ErrNotFound
+func deletedAt(ctx context.Context, tx *sql.Tx, table, id string) (string, error) {
+	var at string
+	query := "SELECT deleted_at::text FROM " + table + " WHERE id=$1 AND deleted=true AND deleted_at IS NOT NULL FOR UPDATE"
+	err := tx.QueryRowContext(ctx, query, id).Scan(&at)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", dbError(err)
+	}
+
+	return at, nil
+}
+
+// undelete restores records of the table matching cond that were deleted
+// at the time at. $1 in cond is at, $2 is id.
+//
+// Returned errors: ErrConcurrentUpdate, ErrInternal
+func undelete(ctx context.Context, tx *sql.Tx, table, cond, at, id string) error {
+	query := "UPDATE " + table + " SET deleted=false, deleted_at=NULL, updated_at=now() WHERE deleted=true AND deleted_at=$1::timestamp AND " + cond
+	if _, err := tx.ExecContext(ctx, query, at, id); err != nil {
+		return dbError(err)
+	}
+
+	return nil
+}
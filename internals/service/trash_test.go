@@ -0,0 +1,568 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestRestoreProjectById(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "username",
+		Email: "username@test.com",
+	}
+	other := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "other",
+		Email: "other@test.com",
+	}
+	pId := uuid.NewString()
+	statusId := uuid.NewString()
+	taskId := uuid.NewString()
+	trashedId := uuid.NewString()
+	hourAgo := time.Now().Add(-time.Hour)
+	tests := map[string]struct {
+		wantErr     error
+		wantVisible int
+		caller      string
+		input       string
+	}{
+		"invalid id": {
+			caller:  owner.Id,
+			input:   "invalid-id",
+			wantErr: ErrFailedValidation,
+		},
+		"non-deleted project": {
+			caller:  owner.Id,
+			input:   uuid.NewString(),
+			wantErr: ErrNotFound,
+		},
+		"not the owner": {
+			caller:  other.Id,
+			input:   pId,
+			wantErr: ErrForbidden,
+		},
+		"restores what was deleted with the project": {
+			caller:      owner.Id,
+			input:       pId,
+			wantVisible: 2,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutAccount(other)
+		m.PutProject(types.Project{Id: pId, Name: "Project", OwnerId: owner.Id})
+		m.PutStatus(types.Status{Id: statusId, Name: "todo", ProjectId: pId})
+		m.PutTask(types.Task{Id: taskId, Name: "task", ProjectId: pId, StatusId: statusId})
+		m.PutTask(types.Task{Id: trashedId, Name: "task", ProjectId: pId, StatusId: statusId, Deleted: true, DeletedAt: &hourAgo})
+		ctx := WithAccountId(context.Background(), owner.Id)
+		if err := s.DeleteProjectById(ctx, pId); err != nil {
+			t.Fatal(ErrFailedToPrepareTest, err)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), tt.caller)
+			err := s.RestoreProjectById(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("RestoreProjectById() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			statuses, err := s.GetStatusesByProjectId(ctx, pId, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tasks, err := s.GetTasksByProjectId(ctx, pId, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			visible := len(statuses.Items) + len(tasks.Items)
+			if visible != tt.wantVisible {
+				t.Fatalf("RestoreProjectById() restored %d statuses and tasks, want %d", visible, tt.wantVisible)
+			}
+			if _, err = s.GetTaskById(ctx, trashedId); err == nil {
+				t.Fatal("RestoreProjectById() restored a task deleted before the project")
+			}
+		})
+	}
+}
+
+func TestGetProjectTrash(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "username",
+		Email: "username@test.com",
+	}
+	other := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "other",
+		Email: "other@test.com",
+	}
+	pId := uuid.NewString()
+	statusId := uuid.NewString()
+	trashedStatusId := uuid.NewString()
+	taskId := uuid.NewString()
+	trashedIds := []string{uuid.NewString(), uuid.NewString()}
+	tests := map[string]struct {
+		wantErr      error
+		wantStatuses []string
+		wantTasks    []string
+		caller       string
+		input        string
+	}{
+		"invalid id": {
+			caller:  owner.Id,
+			input:   "invalid-id",
+			wantErr: ErrFailedValidation,
+		},
+		"non-existent project": {
+			caller:  owner.Id,
+			input:   uuid.NewString(),
+			wantErr: ErrNotFound,
+		},
+		"not a contributor": {
+			caller:  other.Id,
+			input:   pId,
+			wantErr: ErrForbidden,
+		},
+		"most recently deleted first": {
+			caller:       owner.Id,
+			input:        pId,
+			wantStatuses: []string{trashedStatusId},
+			wantTasks:    []string{trashedIds[1], trashedIds[0]},
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutAccount(other)
+		m.PutProject(types.Project{Id: pId, Name: "Project", OwnerId: owner.Id})
+		m.PutStatus(types.Status{Id: statusId, Name: "todo", ProjectId: pId})
+		m.PutStatus(types.Status{Id: trashedStatusId, Name: "trashed", ProjectId: pId, Deleted: true})
+		m.PutTask(types.Task{Id: taskId, Name: "task", ProjectId: pId, StatusId: statusId})
+		for i, id := range trashedIds {
+			at := time.Now().Add(-time.Duration(len(trashedIds)-i) * time.Hour)
+			m.PutTask(types.Task{Id: id, Name: "trashed", ProjectId: pId, StatusId: statusId, Deleted: true, DeletedAt: &at})
+		}
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), tt.caller)
+			got, err := s.GetProjectTrash(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("GetProjectTrash() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			gotStatuses := make([]string, 0)
+			for _, st := range got.Statuses {
+				gotStatuses = append(gotStatuses, st.Id)
+			}
+			if diff := cmp.Diff(tt.wantStatuses, gotStatuses); diff != "" {
+				t.Fatalf("GetProjectTrash() statuses mismatch (-want +got):\n%s", diff)
+			}
+			gotTasks := make([]string, 0)
+			for _, task := range got.Tasks {
+				gotTasks = append(gotTasks, task.Id)
+			}
+			if diff := cmp.Diff(tt.wantTasks, gotTasks); diff != "" {
+				t.Fatalf("GetProjectTrash() tasks mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetAccountTrash(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "username",
+		Email: "username@test.com",
+	}
+	other := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "other",
+		Email: "other@test.com",
+	}
+	pId := uuid.NewString()
+	trashedIds := []string{uuid.NewString(), uuid.NewString()}
+	tests := map[string]struct {
+		wantErr error
+		want    []string
+		caller  string
+		input   string
+	}{
+		"invalid id": {
+			caller:  owner.Id,
+			input:   "invalid-id",
+			wantErr: ErrFailedValidation,
+		},
+		"another account": {
+			caller:  other.Id,
+			input:   owner.Id,
+			wantErr: ErrForbidden,
+		},
+		"no deleted projects": {
+			caller: other.Id,
+			input:  other.Id,
+			want:   []string{},
+		},
+		"most recently deleted first": {
+			caller: owner.Id,
+			input:  owner.Id,
+			want:   []string{trashedIds[1], trashedIds[0]},
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutAccount(other)
+		m.PutProject(types.Project{Id: pId, Name: "Project", OwnerId: owner.Id})
+		for i, id := range trashedIds {
+			at := time.Now().Add(-time.Duration(len(trashedIds)-i) * time.Hour)
+			m.PutProject(types.Project{Id: id, Name: "Trashed", OwnerId: owner.Id, Deleted: true, DeletedAt: &at})
+		}
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), tt.caller)
+			got, err := s.GetAccountTrash(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("GetAccountTrash() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			gotIds := make([]string, 0)
+			for _, pj := range got.Projects {
+				gotIds = append(gotIds, pj.Id)
+			}
+			if diff := cmp.Diff(tt.want, gotIds); diff != "" {
+				t.Fatalf("GetAccountTrash() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRestoreAccount(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(ErrFailedToPrepareTest, err)
+	}
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "username",
+		Email: "username@test.com",
+	}
+	other := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "other",
+		Email: "other@test.com",
+	}
+	pId := uuid.NewString()
+	tests := map[string]struct {
+		wantErr error
+		input   LoginInput
+	}{
+		"empty email": {
+			input:   LoginInput{Password: "password"},
+			wantErr: ErrFailedValidation,
+		},
+		"empty password": {
+			input:   LoginInput{Email: owner.Email},
+			wantErr: ErrFailedValidation,
+		},
+		"wrong password": {
+			input:   LoginInput{Email: owner.Email, Password: "wrong-password"},
+			wantErr: ErrUnauthorized,
+		},
+		"non-deleted account": {
+			input:   LoginInput{Email: other.Email, Password: "password"},
+			wantErr: ErrUnauthorized,
+		},
+		"restores the account with its projects": {
+			input: LoginInput{Email: owner.Email, Password: "password"},
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		for _, a := range []types.Account{owner, other} {
+			m.PutAccount(a)
+			m.PutPassword(a.Id, string(hash))
+		}
+		m.PutProject(types.Project{Id: pId, Name: "Project", OwnerId: owner.Id})
+		if err := s.DeleteAccountById(WithAccountId(context.Background(), owner.Id), owner.Id); err != nil {
+			t.Fatal(ErrFailedToPrepareTest, err)
+		}
+
+		t.Run(name, func(t *testing.T) {
+			got, err := s.RestoreAccount(context.Background(), &tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("RestoreAccount() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			id, err := s.ParseAccessToken(got.AccessToken)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != owner.Id {
+				t.Fatalf("RestoreAccount() issued tokens for %s, want %s", id, owner.Id)
+			}
+			ctx := WithAccountId(context.Background(), owner.Id)
+			if _, err = s.GetAccountById(ctx, owner.Id); err != nil {
+				t.Fatalf("RestoreAccount() didn't restore the account: %s", err)
+			}
+			if _, err = s.GetProjectById(ctx, pId); err != nil {
+				t.Fatalf("RestoreAccount() didn't restore the project: %s", err)
+			}
+		})
+	}
+}
+
+func TestRestoreStatusById(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "username",
+		Email: "username@test.com",
+	}
+	other := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "other",
+		Email: "other@test.com",
+	}
+	pId := uuid.NewString()
+	statusId := uuid.NewString()
+	tests := map[string]struct {
+		wantErr error
+		caller  string
+		input   string
+	}{
+		"invalid id": {
+			caller:  owner.Id,
+			input:   "invalid-id",
+			wantErr: ErrFailedValidation,
+		},
+		"non-deleted status": {
+			caller:  owner.Id,
+			input:   uuid.NewString(),
+			wantErr: ErrNotFound,
+		},
+		"not a contributor": {
+			caller:  other.Id,
+			input:   statusId,
+			wantErr: ErrForbidden,
+		},
+		"deleted status": {
+			caller: owner.Id,
+			input:  statusId,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutAccount(other)
+		m.PutProject(types.Project{Id: pId, Name: "Project", OwnerId: owner.Id})
+		m.PutStatus(types.Status{Id: statusId, Name: "todo", ProjectId: pId, Deleted: true})
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), tt.caller)
+			err := s.RestoreStatusById(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("RestoreStatusById() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if _, err = s.GetStatusById(ctx, statusId); err != nil {
+				t.Fatalf("RestoreStatusById() didn't restore the status: %s", err)
+			}
+		})
+	}
+}
+
+func TestRestoreTaskById(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "username",
+		Email: "username@test.com",
+	}
+	other := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "other",
+		Email: "other@test.com",
+	}
+	pId := uuid.NewString()
+	statusId := uuid.NewString()
+	trashedStatusId := uuid.NewString()
+	taskId := uuid.NewString()
+	subtaskId := uuid.NewString()
+	orphanId := uuid.NewString()
+	hourAgo := time.Now().Add(-time.Hour)
+	tests := map[string]struct {
+		wantErr error
+		caller  string
+		input   string
+	}{
+		"invalid id": {
+			caller:  owner.Id,
+			input:   "invalid-id",
+			wantErr: ErrFailedValidation,
+		},
+		"non-deleted task": {
+			caller:  owner.Id,
+			input:   uuid.NewString(),
+			wantErr: ErrNotFound,
+		},
+		"not a contributor": {
+			caller:  other.Id,
+			input:   taskId,
+			wantErr: ErrForbidden,
+		},
+		"deleted status": {
+			caller:  owner.Id,
+			input:   orphanId,
+			wantErr: ErrConflict,
+		},
+		"deleted parent": {
+			caller:  owner.Id,
+			input:   subtaskId,
+			wantErr: ErrConflict,
+		},
+		"restores subtasks deleted with the task": {
+			caller: owner.Id,
+			input:  taskId,
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutAccount(other)
+		m.PutProject(types.Project{Id: pId, Name: "Project", OwnerId: owner.Id})
+		m.PutStatus(types.Status{Id: statusId, Name: "todo", ProjectId: pId})
+		m.PutStatus(types.Status{Id: trashedStatusId, Name: "trashed", ProjectId: pId, Deleted: true})
+		m.PutTask(types.Task{Id: taskId, Name: "task", ProjectId: pId, StatusId: statusId, Deleted: true, DeletedAt: &hourAgo})
+		m.PutTask(types.Task{Id: subtaskId, Name: "subtask", ProjectId: pId, StatusId: statusId, ParentId: taskId, Deleted: true, DeletedAt: &hourAgo})
+		m.PutTask(types.Task{Id: orphanId, Name: "orphan", ProjectId: pId, StatusId: trashedStatusId, Deleted: true})
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), tt.caller)
+			err := s.RestoreTaskById(ctx, tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("RestoreTaskById() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if _, err = s.GetTaskById(ctx, subtaskId); err != nil {
+				t.Fatalf("RestoreTaskById() didn't restore the subtask: %s", err)
+			}
+		})
+	}
+}
+
+func TestPurge(t *testing.T) {
+	purged := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "purged",
+		Email: "purged@test.com",
+	}
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "username",
+		Email: "username@test.com",
+	}
+	purgedProjectId := uuid.NewString()
+	purgedStatusId := uuid.NewString()
+	purgedTaskId := uuid.NewString()
+	pId := uuid.NewString()
+	statusId := uuid.NewString()
+	taskId := uuid.NewString()
+	trashedTaskId := uuid.NewString()
+	commentId := uuid.NewString()
+	replyId := uuid.NewString()
+	attachmentId := uuid.NewString()
+	// A deleted account is deleted at the time it was last updated.
+	twoDaysAgo := time.Now().Add(-48 * time.Hour)
+	tests := map[string]struct {
+		retention  time.Duration
+		wantPurged int64
+		// wantAuthor is the author of the comment and the uploader of
+		// the attachment the purged account left on the task of the owner.
+		wantAuthor string
+	}{
+		"nothing expired": {
+			retention:  72 * time.Hour,
+			wantAuthor: purged.Id,
+		},
+		"keeps comments and attachments of a purged account": {
+			retention:  24 * time.Hour,
+			wantPurged: 5,
+			wantAuthor: "",
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutAccount(types.Account{Id: purged.Id, Name: purged.Name, Email: purged.Email, Deleted: true, UpdatedAt: twoDaysAgo})
+		m.PutProject(types.Project{Id: purgedProjectId, Name: "Purged", OwnerId: purged.Id, Deleted: true, DeletedAt: &twoDaysAgo})
+		m.PutStatus(types.Status{Id: purgedStatusId, Name: "todo", ProjectId: purgedProjectId, Deleted: true, DeletedAt: &twoDaysAgo})
+		m.PutTask(types.Task{Id: purgedTaskId, Name: "task", ProjectId: purgedProjectId, StatusId: purgedStatusId, Deleted: true, DeletedAt: &twoDaysAgo})
+		m.PutProject(types.Project{Id: pId, Name: "Project", OwnerId: owner.Id})
+		m.PutStatus(types.Status{Id: statusId, Name: "todo", ProjectId: pId})
+		m.PutTask(types.Task{Id: taskId, Name: "task", ProjectId: pId, StatusId: statusId})
+		m.PutTask(types.Task{Id: trashedTaskId, Name: "trashed", ProjectId: pId, StatusId: statusId, Deleted: true, DeletedAt: &twoDaysAgo})
+		m.PutComment(types.Comment{Id: commentId, TaskId: taskId, AuthorId: purged.Id, Body: "comment"})
+		m.PutComment(types.Comment{Id: replyId, TaskId: taskId, AuthorId: owner.Id, ParentId: commentId, Body: "reply"})
+		m.PutAttachment(types.Attachment{Id: attachmentId, TaskId: taskId, UploaderId: purged.Id, Name: "file.txt", ContentType: "text/plain"})
+
+		t.Run(name, func(t *testing.T) {
+			got, err := s.Purge(context.Background(), tt.retention)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.wantPurged, got); diff != "" {
+				t.Fatalf("Purge() mismatch (-want +got):\n%s", diff)
+			}
+
+			ctx := WithAccountId(context.Background(), owner.Id)
+			comments, err := s.GetCommentsByTaskId(ctx, taskId, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			attachments, err := s.GetAttachmentsByTaskId(ctx, taskId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(comments.Items) != 1 || len(attachments) != 1 {
+				t.Fatal("Purge() deleted the comment or the attachment of another project")
+			}
+			author, uploader := comments.Items[0].AuthorId, attachments[0].UploaderId
+			replies := len(comments.Items[0].Replies)
+			if diff := cmp.Diff(tt.wantAuthor, author); diff != "" {
+				t.Fatalf("Purge() comment author mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantAuthor, uploader); diff != "" {
+				t.Fatalf("Purge() attachment uploader mismatch (-want +got):\n%s", diff)
+			}
+			if replies != 1 {
+				t.Fatalf("Purge() left %d replies to the comment, want 1", replies)
+			}
+		})
+	}
+}
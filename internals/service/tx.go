@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// MaxTxAttempts is how many times a transaction is run before
+// ErrConcurrentUpdate is returned to the caller.
+const MaxTxAttempts = 3
+
+// txRetryDelay is the delay before the second attempt of a transaction,
+// every next attempt waits twice as long.
+const txRetryDelay = 10 * time.Millisecond
+
+// querier runs queries either in a transaction or outside of it.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// transact runs fn in a serializable transaction and commits it if fn succeeds.
+// The context passed to fn carries the transaction, so service methods fn
+// calls join it instead of starting their own. A transaction that fails
+// because of a serialization failure or a deadlock is run again from the
+// start, so fn must not have side effects outside of tx and must pass
+// errors of the database through dbError.
+//
+// Returned errors: the errors of fn, ErrConcurrentUpdate, ErrInternal
+func transact(ctx context.Context, db *sql.DB, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx, tx)
+	}
+	if db == nil {
+		return ErrInternal
+	}
+
+	delay := txRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, db, fn)
+		if !errors.Is(err, ErrConcurrentUpdate) || attempt == MaxTxAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func runTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return ErrInternal
+	}
+	defer tx.Rollback()
+
+	if err = fn(context.WithValue(ctx, txKey{}, tx), tx); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		if retryable(err) {
+			return concurrentUpdate()
+		}
+		return ErrInternal
+	}
+
+	return nil
+}
+
+// transact runs fn in a transaction of the database of the service, see transact.
+// A service without a database, e.g. one that keeps accounts in memory,
+// runs fn with a nil tx.
+func (s *Service) transact(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	if s.DB == nil {
+		return fn(ctx, nil)
+	}
+	return transact(ctx, s.DB, fn)
+}
+
+// conn returns the transaction ctx carries or db if there's none.
+// Without db queries fail, e.g. queries of a Postgres repository of
+// a service that keeps the rest of its data in memory.
+func conn(ctx context.Context, db *sql.DB) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	if db == nil {
+		return noDB
+	}
+	return db
+}
+
+// errNoDB is the error of every query of noDB, dbError turns it into ErrInternal.
+var errNoDB = errors.New("no database")
+
+// noDB is a database that can't be connected to.
+var noDB = sql.OpenDB(noConnector{})
+
+type noConnector struct{}
+
+func (noConnector) Connect(context.Context) (driver.Conn, error) { return nil, errNoDB }
+func (noConnector) Driver() driver.Driver                        { return noConnector{} }
+func (noConnector) Open(string) (driver.Conn, error)             { return nil, errNoDB }
+
+// retryable reports whether err is a failure of a transaction
+// that can succeed if the transaction is run again.
+func retryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == pqSerializationFailure || pqErr.Code == pqDeadlockDetected
+}
+
+func concurrentUpdate() error {
+	return &Error{Err: ErrConcurrentUpdate, Message: "the data was changed concurrently, try again"}
+}
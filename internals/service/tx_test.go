@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func TestTransact(t *testing.T) {
+	s, cleanup := setupService(t)
+
+	insert := func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "INSERT INTO accounts (id, email, name) VALUES ($1, $2, $3)", uuid.NewString(), uuid.NewString()+"@test.com", "username")
+		return err
+	}
+	errFailed := errors.New("failed")
+	tests := map[string]struct {
+		wantErr   error
+		wantCount int
+		fn        func(ctx context.Context, tx *sql.Tx) error
+	}{
+		"commits": {
+			wantCount: 1,
+			fn:        insert,
+		},
+		"rolls back on error": {
+			wantErr: errFailed,
+			fn: func(ctx context.Context, tx *sql.Tx) error {
+				if err := insert(ctx, tx); err != nil {
+					return err
+				}
+				return errFailed
+			},
+		},
+		"nested calls join the transaction": {
+			wantErr: errFailed,
+			fn: func(ctx context.Context, tx *sql.Tx) error {
+				err := s.transact(ctx, func(ctx context.Context, nested *sql.Tx) error {
+					if nested != tx {
+						t.Fatal("transact() started a nested transaction")
+					}
+					return insert(ctx, nested)
+				})
+				if err != nil {
+					return err
+				}
+				return errFailed
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Cleanup(cleanup("accounts"))
+
+			err := s.transact(context.Background(), tt.fn)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("transact() mismatch (-want +got):\n%s", diff)
+			}
+			var count int
+			if err = s.DB.QueryRow("SELECT count(*) FROM accounts").Scan(&count); err != nil {
+				t.Fatal(err)
+			}
+			if count != tt.wantCount {
+				t.Fatalf("transact() left %d accounts, want %d", count, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestWithoutDB(t *testing.T) {
+	s := &Service{Secret: []byte("secret")}
+	caller := uuid.NewString()
+	ctx := WithAccountId(context.Background(), caller)
+
+	tests := map[string]func() error{
+		"query": func() error {
+			_, err := s.GetAccountById(ctx, caller)
+			return err
+		},
+		"transaction of the service": func() error {
+			return s.DeleteAccountById(ctx, caller)
+		},
+		"transaction of a repository": func() error {
+			return (&PostgresTrash{}).RestoreStatus(ctx, uuid.NewString())
+		},
+	}
+
+	for name, fn := range tests {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(ErrInternal, fn(), cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// txConn is a database connection that only runs empty transactions,
+// it records the isolation level of every transaction it begins.
+type txConn struct {
+	isolations []sql.IsolationLevel
+}
+
+func (c *txConn) Connect(context.Context) (driver.Conn, error) { return c, nil }
+func (c *txConn) Driver() driver.Driver                        { return c }
+func (c *txConn) Open(string) (driver.Conn, error)             { return c, nil }
+func (c *txConn) Prepare(string) (driver.Stmt, error)          { return nil, errors.New("not supported") }
+func (c *txConn) Close() error                                 { return nil }
+func (c *txConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+func (c *txConn) Commit() error   { return nil }
+func (c *txConn) Rollback() error { return nil }
+
+func (c *txConn) BeginTx(_ context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.isolations = append(c.isolations, sql.IsolationLevel(opts.Isolation))
+	return c, nil
+}
+
+func TestTransactRetry(t *testing.T) {
+	tests := map[string]struct {
+		wantErr      error
+		failures     int
+		failure      error
+		wantAttempts int
+	}{
+		"succeeds after a serialization failure": {
+			failures:     1,
+			failure:      &pq.Error{Code: pqSerializationFailure},
+			wantAttempts: 2,
+		},
+		"succeeds after a deadlock": {
+			failures:     2,
+			failure:      &pq.Error{Code: pqDeadlockDetected},
+			wantAttempts: 3,
+		},
+		"gives up after MaxTxAttempts": {
+			wantErr:      ErrConcurrentUpdate,
+			failures:     MaxTxAttempts,
+			failure:      &pq.Error{Code: pqSerializationFailure},
+			wantAttempts: MaxTxAttempts,
+		},
+		"doesn't retry other failures": {
+			wantErr:      ErrConflict,
+			failures:     1,
+			failure:      &pq.Error{Code: pqUniqueViolation},
+			wantAttempts: 1,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			conn := new(txConn)
+			db := sql.OpenDB(conn)
+			defer db.Close()
+
+			attempts := 0
+			err := transact(context.Background(), db, func(ctx context.Context, tx *sql.Tx) error {
+				attempts++
+				if attempts <= tt.failures {
+					return dbError(tt.failure)
+				}
+				return nil
+			})
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("transact() mismatch (-want +got):\n%s", diff)
+			}
+			if attempts != tt.wantAttempts {
+				t.Fatalf("transact() ran fn %d times, want %d", attempts, tt.wantAttempts)
+			}
+			if len(conn.isolations) != tt.wantAttempts {
+				t.Fatalf("transact() began %d transactions, want %d", len(conn.isolations), tt.wantAttempts)
+			}
+			for _, level := range conn.isolations {
+				if level != sql.LevelSerializable {
+					t.Fatalf("transact() began a transaction with isolation %v, want %v", level, sql.LevelSerializable)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ETag returns the entity tag of v, a resource as it's returned to clients.
+// The tag is a hash of the JSON of v rather than its version, so it changes
+// with fields that come from other rows too, e.g. labels of a task or
+// the number of tasks of a status.
+//
+// Returned errors: ErrInternal
+func ETag(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", ErrInternal
+	}
+	sum := sha256.Sum256(data)
+
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// WithETag returns a copy of ctx that makes the next update or delete of
+// an account, a project, a status or a task fail with ErrPreconditionFailed
+// unless the resource still has the entity tag.
+func WithETag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, etagKey, tag)
+}
+
+// ETagFromContext returns the entity tag the resource being changed is expected to have.
+func ETagFromContext(ctx context.Context) (string, bool) {
+	tag, ok := ctx.Value(etagKey).(string)
+	return tag, ok
+}
+
+// checkETag compares the entity tag of the resource id returned by get with
+// the one ctx carries. Callers run it in the transaction of the change, so
+// the resource can't change in between. A missing resource passes the check,
+// so the change that follows reports it.
+//
+// Returned errors: ErrPreconditionFailed, the errors of get but ErrNotFound
+func checkETag[T any](ctx context.Context, id string, get func(context.Context, string) (*T, error)) error {
+	want, ok := ETagFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	v, err := get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	tag, err := ETag(v)
+	if err != nil {
+		return err
+	}
+	if tag != want {
+		return &Error{Err: ErrPreconditionFailed, Message: "entity tag is " + tag + ", not " + want}
+	}
+
+	return nil
+}
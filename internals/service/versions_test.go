@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+)
+
+func TestETag(t *testing.T) {
+	task := types.Task{Id: uuid.NewString(), Name: "task", Version: 1}
+	tag, err := ETag(&task)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		task     types.Task
+		wantSame bool
+	}{
+		"same task": {
+			task:     task,
+			wantSame: true,
+		},
+		"changed field": {
+			task: types.Task{Id: task.Id, Name: "new name", Version: 2},
+		},
+		"changed labels of the same version": {
+			task: types.Task{Id: task.Id, Name: task.Name, Version: 1, Labels: []types.Label{{Name: "bug"}}},
+		},
+		"changed progress of the same version": {
+			task: types.Task{Id: task.Id, Name: task.Name, Version: 1, Progress: &types.Progress{Total: 1}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ETag(&tt.task)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.wantSame, got == tag); diff != "" {
+				t.Fatalf("ETag() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUpdateTaskETag(t *testing.T) {
+	owner := types.Account{
+		Id:    uuid.NewString(),
+		Name:  "username",
+		Email: "username@test.com",
+	}
+	pId := uuid.NewString()
+	sId := uuid.NewString()
+	tId := uuid.NewString()
+	tests := map[string]struct {
+		wantErr error
+		// change changes the task after its entity tag is taken.
+		change func(ctx context.Context, s *Service) error
+	}{
+		"unchanged task": {
+			change: func(ctx context.Context, s *Service) error { return nil },
+		},
+		"changed task": {
+			wantErr: ErrPreconditionFailed,
+			change: func(ctx context.Context, s *Service) error {
+				return s.UpdateTask(ctx, &UpdateTaskInput{Id: tId, Name: types.PatchOf("New name")})
+			},
+		},
+		"assigned task": {
+			wantErr: ErrPreconditionFailed,
+			change: func(ctx context.Context, s *Service) error {
+				return s.AssignTask(ctx, &AssignTaskInput{TaskId: tId, AccountId: owner.Id})
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		s, m := setupMemory()
+		m.PutAccount(owner)
+		m.PutProject(types.Project{Id: pId, Name: "Project", OwnerId: owner.Id})
+		m.PutStatus(types.Status{Id: sId, Name: "todo", ProjectId: pId})
+		m.PutTask(types.Task{Id: tId, Name: "task", ProjectId: pId, StatusId: sId})
+
+		t.Run(name, func(t *testing.T) {
+			ctx := WithAccountId(context.Background(), owner.Id)
+			task, err := s.GetTaskById(ctx, tId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tag, err := ETag(task)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = tt.change(ctx, s); err != nil {
+				t.Fatal(err)
+			}
+
+			err = s.UpdateTask(WithETag(ctx, tag), &UpdateTaskInput{Id: tId, Priority: types.PatchOf(types.PriorityHigh)})
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("UpdateTask() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/danblok/pm/internals/types"
+)
+
+// DefaultWorkflowName is the workflow of new projects unless
+// Service.DefaultWorkflow or the project asks for another one.
+const DefaultWorkflowName = "basic"
+
+// DefaultWorkflows are the workflows new projects can start with
+// unless Service.Workflows are configured.
+var DefaultWorkflows = []types.Workflow{
+	{
+		Name: "basic",
+		Statuses: []types.WorkflowStatus{
+			{Name: "To Do", Category: types.CategoryTodo},
+			{Name: "In Progress", Category: types.CategoryInProgress},
+			{Name: "Done", Category: types.CategoryDone},
+		},
+		Transitions: []types.WorkflowTransition{},
+	},
+	{
+		Name: "kanban",
+		Statuses: []types.WorkflowStatus{
+			{Name: "Backlog", Category: types.CategoryBacklog},
+			{Name: "To Do", Category: types.CategoryTodo},
+			{Name: "In Progress", Category: types.CategoryInProgress},
+			{Name: "Review", Category: types.CategoryInProgress},
+			{Name: "Done", Category: types.CategoryDone},
+		},
+		Transitions: []types.WorkflowTransition{},
+	},
+	{
+		Name: "bugs",
+		Statuses: []types.WorkflowStatus{
+			{Name: "Open", Category: types.CategoryTodo},
+			{Name: "In Progress", Category: types.CategoryInProgress},
+			{Name: "Fixed", Category: types.CategoryDone},
+			{Name: "Won't Fix", Category: types.CategoryCancelled},
+		},
+		Transitions: []types.WorkflowTransition{
+			{From: "Open", To: "In Progress"},
+			{From: "Open", To: "Won't Fix"},
+			{From: "In Progress", To: "Open"},
+			{From: "In Progress", To: "Fixed"},
+			{From: "Fixed", To: "Open"},
+			{From: "Won't Fix", To: "Open"},
+		},
+	},
+	{
+		Name:        "blank",
+		Statuses:    []types.WorkflowStatus{},
+		Transitions: []types.WorkflowTransition{},
+	},
+}
+
+// GetWorkflows returns the workflows new projects can start with.
+func (s *Service) GetWorkflows() []types.Workflow {
+	if s.Workflows == nil {
+		return DefaultWorkflows
+	}
+	return s.Workflows
+}
+
+// GetWorkflow returns the workflow by its name,
+// an empty name is the default workflow.
+//
+// Returned errors: ErrNotFound
+func (s *Service) GetWorkflow(name string) (*types.Workflow, error) {
+	if name == "" {
+		name = s.DefaultWorkflow
+	}
+	if name == "" {
+		name = DefaultWorkflowName
+	}
+
+	wfs := s.GetWorkflows()
+	for i := range wfs {
+		if wfs[i].Name == name {
+			return &wfs[i], nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// seedWorkflow adds statuses and transitions of the workflow wf
+// to the new project pId.
+//
+// Returned errors: ErrFailedValidation, ErrInternal, ErrConflict, ErrUnprocessable
+func seedWorkflow(ctx context.Context, tx *sql.Tx, pId string, wf *types.Workflow) ([]types.Status, error) {
+	sts := make([]types.Status, 0, len(wf.Statuses))
+	ids := make(map[string]string, len(wf.Statuses))
+	query := "INSERT INTO statuses (name, project_id, category, position) VALUES ($1, $2, $3, $4) RETURNING " + statusColumns
+	for i, ws := range wf.Statuses {
+		var st types.Status
+		row := tx.QueryRowContext(ctx, query, ws.Name, pId, ws.Category, int64(i+1)*positionGap)
+		if err := scanStatus(row, &st); err != nil {
+			return nil, dbError(err)
+		}
+		sts = append(sts, st)
+		ids[st.Name] = st.Id
+	}
+
+	query = "INSERT INTO status_transitions (project_id, from_status_id, to_status_id) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING"
+	for _, tr := range wf.Transitions {
+		from, okFrom := ids[tr.From]
+		to, okTo := ids[tr.To]
+		if !okFrom || !okTo {
+			return nil, ErrInternal
+		}
+		if _, err := tx.ExecContext(ctx, query, pId, from, to); err != nil {
+			return nil, dbError(err)
+		}
+	}
+
+	return sts, nil
+}
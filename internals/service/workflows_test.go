@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/danblok/pm/internals/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestGetWorkflow(t *testing.T) {
+	custom := []types.Workflow{{Name: "custom"}, {Name: "other"}}
+	tests := map[string]struct {
+		service  *Service
+		input    string
+		wantName string
+		wantErr  error
+	}{
+		"default": {
+			service:  &Service{},
+			wantName: DefaultWorkflowName,
+		},
+		"by name": {
+			service:  &Service{},
+			input:    "kanban",
+			wantName: "kanban",
+		},
+		"configured default": {
+			service:  &Service{Workflows: custom, DefaultWorkflow: "other"},
+			wantName: "other",
+		},
+		"not configured": {
+			service: &Service{Workflows: custom},
+			input:   "kanban",
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tt.service.GetWorkflow(tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("GetWorkflow() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.wantName, got.Name); diff != "" {
+				t.Fatalf("GetWorkflow() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDefaultWorkflows(t *testing.T) {
+	for _, wf := range DefaultWorkflows {
+		names := make(map[string]bool)
+		for _, st := range wf.Statuses {
+			if !validCategory(st.Category) {
+				t.Errorf("workflow %q: status %q has invalid category %q", wf.Name, st.Name, st.Category)
+			}
+			names[st.Name] = true
+		}
+		for _, tr := range wf.Transitions {
+			if !names[tr.From] || !names[tr.To] {
+				t.Errorf("workflow %q: transition %q -> %q references an unknown status", wf.Name, tr.From, tr.To)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestLocal(t *testing.T) {
+	l, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	tests := map[string]struct {
+		key     string
+		body    string
+		wantErr bool
+	}{
+		"plain key": {
+			key:  "file",
+			body: "contents",
+		},
+		"nested key": {
+			key:  "task/file",
+			body: "contents",
+		},
+		"key outside of root": {
+			key:     "../file",
+			wantErr: true,
+		},
+		"absolute key": {
+			key:     "/etc/passwd",
+			wantErr: true,
+		},
+		"empty key": {
+			key:     "",
+			wantErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := l.Put(ctx, tt.key, strings.NewReader(tt.body))
+			if diff := cmp.Diff(tt.wantErr, err != nil); diff != "" {
+				t.Fatalf("Put() mismatch (-want +got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+
+			r, err := l.Open(ctx, tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.body, string(got)); diff != "" {
+				t.Fatalf("Open() mismatch (-want +got):\n%s", diff)
+			}
+
+			if err = l.Delete(ctx, tt.key); err != nil {
+				t.Fatal(err)
+			}
+			_, err = l.Open(ctx, tt.key)
+			if diff := cmp.Diff(ErrNotExist, err, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("Open() after Delete() mismatch (-want +got):\n%s", diff)
+			}
+			if err = l.Delete(ctx, tt.key); err != nil {
+				t.Fatalf("Delete() of a deleted object: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+// Package storage stores contents of files, e.g. task attachments,
+// outside of the database.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrNotExist = errors.New("object doesn't exist")
+
+// Storage stores objects by keys. Implementations must be safe for
+// concurrent use.
+type Storage interface {
+	// Put stores the contents of r under key, replacing an existing object.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Open returns the contents of the object stored under key,
+	// the caller must close it.
+	//
+	// Returned errors: ErrNotExist
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete deletes the object stored under key, deleting a
+	// non-existent object isn't an error.
+	Delete(ctx context.Context, key string) error
+}
@@ -1,9 +1,20 @@
 package types
 
 import (
+	"encoding/json"
 	"time"
 )
 
+// Role is a role of an account in a project.
+type Role string
+
+const (
+	RoleOwner      Role = "owner"
+	RoleMaintainer Role = "maintainer"
+	RoleMember     Role = "member"
+	RoleViewer     Role = "viewer"
+)
+
 type Account struct {
 	CreatedAt           time.Time `json:"created_at"`
 	UpdatedAt           time.Time `json:"updated_at"`
@@ -11,50 +22,307 @@ type Account struct {
 	Email               string    `json:"email"`
 	Name                string    `json:"name"`
 	Avatar              string    `json:"avatar,omitempty"`
+	Role                Role      `json:"role,omitempty"`
 	OwnedProjects       []Project `json:"owned_projets,omitempty"`
 	ContributedProjects []Project `json:"contributed_projects,omitempty"`
+	Version             int64     `json:"version"`
 	Deleted             bool      `json:"deleted"`
 }
 
+// Project is a project of tasks. With StrictDependencies a task
+// can't start before the tasks blocking it end. With StrictWipLimits
+// tasks can't be moved to a status at its WipLimit, otherwise the status
+// is only flagged with OverLimit.
 type Project struct {
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Owner        *Account  `json:"owner,omitempty"`
-	Id           string    `json:"id"`
-	Name         string    `json:"name"`
-	Description  string    `json:"description"`
-	OwnerId      string    `json:"owner_id"`
-	Contributors []Account `json:"contributors,omitempty"`
-	Tasks        []Task    `json:"tasks,omitempty"`
-	Statuses     []Status  `json:"statuses,omitempty"`
-	Deleted      bool      `json:"deleted"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	DeletedAt          *time.Time `json:"deleted_at,omitempty"`
+	Owner              *Account   `json:"owner,omitempty"`
+	Id                 string     `json:"id"`
+	Name               string     `json:"name"`
+	Description        string     `json:"description"`
+	OwnerId            string     `json:"owner_id"`
+	Contributors       []Account  `json:"contributors,omitempty"`
+	Tasks              []Task     `json:"tasks,omitempty"`
+	Statuses           []Status   `json:"statuses,omitempty"`
+	StrictDependencies bool       `json:"strict_dependencies"`
+	Version            int64      `json:"version"`
+	StrictWipLimits    bool       `json:"strict_wip_limits"`
+	Deleted            bool       `json:"deleted"`
 }
 
+// Priority is a priority of a task.
+type Priority string
+
+const (
+	PriorityNone   Priority = "none"
+	PriorityLow    Priority = "low"
+	PriorityMedium Priority = "medium"
+	PriorityHigh   Priority = "high"
+	PriorityUrgent Priority = "urgent"
+)
+
+// Task is a task of a project. Subtasks reference their parent with ParentId,
+// Children and Progress are only loaded for a task tree.
 type Task struct {
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	Start       time.Time  `json:"start"`
+	End         time.Time  `json:"end"`
+	Status      *Status    `json:"status"`
+	Project     *Project   `json:"project"`
+	Progress    *Progress  `json:"progress,omitempty"`
+	Assignees   []Account  `json:"assignees,omitempty"`
+	Labels      []Label    `json:"labels,omitempty"`
+	Children    []Task     `json:"children,omitempty"`
+	Id          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Priority    Priority   `json:"priority"`
+	StatusId    string     `json:"status_id"`
+	ProjectId   string     `json:"project_id"`
+	ParentId    string     `json:"parent_id,omitempty"`
+	Position    int64      `json:"position"`
+	Version     int64      `json:"version"`
+	Deleted     bool       `json:"deleted"`
+}
+
+// Trash holds deleted records that can be restored until they are purged.
+type Trash struct {
+	Projects []Project `json:"projects,omitempty"`
+	Statuses []Status  `json:"statuses,omitempty"`
+	Tasks    []Task    `json:"tasks,omitempty"`
+}
+
+// Progress is the completion of all subtasks of a task at any depth.
+type Progress struct {
+	Total int `json:"total"`
+	Done  int `json:"done"`
+}
+
+// Label is a project scoped tag of tasks. Color is in "#rrggbb" format.
+type Label struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
-	Start     time.Time `json:"start"`
-	End       time.Time `json:"end"`
-	Status    *Status   `json:"status"`
-	Project   *Project  `json:"project"`
 	Id        string    `json:"id"`
 	Name      string    `json:"name"`
-	StatusId  string    `json:"status_id"`
+	Color     string    `json:"color"`
 	ProjectId string    `json:"project_id"`
 	Deleted   bool      `json:"deleted"`
 }
 
+// Status is a column of the board of a project. TaskCount is the number of
+// tasks in the status, a nil WipLimit means there's no limit on it.
 type Status struct {
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	Project   *Project   `json:"project,omitempty"`
+	Id        string     `json:"id"`
+	Name      string     `json:"name"`
+	ProjectId string     `json:"project_id"`
+	Tasks     []Task     `json:"tasks"`
+	Category  Category   `json:"category"`
+	Position  int64      `json:"position"`
+	WipLimit  *int64     `json:"wip_limit"`
+	TaskCount int64      `json:"task_count"`
+	Version   int64      `json:"version"`
+	OverLimit bool       `json:"over_limit"`
+	Done      bool       `json:"done"`
+	Deleted   bool       `json:"deleted"`
+}
+
+// Category tells what a status means regardless of its name.
+// Tasks in a status of CategoryDone or CategoryCancelled are Done.
+type Category string
+
+const (
+	CategoryBacklog    Category = "backlog"
+	CategoryTodo       Category = "todo"
+	CategoryInProgress Category = "in-progress"
+	CategoryDone       Category = "done"
+	CategoryCancelled  Category = "cancelled"
+)
+
+// Transition allows tasks to move from one status to another.
+type Transition struct {
+	FromStatusId string `json:"from_status_id"`
+	ToStatusId   string `json:"to_status_id"`
+}
+
+// Workflow is a template of statuses and transitions between them
+// new projects start with. Transitions reference statuses by name.
+type Workflow struct {
+	Name        string               `json:"name"`
+	Statuses    []WorkflowStatus     `json:"statuses"`
+	Transitions []WorkflowTransition `json:"transitions"`
+}
+
+type WorkflowStatus struct {
+	Name     string   `json:"name"`
+	Category Category `json:"category"`
+}
+
+type WorkflowTransition struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Comment is a comment of a task. Replies are only loaded for top-level comments.
+// AuthorId is empty once the author's account is purged.
+type Comment struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
-	Project   *Project  `json:"project,omitempty"`
 	Id        string    `json:"id"`
-	Name      string    `json:"name"`
-	ProjectId string    `json:"project_id"`
-	Tasks     []Task    `json:"tasks"`
+	TaskId    string    `json:"task_id"`
+	AuthorId  string    `json:"author_id"`
+	ParentId  string    `json:"parent_id,omitempty"`
+	Body      string    `json:"body"`
+	Edited    bool      `json:"edited"`
+	Replies   []Comment `json:"replies,omitempty"`
 	Deleted   bool      `json:"deleted"`
 }
 
+// CommentRevision is a body a comment had before it was edited.
+type CommentRevision struct {
+	CreatedAt time.Time `json:"created_at"`
+	Id        string    `json:"id"`
+	CommentId string    `json:"comment_id"`
+	Body      string    `json:"body"`
+}
+
+// Attachment is metadata of a file attached to a task, its contents
+// are kept in a storage under Id. UploaderId is empty once the uploader's
+// account is purged.
+type Attachment struct {
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Id          string    `json:"id"`
+	TaskId      string    `json:"task_id"`
+	UploaderId  string    `json:"uploader_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Deleted     bool      `json:"deleted"`
+}
+
+// Dependency means the task BlockerId blocks the task BlockedId,
+// i.e. BlockedId can't start until BlockerId is done.
+type Dependency struct {
+	CreatedAt time.Time `json:"created_at"`
+	BlockerId string    `json:"blocker_id"`
+	BlockedId string    `json:"blocked_id"`
+}
+
+// Dependencies are the tasks a task is blocked by and the tasks it blocks.
+type Dependencies struct {
+	BlockedBy []Task `json:"blocked_by"`
+	Blocks    []Task `json:"blocks"`
+}
+
+// Timeline is the schedule of tasks of a project for a Gantt chart. Tasks are
+// ordered by their start, CriticalPath lists ids of the tasks that can't be
+// delayed without delaying the end of the project, from the first to the last.
+type Timeline struct {
+	Start        time.Time      `json:"start"`
+	End          time.Time      `json:"end"`
+	Tasks        []TimelineTask `json:"tasks"`
+	Dependencies []Dependency   `json:"dependencies"`
+	CriticalPath []string       `json:"critical_path"`
+}
+
+// TimelineTask is a task on a timeline. A task can't start before its Start
+// or before its blockers end. Slack is in seconds.
+type TimelineTask struct {
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	EarliestStart time.Time `json:"earliest_start"`
+	LatestStart   time.Time `json:"latest_start"`
+	Id            string    `json:"id"`
+	Name          string    `json:"name"`
+	StatusId      string    `json:"status_id"`
+	ParentId      string    `json:"parent_id,omitempty"`
+	Slack         int64     `json:"slack"`
+	Critical      bool      `json:"critical"`
+}
+
+// Page is a single page of a list. NextCursor is empty on the last page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Patch is a field of a JSON merge patch (RFC 7396). A field absent from
+// the patch isn't Set, a field set to null is Set and Null.
+type Patch[T any] struct {
+	Value T
+	Set   bool
+	Null  bool
+}
+
+// PatchOf returns a field of a patch set to v.
+func PatchOf[T any](v T) Patch[T] {
+	return Patch[T]{Value: v, Set: true}
+}
+
+// PatchNull returns a field of a patch set to null.
+func PatchNull[T any]() Patch[T] {
+	return Patch[T]{Set: true, Null: true}
+}
+
+func (p *Patch[T]) UnmarshalJSON(b []byte) error {
+	*p = Patch[T]{Set: true}
+	if string(b) == "null" {
+		p.Null = true
+		return nil
+	}
+	return json.Unmarshal(b, &p.Value)
+}
+
+func (p Patch[T]) MarshalJSON() ([]byte, error) {
+	if !p.Set || p.Null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(p.Value)
+}
+
 type HTTPError struct {
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
 	Message string `json:"message"`
 }
+
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// InvitationStatus is a state of an invitation to a project.
+type InvitationStatus string
+
+const (
+	InvitationPending  InvitationStatus = "pending"
+	InvitationAccepted InvitationStatus = "accepted"
+	InvitationDeclined InvitationStatus = "declined"
+)
+
+type Invitation struct {
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	ExpiresAt time.Time        `json:"expires_at"`
+	Id        string           `json:"id"`
+	ProjectId string           `json:"project_id"`
+	InviterId string           `json:"inviter_id"`
+	InviteeId string           `json:"invitee_id,omitempty"`
+	Email     string           `json:"email"`
+	Role      Role             `json:"role"`
+	Status    InvitationStatus `json:"status"`
+	Token     string           `json:"token,omitempty"`
+	Deleted   bool             `json:"deleted"`
+}
@@ -0,0 +1,3 @@
+-- Existing accounts get no password, they set one with a setup token (see README).
+ALTER TABLE accounts
+ADD COLUMN "password_hash" TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE projects_to_accounts DROP CONSTRAINT IF EXISTS projects_to_accounts_role_check;
+ALTER TABLE projects_to_accounts DROP COLUMN IF EXISTS "role";
@@ -0,0 +1,6 @@
+ALTER TABLE projects_to_accounts
+ADD COLUMN "role" TEXT NOT NULL DEFAULT 'member';
+
+ALTER TABLE projects_to_accounts
+ADD CONSTRAINT projects_to_accounts_role_check
+CHECK (role IN ('maintainer', 'member', 'viewer'));
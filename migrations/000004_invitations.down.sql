@@ -0,0 +1,7 @@
+BEGIN;
+ALTER TABLE invitations DROP CONSTRAINT fk_invitations_invitees;
+ALTER TABLE invitations DROP CONSTRAINT fk_invitations_inviters;
+ALTER TABLE invitations DROP CONSTRAINT fk_invitations_projects;
+
+DROP TABLE IF EXISTS invitations;
+COMMIT;
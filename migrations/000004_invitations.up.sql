@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS invitations (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "project_id" uuid NOT NULL,
+    "inviter_id" uuid NOT NULL,
+    "invitee_id" uuid,
+    "email" TEXT NOT NULL,
+    "role" TEXT NOT NULL DEFAULT 'member',
+    "status" TEXT NOT NULL DEFAULT 'pending',
+    "token" TEXT UNIQUE NOT NULL,
+    "expires_at" TIMESTAMP(3) NOT NULL,
+    "deleted" BOOLEAN DEFAULT FALSE,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT now(),
+    "updated_at" TIMESTAMP(3) NOT NULL DEFAULT now(),
+    PRIMARY KEY(id),
+    CHECK (role IN ('maintainer', 'member', 'viewer')),
+    CHECK (status IN ('pending', 'accepted', 'declined'))
+);
+
+CREATE INDEX invitations_email_idx
+ON invitations(lower(email));
+
+ALTER TABLE invitations
+ADD CONSTRAINT fk_invitations_projects
+FOREIGN KEY (project_id) REFERENCES projects(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE invitations
+ADD CONSTRAINT fk_invitations_inviters
+FOREIGN KEY (inviter_id) REFERENCES accounts(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE invitations
+ADD CONSTRAINT fk_invitations_invitees
+FOREIGN KEY (invitee_id) REFERENCES accounts(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
@@ -0,0 +1,6 @@
+BEGIN;
+ALTER TABLE tasks_to_accounts DROP CONSTRAINT fk_tasks_to_accounts_accounts;
+ALTER TABLE tasks_to_accounts DROP CONSTRAINT fk_tasks_to_accounts_tasks;
+
+DROP TABLE IF EXISTS tasks_to_accounts;
+COMMIT;
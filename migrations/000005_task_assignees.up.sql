@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS tasks_to_accounts (
+    "task_id" uuid NOT NULL,
+    "account_id" uuid NOT NULL,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX tasks_to_accounts_task_account_unique
+ON tasks_to_accounts(task_id, account_id);
+
+CREATE INDEX tasks_to_accounts_account_id
+ON tasks_to_accounts(account_id);
+
+ALTER TABLE tasks_to_accounts
+ADD CONSTRAINT fk_tasks_to_accounts_tasks
+FOREIGN KEY (task_id) REFERENCES tasks(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE tasks_to_accounts
+ADD CONSTRAINT fk_tasks_to_accounts_accounts
+FOREIGN KEY (account_id) REFERENCES accounts(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
@@ -0,0 +1,8 @@
+BEGIN;
+DROP TABLE IF EXISTS tasks_to_labels;
+DROP TABLE IF EXISTS labels;
+
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_priority_check;
+ALTER TABLE tasks DROP COLUMN IF EXISTS "priority";
+ALTER TABLE tasks DROP COLUMN IF EXISTS "description";
+COMMIT;
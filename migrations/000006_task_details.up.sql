@@ -0,0 +1,50 @@
+ALTER TABLE tasks
+ADD COLUMN "description" TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE tasks
+ADD COLUMN "priority" TEXT NOT NULL DEFAULT 'none';
+
+ALTER TABLE tasks
+ADD CONSTRAINT tasks_priority_check
+CHECK (priority IN ('none', 'low', 'medium', 'high', 'urgent'));
+
+CREATE TABLE IF NOT EXISTS labels (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "name" TEXT NOT NULL,
+    "color" TEXT NOT NULL,
+    "project_id" uuid NOT NULL,
+    "deleted" BOOLEAN DEFAULT FALSE,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT now(),
+    "updated_at" TIMESTAMP(3) NOT NULL DEFAULT now(),
+    PRIMARY KEY(id),
+    CONSTRAINT labels_color_check CHECK (color ~ '^#[0-9a-fA-F]{6}$')
+);
+
+CREATE UNIQUE INDEX label_name_project_id_unique
+ON labels(name, project_id) WHERE deleted=false;
+
+CREATE TABLE IF NOT EXISTS tasks_to_labels (
+    "task_id" uuid NOT NULL,
+    "label_id" uuid NOT NULL
+);
+
+CREATE UNIQUE INDEX tasks_to_labels_task_label_unique
+ON tasks_to_labels(task_id, label_id);
+
+CREATE INDEX tasks_to_labels_label_id
+ON tasks_to_labels(label_id);
+
+ALTER TABLE labels
+ADD CONSTRAINT fk_labels_projects
+FOREIGN KEY (project_id) REFERENCES projects(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE tasks_to_labels
+ADD CONSTRAINT fk_tasks_to_labels_tasks
+FOREIGN KEY (task_id) REFERENCES tasks(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE tasks_to_labels
+ADD CONSTRAINT fk_tasks_to_labels_labels
+FOREIGN KEY (label_id) REFERENCES labels(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
@@ -0,0 +1,4 @@
+BEGIN;
+DROP TABLE IF EXISTS comment_revisions;
+DROP TABLE IF EXISTS comments;
+COMMIT;
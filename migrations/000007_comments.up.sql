@@ -0,0 +1,48 @@
+CREATE TABLE IF NOT EXISTS comments (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "task_id" uuid NOT NULL,
+    "author_id" uuid NOT NULL,
+    "parent_id" uuid,
+    "body" TEXT NOT NULL,
+    "deleted" BOOLEAN DEFAULT FALSE,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT now(),
+    "updated_at" TIMESTAMP(3) NOT NULL DEFAULT now(),
+    PRIMARY KEY(id)
+);
+
+CREATE INDEX comments_task_id
+ON comments(task_id);
+
+CREATE INDEX comments_parent_id
+ON comments(parent_id);
+
+CREATE TABLE IF NOT EXISTS comment_revisions (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "comment_id" uuid NOT NULL,
+    "body" TEXT NOT NULL,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT now(),
+    PRIMARY KEY(id)
+);
+
+CREATE INDEX comment_revisions_comment_id
+ON comment_revisions(comment_id);
+
+ALTER TABLE comments
+ADD CONSTRAINT fk_comments_tasks
+FOREIGN KEY (task_id) REFERENCES tasks(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE comments
+ADD CONSTRAINT fk_comments_authors
+FOREIGN KEY (author_id) REFERENCES accounts(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE comments
+ADD CONSTRAINT fk_comments_parents
+FOREIGN KEY (parent_id) REFERENCES comments(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE comment_revisions
+ADD CONSTRAINT fk_comment_revisions_comments
+FOREIGN KEY (comment_id) REFERENCES comments(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
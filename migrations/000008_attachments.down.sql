@@ -0,0 +1,6 @@
+BEGIN;
+ALTER TABLE attachments DROP CONSTRAINT fk_attachments_uploaders;
+ALTER TABLE attachments DROP CONSTRAINT fk_attachments_tasks;
+
+DROP TABLE IF EXISTS attachments;
+COMMIT;
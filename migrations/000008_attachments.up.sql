@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS attachments (
+    "id" uuid DEFAULT gen_random_uuid(),
+    "task_id" uuid NOT NULL,
+    "uploader_id" uuid NOT NULL,
+    "name" VARCHAR(255) NOT NULL,
+    "content_type" VARCHAR(255) NOT NULL,
+    "size" BIGINT NOT NULL CHECK (size >= 0),
+    "deleted" BOOLEAN DEFAULT FALSE,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT now(),
+    "updated_at" TIMESTAMP(3) NOT NULL DEFAULT now(),
+    PRIMARY KEY(id)
+);
+
+CREATE INDEX attachments_task_id
+ON attachments(task_id);
+
+ALTER TABLE attachments
+ADD CONSTRAINT fk_attachments_tasks
+FOREIGN KEY (task_id) REFERENCES tasks(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE attachments
+ADD CONSTRAINT fk_attachments_uploaders
+FOREIGN KEY (uploader_id) REFERENCES accounts(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
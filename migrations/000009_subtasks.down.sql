@@ -0,0 +1,8 @@
+BEGIN;
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_parent_check;
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS fk_tasks_parents;
+DROP INDEX IF EXISTS tasks_parent_id;
+ALTER TABLE tasks DROP COLUMN IF EXISTS "parent_id";
+
+ALTER TABLE statuses DROP COLUMN IF EXISTS "done";
+COMMIT;
@@ -0,0 +1,17 @@
+ALTER TABLE statuses
+ADD COLUMN "done" BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE tasks
+ADD COLUMN "parent_id" uuid;
+
+CREATE INDEX tasks_parent_id
+ON tasks(parent_id);
+
+ALTER TABLE tasks
+ADD CONSTRAINT fk_tasks_parents
+FOREIGN KEY (parent_id) REFERENCES tasks(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE tasks
+ADD CONSTRAINT tasks_parent_check
+CHECK (parent_id <> id);
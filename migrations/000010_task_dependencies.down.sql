@@ -0,0 +1,8 @@
+BEGIN;
+ALTER TABLE task_dependencies DROP CONSTRAINT fk_task_dependencies_blocked;
+ALTER TABLE task_dependencies DROP CONSTRAINT fk_task_dependencies_blockers;
+
+DROP TABLE IF EXISTS task_dependencies;
+
+ALTER TABLE projects DROP COLUMN IF EXISTS "strict_dependencies";
+COMMIT;
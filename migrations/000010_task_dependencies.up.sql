@@ -0,0 +1,23 @@
+ALTER TABLE projects
+ADD COLUMN "strict_dependencies" BOOLEAN NOT NULL DEFAULT TRUE;
+
+CREATE TABLE IF NOT EXISTS task_dependencies (
+    "blocker_id" uuid NOT NULL,
+    "blocked_id" uuid NOT NULL,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT now(),
+    PRIMARY KEY(blocker_id, blocked_id),
+    CONSTRAINT task_dependencies_self_check CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX task_dependencies_blocked_id
+ON task_dependencies(blocked_id);
+
+ALTER TABLE task_dependencies
+ADD CONSTRAINT fk_task_dependencies_blockers
+FOREIGN KEY (blocker_id) REFERENCES tasks(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE task_dependencies
+ADD CONSTRAINT fk_task_dependencies_blocked
+FOREIGN KEY (blocked_id) REFERENCES tasks(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
@@ -0,0 +1,7 @@
+BEGIN;
+DROP INDEX IF EXISTS tasks_status_id_position;
+DROP INDEX IF EXISTS statuses_project_id_position;
+ALTER TABLE tasks DROP COLUMN IF EXISTS "position";
+
+ALTER TABLE statuses DROP COLUMN IF EXISTS "position";
+COMMIT;
@@ -0,0 +1,19 @@
+ALTER TABLE statuses
+ADD COLUMN "position" BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE tasks
+ADD COLUMN "position" BIGINT NOT NULL DEFAULT 0;
+
+UPDATE statuses s SET position=o.rn * 65536
+FROM (SELECT id, row_number() OVER (PARTITION BY project_id ORDER BY created_at, id) AS rn FROM statuses) o
+WHERE s.id=o.id;
+
+UPDATE tasks t SET position=o.rn * 65536
+FROM (SELECT id, row_number() OVER (PARTITION BY status_id ORDER BY created_at, id) AS rn FROM tasks) o
+WHERE t.id=o.id;
+
+CREATE INDEX statuses_project_id_position
+ON statuses(project_id, position);
+
+CREATE INDEX tasks_status_id_position
+ON tasks(status_id, position);
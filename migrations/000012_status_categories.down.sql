@@ -0,0 +1,8 @@
+BEGIN;
+DROP TABLE IF EXISTS status_transitions;
+
+ALTER TABLE statuses DROP COLUMN IF EXISTS "done";
+ALTER TABLE statuses ADD COLUMN "done" BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE statuses SET done=true WHERE category IN ('done', 'cancelled');
+ALTER TABLE statuses DROP COLUMN IF EXISTS "category";
+COMMIT;
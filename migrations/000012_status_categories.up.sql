@@ -0,0 +1,37 @@
+ALTER TABLE statuses
+ADD COLUMN "category" TEXT NOT NULL DEFAULT 'todo'
+CONSTRAINT statuses_category_check CHECK (category IN ('backlog', 'todo', 'in-progress', 'done', 'cancelled'));
+
+UPDATE statuses SET category='done' WHERE done;
+
+ALTER TABLE statuses DROP COLUMN "done";
+
+ALTER TABLE statuses
+ADD COLUMN "done" BOOLEAN GENERATED ALWAYS AS (category IN ('done', 'cancelled')) STORED;
+
+CREATE TABLE IF NOT EXISTS status_transitions (
+    "project_id" uuid NOT NULL,
+    "from_status_id" uuid NOT NULL,
+    "to_status_id" uuid NOT NULL,
+    "created_at" TIMESTAMP(3) NOT NULL DEFAULT now(),
+    PRIMARY KEY(from_status_id, to_status_id),
+    CONSTRAINT status_transitions_self_check CHECK (from_status_id <> to_status_id)
+);
+
+CREATE INDEX status_transitions_project_id
+ON status_transitions(project_id);
+
+ALTER TABLE status_transitions
+ADD CONSTRAINT fk_status_transitions_projects
+FOREIGN KEY (project_id) REFERENCES projects(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE status_transitions
+ADD CONSTRAINT fk_status_transitions_from
+FOREIGN KEY (from_status_id) REFERENCES statuses(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE status_transitions
+ADD CONSTRAINT fk_status_transitions_to
+FOREIGN KEY (to_status_id) REFERENCES statuses(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
@@ -0,0 +1,5 @@
+BEGIN;
+ALTER TABLE statuses DROP COLUMN IF EXISTS "wip_limit";
+
+ALTER TABLE projects DROP COLUMN IF EXISTS "strict_wip_limits";
+COMMIT;
@@ -0,0 +1,6 @@
+ALTER TABLE projects
+ADD COLUMN "strict_wip_limits" BOOLEAN NOT NULL DEFAULT TRUE;
+
+ALTER TABLE statuses
+ADD COLUMN "wip_limit" BIGINT
+CONSTRAINT statuses_wip_limit_check CHECK (wip_limit > 0);
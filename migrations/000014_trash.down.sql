@@ -0,0 +1,11 @@
+BEGIN;
+DROP INDEX IF EXISTS tasks_deleted_at;
+DROP INDEX IF EXISTS statuses_deleted_at;
+DROP INDEX IF EXISTS projects_deleted_at;
+DROP INDEX IF EXISTS accounts_deleted_at;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE statuses DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE projects DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE accounts DROP COLUMN IF EXISTS "deleted_at";
+COMMIT;
@@ -0,0 +1,14 @@
+ALTER TABLE accounts ADD COLUMN "deleted_at" TIMESTAMP(3);
+ALTER TABLE projects ADD COLUMN "deleted_at" TIMESTAMP(3);
+ALTER TABLE statuses ADD COLUMN "deleted_at" TIMESTAMP(3);
+ALTER TABLE tasks ADD COLUMN "deleted_at" TIMESTAMP(3);
+
+UPDATE accounts SET deleted_at=now() WHERE deleted;
+UPDATE projects SET deleted_at=now() WHERE deleted;
+UPDATE statuses SET deleted_at=now() WHERE deleted;
+UPDATE tasks SET deleted_at=now() WHERE deleted;
+
+CREATE INDEX accounts_deleted_at ON accounts(deleted_at) WHERE deleted;
+CREATE INDEX projects_deleted_at ON projects(deleted_at) WHERE deleted;
+CREATE INDEX statuses_deleted_at ON statuses(deleted_at) WHERE deleted;
+CREATE INDEX tasks_deleted_at ON tasks(deleted_at) WHERE deleted;
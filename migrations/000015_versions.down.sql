@@ -0,0 +1,12 @@
+BEGIN;
+DROP TRIGGER IF EXISTS tasks_version ON tasks;
+DROP TRIGGER IF EXISTS statuses_version ON statuses;
+DROP TRIGGER IF EXISTS projects_version ON projects;
+DROP TRIGGER IF EXISTS accounts_version ON accounts;
+DROP FUNCTION IF EXISTS bump_version();
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS "version";
+ALTER TABLE statuses DROP COLUMN IF EXISTS "version";
+ALTER TABLE projects DROP COLUMN IF EXISTS "version";
+ALTER TABLE accounts DROP COLUMN IF EXISTS "version";
+COMMIT;
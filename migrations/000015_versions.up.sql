@@ -0,0 +1,23 @@
+ALTER TABLE accounts ADD COLUMN "version" BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE projects ADD COLUMN "version" BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE statuses ADD COLUMN "version" BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE tasks ADD COLUMN "version" BIGINT NOT NULL DEFAULT 1;
+
+CREATE OR REPLACE FUNCTION bump_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER accounts_version BEFORE UPDATE ON accounts
+FOR EACH ROW EXECUTE FUNCTION bump_version();
+
+CREATE TRIGGER projects_version BEFORE UPDATE ON projects
+FOR EACH ROW EXECUTE FUNCTION bump_version();
+
+CREATE TRIGGER statuses_version BEFORE UPDATE ON statuses
+FOR EACH ROW EXECUTE FUNCTION bump_version();
+
+CREATE TRIGGER tasks_version BEFORE UPDATE ON tasks
+FOR EACH ROW EXECUTE FUNCTION bump_version();
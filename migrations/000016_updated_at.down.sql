@@ -0,0 +1,7 @@
+BEGIN;
+DROP TRIGGER IF EXISTS tasks_updated_at ON tasks;
+DROP TRIGGER IF EXISTS statuses_updated_at ON statuses;
+DROP TRIGGER IF EXISTS projects_updated_at ON projects;
+DROP TRIGGER IF EXISTS accounts_updated_at ON accounts;
+DROP FUNCTION IF EXISTS touch_updated_at();
+COMMIT;
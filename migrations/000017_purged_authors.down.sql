@@ -0,0 +1,22 @@
+BEGIN;
+ALTER TABLE attachments
+DROP CONSTRAINT IF EXISTS fk_attachments_uploaders,
+ADD CONSTRAINT fk_attachments_uploaders
+FOREIGN KEY (uploader_id) REFERENCES accounts(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE comments
+DROP CONSTRAINT IF EXISTS fk_comments_authors,
+ADD CONSTRAINT fk_comments_authors
+FOREIGN KEY (author_id) REFERENCES accounts(id)
+ON DELETE CASCADE ON UPDATE CASCADE;
+
+DELETE FROM attachments WHERE uploader_id IS NULL;
+DELETE FROM comments WHERE author_id IS NULL;
+
+ALTER TABLE attachments
+ALTER COLUMN "uploader_id" SET NOT NULL;
+
+ALTER TABLE comments
+ALTER COLUMN "author_id" SET NOT NULL;
+COMMIT;
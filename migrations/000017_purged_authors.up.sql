@@ -0,0 +1,17 @@
+ALTER TABLE comments
+ALTER COLUMN "author_id" DROP NOT NULL;
+
+ALTER TABLE attachments
+ALTER COLUMN "uploader_id" DROP NOT NULL;
+
+ALTER TABLE comments
+DROP CONSTRAINT IF EXISTS fk_comments_authors,
+ADD CONSTRAINT fk_comments_authors
+FOREIGN KEY (author_id) REFERENCES accounts(id)
+ON DELETE SET NULL ON UPDATE CASCADE;
+
+ALTER TABLE attachments
+DROP CONSTRAINT IF EXISTS fk_attachments_uploaders,
+ADD CONSTRAINT fk_attachments_uploaders
+FOREIGN KEY (uploader_id) REFERENCES accounts(id)
+ON DELETE SET NULL ON UPDATE CASCADE;